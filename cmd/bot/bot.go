@@ -3,57 +3,167 @@ package bot
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
 
 	"github.com/6ermvH/trash-bot/internal/config"
 	"github.com/6ermvH/trash-bot/internal/handlers/telegram"
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/services/journal"
+	"github.com/6ermvH/trash-bot/internal/services/metrics"
 	"github.com/6ermvH/trash-bot/internal/services/scheduler"
+	"github.com/6ermvH/trash-bot/internal/services/stats"
 	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
 	"github.com/go-telegram/bot"
+	"golang.org/x/sync/errgroup"
 )
 
-func Start(ctx context.Context, cfg *config.Config, trashm *trashmanager.Service) error {
-	opts := []bot.Option{}
+const (
+	// pollTimeout is the long polling timeout of getUpdates, the default of the library.
+	pollTimeout = time.Minute
+	// shutdownTimeout limits waiting for handlers and reminders on shutdown.
+	shutdownTimeout = 5 * time.Second
+)
+
+// NewAPI creates the bot client, it is shared by the bot and the panel.
+// onPoll is called after every successful poll for updates, drainer tracks
+// handled updates so that they are finished on shutdown.
+func NewAPI(cfg *config.Config, onPoll func(), drainer *telegram.Drainer) (*bot.Bot, error) {
+	client := &http.Client{Timeout: pollTimeout}
+
+	return newAPI(cfg.Telegram.BotKey, telegram.ObservePolling(client, onPoll), drainer)
+}
 
-	botApi, err := bot.New(cfg.Telegram.BotKey, opts...)
+func newAPI(token string, client bot.HttpClient, drainer *telegram.Drainer, extra ...bot.Option) (*bot.Bot, error) {
+	opts := []bot.Option{
+		bot.WithMiddlewares(
+			drainer.Middleware,
+			telegram.TraceMiddleware,
+			telegram.LogMiddleware,
+			telegram.ActorMiddleware,
+		),
+		// Обновление передаётся обработчику, только когда его уже можно учесть:
+		// неполученные обновления Telegram отдаст снова после перезапуска
+		bot.WithNotAsyncHandlers(),
+		bot.WithUpdatesChannelCap(0),
+		bot.WithHTTPClient(pollTimeout, client),
+		bot.WithErrorsHandler(func(err error) {
+			slog.Error("telegram bot", logging.Err(err))
+		}),
+	}
+
+	botApi, err := bot.New(token, append(opts, extra...)...)
 	if err != nil {
-		return fmt.Errorf("init bot: %w", err)
+		return nil, fmt.Errorf("init bot: %w", err)
 	}
 
+	return botApi, nil
+}
+
+// Deps are services used by the bot.
+type Deps struct {
+	Trash *trashmanager.Service
+	Stats *stats.Service
+	// Metrics counts commands, button presses and reminders, nil disables them.
+	Metrics *metrics.Metrics
+	// Changes delivers changes made by other processes, e.g. a separately
+	// running panel, to be announced too. Nil when the database is not shared.
+	Changes *journal.Journal
+	// OnTick is called on every check of the reminder scheduler, nil skips it.
+	OnTick func()
+	// Drainer is the drainer passed to NewAPI, handlers and reminders in
+	// progress are waited for on shutdown.
+	Drainer *telegram.Drainer
+}
+
+// Start runs the bot until ctx is done. Then it stops taking updates, waits
+// for handlers and reminders in progress and returns.
+func Start(ctx context.Context, botApi *bot.Bot, deps Deps) error {
+	trashm := deps.Trash
 	handlers := telegram.New(trashm)
+	recorders := []telegram.CommandRecorder{deps.Stats}
+
+	// Запускаем планировщик уведомлений
+	notifyScheduler := scheduler.New(trashm, botApi).WithReporter(deps.Stats).WithTracker(deps.Drainer)
+
+	if deps.Metrics != nil {
+		recorders = append(recorders, deps.Metrics)
+		handlers.WithCallbackRecorder(deps.Metrics)
+		notifyScheduler.WithReporter(deps.Metrics).OnTick(deps.Metrics.SchedulerTick)
+	}
+
+	if deps.OnTick != nil {
+		notifyScheduler.OnTick(deps.OnTick)
+	}
+
+	countCommand := telegram.CommandMiddleware(recorders...)
 
 	botApi.RegisterHandler(
 		bot.HandlerTypeMessageText,
 		"start",
 		bot.MatchTypeCommand,
 		handlers.Start,
+		countCommand,
 	)
 	botApi.RegisterHandler(
 		bot.HandlerTypeMessageText,
 		"set",
 		bot.MatchTypeCommand,
 		handlers.SetEstablish,
+		countCommand,
 	)
-	botApi.RegisterHandler(bot.HandlerTypeMessageText, "next", bot.MatchTypeCommand, handlers.Next)
-	botApi.RegisterHandler(bot.HandlerTypeMessageText, "prev", bot.MatchTypeCommand, handlers.Prev)
-	botApi.RegisterHandler(bot.HandlerTypeMessageText, "who", bot.MatchTypeCommand, handlers.Who)
+	botApi.RegisterHandler(bot.HandlerTypeMessageText, "next", bot.MatchTypeCommand, handlers.Next, countCommand)
+	botApi.RegisterHandler(bot.HandlerTypeMessageText, "prev", bot.MatchTypeCommand, handlers.Prev, countCommand)
+	botApi.RegisterHandler(bot.HandlerTypeMessageText, "who", bot.MatchTypeCommand, handlers.Who, countCommand)
+	botApi.RegisterHandler(bot.HandlerTypeMessageText, "stats", bot.MatchTypeCommand, handlers.Stats, countCommand)
 	botApi.RegisterHandler(
 		bot.HandlerTypeMessageText,
 		"subscribe",
 		bot.MatchTypeCommand,
 		handlers.Subscribe,
+		countCommand,
 	)
 	botApi.RegisterHandler(
 		bot.HandlerTypeMessageText,
 		"unsubscribe",
 		bot.MatchTypeCommand,
 		handlers.Unsubscribe,
+		countCommand,
 	)
 
-	// Запускаем планировщик уведомлений
-	notifyScheduler := scheduler.New(trashm, botApi)
-	go notifyScheduler.Start(ctx)
+	// Сообщаем в чат об изменениях, сделанных через админку
+	announcer := telegram.NewAnnouncer(botApi)
+	trashm.OnEventAsync(announcer.Handle)
+
+	if deps.Changes != nil {
+		deps.Changes.OnChange(announcer.Handle)
+	}
+
+	var group errgroup.Group
+
+	group.Go(func() error {
+		notifyScheduler.Start(ctx)
+
+		return nil
+	})
 
 	botApi.Start(ctx)
 
+	// Новые обновления уже не принимаются, дожидаемся начатой работы
+	drainCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	//nolint:contextcheck // need fresh context for draining after parent is cancelled
+	drainErr := deps.Drainer.Wait(drainCtx)
+
+	_ = group.Wait()
+
+	if drainErr != nil {
+		return fmt.Errorf("stop bot: %w", drainErr)
+	}
+
+	slog.Info("bot stopped")
+
 	return nil
 }
@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/handlers/telegram"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/6ermvH/trash-bot/internal/services/stats"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/go-telegram/bot"
+	"github.com/stretchr/testify/require"
+)
+
+const whoUpdate = `{"ok": true, "result": [{"update_id": 1, "message": {
+	"message_id": 1, "date": 0, "text": "/who",
+	"chat": {"id": 1, "type": "group"},
+	"from": {"id": 5, "is_bot": false, "first_name": "German"},
+	"entities": [{"type": "bot_command", "offset": 0, "length": 4}]
+}}]}`
+
+// telegramStub delivers one /who command and holds the answer until released.
+type telegramStub struct {
+	mu      sync.Mutex
+	polled  bool
+	sent    []string
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (s *telegramStub) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/getUpdates"):
+		s.mu.Lock()
+		polled := s.polled
+		s.polled = true
+		s.mu.Unlock()
+
+		if !polled {
+			return response(whoUpdate), nil
+		}
+
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+	case strings.HasSuffix(req.URL.Path, "/sendMessage"):
+		close(s.sending)
+
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		s.mu.Lock()
+		s.sent = append(s.sent, req.URL.Path)
+		s.mu.Unlock()
+
+		return response(`{"ok": true, "result": {"message_id": 2, "date": 0, "chat": {"id": 1, "type": "group"}}}`), nil
+	default:
+		return response(`{"ok": true, "result": true}`), nil
+	}
+}
+
+func response(body string) *http.Response {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestStart_DrainsHandlers(t *testing.T) {
+	t.Parallel()
+
+	repo := inmemory.New()
+	trashm := trashmanager.New(repo)
+	require.NoError(t, trashm.SetEstablish(t.Context(), 1, []string{"German", "Anthon"}))
+
+	stub := &telegramStub{sending: make(chan struct{}), release: make(chan struct{})}
+	drainer := telegram.NewDrainer()
+
+	botApi, err := newAPI("1:test", stub, drainer, bot.WithSkipGetMe())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	stopped := make(chan error, 1)
+
+	go func() {
+		stopped <- Start(ctx, botApi, Deps{
+			Trash:   trashm,
+			Stats:   stats.New(repo, trashm),
+			Drainer: drainer,
+		})
+	}()
+
+	// Остановка приходит, пока ответ на команду ещё отправляется
+	<-stub.sending
+	cancel()
+
+	select {
+	case <-stopped:
+		t.Fatal("bot stopped before the handler answered")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(stub.release)
+	require.NoError(t, <-stopped)
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+
+	require.Len(t, stub.sent, 1)
+}
@@ -0,0 +1,676 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/6ermvH/trash-bot/internal/config"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/sqlite"
+	"github.com/6ermvH/trash-bot/internal/services/adminmanager"
+	"github.com/6ermvH/trash-bot/internal/services/audit"
+	"github.com/6ermvH/trash-bot/internal/services/backup"
+	"github.com/6ermvH/trash-bot/internal/services/journal"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+)
+
+// Exit codes of the commands.
+const (
+	exitOK       = 0
+	exitFailure  = 1
+	exitUsage    = 2 // unknown command, flag or argument
+	exitConfig   = 3 // the config can not be loaded or is invalid
+	exitNotFound = 4 // the chat or the admin does not exist
+)
+
+var (
+	errUsage         = errors.New("invalid usage")
+	errChatNotFound  = errors.New("chat not found")
+	errSQLiteOnly    = errors.New("supported only by sqlite, database.type is memory")
+	errPasswordFlags = errors.New("exactly one of --password and --password-stdin is required")
+)
+
+// cliActor is recorded in the audit log for changes made by the commands.
+var cliActor = trashmanager.Actor{
+	Type:   trashmanager.ActorSystem,
+	ID:     "cli",
+	Name:   "cli",
+	Source: trashmanager.SourceSystem,
+}
+
+// command is a subcommand, the name of a nested one has two words, e.g. "chats list".
+type command struct {
+	name  string
+	args  string
+	help  string
+	run   func(c *cli, ctx context.Context, args []string) error
+	serve bool // runs the service, handles signals and logging itself
+}
+
+var commands = []command{
+	{name: "serve", help: "run the bot and the panel (default)", serve: true},
+	{name: "migrate", help: "create or upgrade the database schema", run: (*cli).migrate},
+	{name: "backup", args: "[--out FILE]", help: "back up the sqlite database", run: (*cli).backup},
+	{name: "restore", args: "--from FILE", help: "replace the sqlite database with a backup, stop the service first", run: (*cli).restore},
+	{name: "chats list", args: "[--subscribed] [--member NAME]", help: "list chats", run: (*cli).chatsList},
+	{name: "chats show", args: "CHAT_ID", help: "show a chat", run: (*cli).chatsShow},
+	{name: "chats set", args: "CHAT_ID MEMBER...", help: "replace the members of a chat", run: (*cli).chatsSet},
+	{name: "chats next", args: "CHAT_ID", help: "pass the duty to the next member", run: (*cli).chatsNext},
+	{name: "admin create-user", args: "--login LOGIN --role ROLE (--password PASSWORD | --password-stdin)", help: "create a panel account", run: (*cli).adminCreateUser},
+	{name: "config check", help: "load and validate the config", run: (*cli).configCheck},
+}
+
+// options are the flags common to all commands.
+type options struct {
+	configPath string
+	envFile    string
+	json       bool
+}
+
+func (o options) loadConfig() (*config.Config, error) {
+	if err := config.LoadEnvFile(o.envFile); err != nil {
+		return nil, &configError{fmt.Errorf("load env file: %w", err)}
+	}
+
+	cfg, err := config.NewFromFile(o.configPath)
+	if err != nil {
+		return nil, &configError{err}
+	}
+
+	return cfg, nil
+}
+
+// configError is a failure to load the config, it exits with exitConfig.
+type configError struct {
+	err error
+}
+
+func (e *configError) Error() string {
+	return e.err.Error()
+}
+
+func (e *configError) Unwrap() error {
+	return e.err
+}
+
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	cmd    command
+	opts   options
+}
+
+func newCLI() *cli {
+	return &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+}
+
+// run executes the command named by the first arguments and returns the exit
+// code. Without a command, or when the arguments start with a flag, it serves
+// as before the commands were added.
+func (c *cli) run(args []string) int {
+	if len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "--help") {
+		c.usage(c.stdout)
+
+		return exitOK
+	}
+
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		args = append([]string{"serve"}, args...)
+	}
+
+	cmd, rest, ok := findCommand(args)
+	if !ok {
+		fmt.Fprintf(c.stderr, "unknown command %q\n\n", strings.Join(args[:min(len(args), 2)], " "))
+		c.usage(c.stderr)
+
+		return exitUsage
+	}
+
+	c.cmd = cmd
+
+	if cmd.serve {
+		if err := c.parse(rest); err != nil {
+			return c.fail(err)
+		}
+
+		return serve(c.opts)
+	}
+
+	// Команды пишут в stdout только результат
+	slog.SetDefault(slog.New(slog.NewTextHandler(c.stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	ctx = trashmanager.WithActor(ctx, cliActor)
+
+	if err := cmd.run(c, ctx, rest); err != nil {
+		return c.fail(err)
+	}
+
+	return exitOK
+}
+
+func findCommand(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+
+	return command{}, nil, false
+}
+
+func (c *cli) usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: trash-bot [COMMAND] [--config FILE] [--env-file FILE] [--json] [ARGS]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(table, "  %s\t%s\n", cmd.name, cmd.help)
+	}
+
+	_ = table.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "exit codes: 0 ok, 1 failure, 2 usage, 3 invalid config, 4 not found")
+	fmt.Fprintln(w, `run "trash-bot COMMAND -h" for the arguments of a command`)
+}
+
+// flagSet returns the flags of the command with the common ones.
+func (c *cli) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.cmd.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	// Справку и ошибки печатают parseFlags и fail
+	fs.Usage = func() {}
+	fs.StringVar(&c.opts.configPath, "config", "config/base.yaml", "path to the configuration file")
+	fs.StringVar(&c.opts.envFile, "env-file", ".env", "file with environment variables, skipped when missing")
+
+	if !c.cmd.serve {
+		fs.BoolVar(&c.opts.json, "json", false, "print the result and errors as JSON")
+	}
+
+	return fs
+}
+
+// parse parses the flags of a command without flags of its own, it must be
+// called with flagSet for the others.
+func (c *cli) parse(args []string) error {
+	return c.parseFlags(c.flagSet(), args)
+}
+
+func (c *cli) parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(c.stdout, "usage: trash-bot %s %s\n\n%s\n\nflags:\n", c.cmd.name, c.cmd.args, c.cmd.help)
+		fs.SetOutput(c.stdout)
+		fs.PrintDefaults()
+
+		return err
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	return nil
+}
+
+// fail prints the error and returns its exit code.
+func (c *cli) fail(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	var invalid *config.ValidationError
+	isInvalid := errors.As(err, &invalid)
+
+	if c.opts.json {
+		body := map[string]any{"error": err.Error()}
+		if isInvalid {
+			body["problems"] = invalid.Problems
+		}
+
+		c.encode(c.stderr, body)
+	} else if isInvalid {
+		fmt.Fprintln(c.stderr, "invalid config:")
+
+		for _, problem := range invalid.Problems {
+			fmt.Fprintln(c.stderr, "  "+problem)
+		}
+	} else {
+		fmt.Fprintln(c.stderr, "error: "+err.Error())
+	}
+
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	var cfgErr *configError
+
+	switch {
+	case errors.Is(err, errUsage), errors.Is(err, errPasswordFlags), errors.Is(err, adminmanager.ErrInvalidLogin),
+		errors.Is(err, adminmanager.ErrWeakPassword), errors.Is(err, adminmanager.ErrUnknownRole):
+		return exitUsage
+	case errors.As(err, &cfgErr):
+		return exitConfig
+	case errors.Is(err, errChatNotFound), errors.Is(err, repository.ErrAdminNotFound):
+		return exitNotFound
+	default:
+		return exitFailure
+	}
+}
+
+// print writes v as JSON with --json, otherwise calls text.
+func (c *cli) print(v any, text func(w io.Writer)) {
+	if c.opts.json {
+		c.encode(c.stdout, v)
+
+		return
+	}
+
+	text(c.stdout)
+}
+
+func (c *cli) encode(w io.Writer, v any) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintln(c.stderr, "error: encode output: "+err.Error())
+	}
+}
+
+// openRepository loads the config and opens the configured repository.
+func (c *cli) openRepository() (*config.Config, store, *sqlite.RepoSQLite, func(), error) {
+	cfg, err := c.opts.loadConfig()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	repo, sqliteRepo, cleanup, err := openRepository(cfg)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	if sqliteRepo == nil {
+		slog.Warn("the in-memory database starts empty and is lost when the command exits")
+	}
+
+	return cfg, repo, sqliteRepo, cleanup, nil
+}
+
+// trashManager opens the repository and returns the service recording changes
+// to the audit log and, with sqlite, for the running bot and panel.
+func (c *cli) trashManager() (*trashmanager.Service, func(), error) {
+	cfg, repo, sqliteRepo, cleanup, err := c.openRepository()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trashm := trashmanager.New(repo).WithAudit(audit.New(repo, cfg.Database.Audit.Retention))
+
+	if sqliteRepo != nil {
+		changes := journal.New(sqliteRepo, cfg.Database.Changes.PollInterval, cfg.Database.Changes.Retention)
+		trashm.OnEventAsync(changes.Handler())
+	}
+
+	return trashm, func() {
+		trashm.Close()
+		cleanup()
+	}, nil
+}
+
+func (c *cli) migrate(_ context.Context, args []string) error {
+	if err := c.parse(args); err != nil {
+		return err
+	}
+
+	// Схема создаётся и обновляется при открытии базы
+	cfg, _, sqliteRepo, cleanup, err := c.openRepository()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	result := map[string]string{"database": "memory"}
+	if sqliteRepo != nil {
+		result = map[string]string{"database": "sqlite", "path": cfg.Database.Path}
+	}
+
+	c.print(result, func(w io.Writer) {
+		if sqliteRepo == nil {
+			fmt.Fprintln(w, "the in-memory database has no schema to migrate")
+
+			return
+		}
+
+		fmt.Fprintln(w, "migrated "+cfg.Database.Path)
+	})
+
+	return nil
+}
+
+func (c *cli) backup(ctx context.Context, args []string) error {
+	fs := c.flagSet()
+	out := fs.String("out", "", "backup file, a new file in database.backup.dir by default")
+
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, _, sqliteRepo, cleanup, err := c.openRepository()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if sqliteRepo == nil {
+		return errSQLiteOnly
+	}
+
+	path := *out
+
+	if path == "" {
+		if cfg.Database.Backup.Dir == "" {
+			return fmt.Errorf("%w: --out is required when database.backup.dir is empty", errUsage)
+		}
+
+		// Ротация по database.backup.keep, как у резервных копий по расписанию
+		job := backup.New(sqliteRepo, cfg.Database.Backup.Dir, cfg.Database.Backup.Keep, cfg.Database.Backup.Interval)
+
+		if path, err = job.Run(ctx); err != nil {
+			return fmt.Errorf("back up: %w", err)
+		}
+	} else if err := sqliteRepo.Backup(ctx, path); err != nil {
+		return fmt.Errorf("back up: %w", err)
+	}
+
+	c.print(map[string]string{"path": path}, func(w io.Writer) {
+		fmt.Fprintln(w, "backed up to "+path)
+	})
+
+	return nil
+}
+
+func (c *cli) restore(ctx context.Context, args []string) error {
+	fs := c.flagSet()
+	from := fs.String("from", "", "backup file to restore")
+
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *from == "" {
+		return fmt.Errorf("%w: --from is required", errUsage)
+	}
+
+	cfg, err := c.opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Database.Type != "sqlite" {
+		return errSQLiteOnly
+	}
+
+	if err := sqlite.Restore(ctx, *from, cfg.Database.Path); err != nil {
+		return fmt.Errorf("restore from %s: %w", *from, err)
+	}
+
+	c.print(map[string]string{"from": *from, "path": cfg.Database.Path}, func(w io.Writer) {
+		fmt.Fprintf(w, "restored %s from %s\n", cfg.Database.Path, *from)
+	})
+
+	return nil
+}
+
+func (c *cli) chatsList(ctx context.Context, args []string) error {
+	fs := c.flagSet()
+	subscribed := fs.Bool("subscribed", false, "only chats with the daily reminder")
+	member := fs.String("member", "", "only chats with a member whose name contains this text")
+
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	query := repository.ChatQuery{Member: *member}
+	if *subscribed {
+		query.Subscribed = subscribed
+	}
+
+	trashm, cleanup, err := c.trashManager()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	page, err := trashm.FindChats(ctx, query)
+	if err != nil {
+		return fmt.Errorf("find chats: %w", err)
+	}
+
+	c.print(page.Chats, func(w io.Writer) {
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tMEMBERS\tON DUTY\tREMINDER")
+
+		for _, chat := range page.Chats {
+			fmt.Fprintf(table, "%d\t%d\t%s\t%s\n", chat.ID, len(chat.Users), onDuty(chat), reminder(chat))
+		}
+
+		_ = table.Flush()
+	})
+
+	return nil
+}
+
+func (c *cli) chatsShow(ctx context.Context, args []string) error {
+	chatID, _, err := c.parseChatArgs(args, false)
+	if err != nil {
+		return err
+	}
+
+	trashm, cleanup, err := c.trashManager()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	chat, err := trashm.Chat(ctx, chatID)
+	if err != nil {
+		return chatError(chatID, err)
+	}
+
+	c.printChat(chat)
+
+	return nil
+}
+
+func (c *cli) chatsSet(ctx context.Context, args []string) error {
+	chatID, members, err := c.parseChatArgs(args, true)
+	if err != nil {
+		return err
+	}
+
+	trashm, cleanup, err := c.trashManager()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := trashm.SetEstablish(ctx, chatID, members); err != nil {
+		return chatError(chatID, err)
+	}
+
+	chat, err := trashm.Chat(ctx, chatID)
+	if err != nil {
+		return chatError(chatID, err)
+	}
+
+	c.printChat(chat)
+
+	return nil
+}
+
+func (c *cli) chatsNext(ctx context.Context, args []string) error {
+	chatID, _, err := c.parseChatArgs(args, false)
+	if err != nil {
+		return err
+	}
+
+	trashm, cleanup, err := c.trashManager()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	current, err := trashm.Next(ctx, chatID)
+	if err != nil {
+		return chatError(chatID, err)
+	}
+
+	c.print(map[string]any{"id": chatID, "current": current}, func(w io.Writer) {
+		fmt.Fprintln(w, current)
+	})
+
+	return nil
+}
+
+// parseChatArgs parses the flags and the chat id, followed by the members when withMembers is set.
+func (c *cli) parseChatArgs(args []string, withMembers bool) (int64, []string, error) {
+	fs := c.flagSet()
+	if err := c.parseFlags(fs, args); err != nil {
+		return 0, nil, err
+	}
+
+	rest := fs.Args()
+
+	switch {
+	case len(rest) == 0:
+		return 0, nil, fmt.Errorf("%w: the chat id is required", errUsage)
+	case !withMembers && len(rest) > 1:
+		return 0, nil, fmt.Errorf("%w: unexpected arguments %q", errUsage, rest[1:])
+	case withMembers && len(rest) == 1:
+		return 0, nil, fmt.Errorf("%w: at least one member is required", errUsage)
+	}
+
+	chatID, err := strconv.ParseInt(rest[0], 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: the chat id must be a number, got %q", errUsage, rest[0])
+	}
+
+	return chatID, rest[1:], nil
+}
+
+// chatError turns the errors of a missing chat, which are worded for the bot, into errChatNotFound.
+func chatError(chatID int64, err error) error {
+	if errors.Is(err, repository.ErrChatIsNotInitialize) || errors.Is(err, trashmanager.ErrTryToInitialize) {
+		return fmt.Errorf("chat %d: %w", chatID, errChatNotFound)
+	}
+
+	return fmt.Errorf("chat %d: %w", chatID, err)
+}
+
+func (c *cli) printChat(chat *repository.Chat) {
+	c.print(chat, func(w io.Writer) {
+		fmt.Fprintf(w, "chat %d, reminder %s\n", chat.ID, reminder(*chat))
+
+		for i, user := range chat.Users {
+			marker := " "
+			if i == chat.Current {
+				marker = "*"
+			}
+
+			fmt.Fprintf(w, "%s %d. %s\n", marker, i+1, user)
+		}
+	})
+}
+
+func onDuty(chat repository.Chat) string {
+	if chat.Current < 0 || chat.Current >= len(chat.Users) {
+		return "-"
+	}
+
+	return chat.Users[chat.Current]
+}
+
+func reminder(chat repository.Chat) string {
+	if chat.NotifyTime == nil {
+		return "off"
+	}
+
+	return *chat.NotifyTime
+}
+
+func (c *cli) adminCreateUser(ctx context.Context, args []string) error {
+	fs := c.flagSet()
+	login := fs.String("login", "", "login of the account")
+	role := fs.String("role", string(repository.RoleViewer), `"viewer", "operator" or "owner"`)
+	password := fs.String("password", "", "password, visible in the process list, prefer --password-stdin")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from the first line of stdin")
+
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if (*password != "") == *passwordStdin {
+		return errPasswordFlags
+	}
+
+	if *passwordStdin {
+		line, err := bufio.NewReader(c.stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("read password: %w", err)
+		}
+
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	_, repo, _, cleanup, err := c.openRepository()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	admin, err := adminmanager.New(repo).Create(ctx, *login, *password, repository.Role(*role))
+	if err != nil {
+		return fmt.Errorf("create admin: %w", err)
+	}
+
+	c.print(admin, func(w io.Writer) {
+		fmt.Fprintf(w, "created %s %s\n", admin.Role, admin.Login)
+	})
+
+	return nil
+}
+
+func (c *cli) configCheck(_ context.Context, args []string) error {
+	if err := c.parse(args); err != nil {
+		return err
+	}
+
+	if _, err := c.opts.loadConfig(); err != nil {
+		return err
+	}
+
+	c.print(map[string]any{"valid": true, "path": c.opts.configPath}, func(w io.Writer) {
+		fmt.Fprintln(w, c.opts.configPath+" is valid")
+	})
+
+	return nil
+}
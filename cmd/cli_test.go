@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/config"
+	"github.com/6ermvH/trash-bot/internal/repository/sqlite"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig writes a config with the panel disabled and a sqlite database
+// in a temporary directory, extra is appended to it.
+func writeConfig(t *testing.T, extra string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+telegram:
+  botkey: "123:test"
+server:
+  enabled: false
+database:
+  type: "sqlite"
+  path: "` + filepath.Join(dir, "trash.db") + `"
+  backup:
+    dir: "` + filepath.Join(dir, "backups") + `"
+    keep: 1
+` + extra
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+// runCLI runs the command with the config and no env file.
+func runCLI(t *testing.T, stdin, configPath string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+
+	c := &cli{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr}
+
+	// Общие флаги идут после имени команды, до позиционных аргументов
+	words := 1
+	if len(args) > 1 && (args[0] == "chats" || args[0] == "admin" || args[0] == "config") {
+		words = 2
+	}
+
+	full := append([]string{}, args[:words]...)
+	full = append(full, "--config", configPath, "--env-file", filepath.Join(t.TempDir(), ".env"))
+	full = append(full, args[words:]...)
+
+	code := c.run(full)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCLI_ConfigCheck(t *testing.T) {
+	t.Parallel()
+
+	code, stdout, _ := runCLI(t, "", writeConfig(t, ""), "config", "check")
+	require.Equal(t, exitOK, code)
+	require.Contains(t, stdout, "is valid")
+
+	invalid := writeConfig(t, "log:\n  level: \"loud\"\n")
+
+	code, _, stderr := runCLI(t, "", invalid, "config", "check", "--json")
+	require.Equal(t, exitConfig, code)
+
+	var body struct {
+		Problems []string `json:"problems"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(stderr), &body))
+	require.Equal(t, []string{`log.level must be "debug", "info", "warn" or "error", got "loud"`}, body.Problems)
+
+	code, _, _ = runCLI(t, "", filepath.Join(t.TempDir(), "missing.yaml"), "config", "check")
+	require.Equal(t, exitConfig, code)
+}
+
+func TestCLI_Chats(t *testing.T) {
+	t.Parallel()
+
+	configPath := writeConfig(t, "")
+
+	code, _, stderr := runCLI(t, "", configPath, "chats", "set", "42", "Alice", "Bob")
+	require.Equal(t, exitOK, code, stderr)
+
+	code, stdout, _ := runCLI(t, "", configPath, "chats", "next", "42")
+	require.Equal(t, exitOK, code)
+	require.Equal(t, "Bob\n", stdout)
+
+	code, stdout, _ = runCLI(t, "", configPath, "chats", "list", "--json", "--member", "ali")
+	require.Equal(t, exitOK, code)
+	require.JSONEq(t, `[{"id": 42, "currentUser": 1, "activeUsers": ["Alice", "Bob"]}]`, stdout)
+
+	code, stdout, _ = runCLI(t, "", configPath, "chats", "list", "--subscribed")
+	require.Equal(t, exitOK, code)
+	require.NotContains(t, stdout, "42")
+
+	code, stdout, _ = runCLI(t, "", configPath, "chats", "show", "42")
+	require.Equal(t, exitOK, code)
+	require.Contains(t, stdout, "* 2. Bob")
+
+	// Запущенные бот и панель узнают об изменениях из общей базы
+	repo, err := sqlite.New(filepath.Join(filepath.Dir(configPath), "trash.db"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = repo.Close()
+	})
+
+	changes, err := repo.GetChangesAfter(t.Context(), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	require.Equal(t, "rotation.advanced", changes[2].Type)
+
+	code, _, stderr = runCLI(t, "", configPath, "chats", "show", "7")
+	require.Equal(t, exitNotFound, code)
+	require.Contains(t, stderr, "chat not found")
+
+	code, _, _ = runCLI(t, "", configPath, "chats", "next", "7")
+	require.Equal(t, exitNotFound, code)
+
+	code, _, _ = runCLI(t, "", configPath, "chats", "show", "forty-two")
+	require.Equal(t, exitUsage, code)
+
+	code, _, _ = runCLI(t, "", configPath, "chats", "set", "42")
+	require.Equal(t, exitUsage, code)
+}
+
+func TestCLI_BackupRestore(t *testing.T) {
+	t.Parallel()
+
+	configPath := writeConfig(t, "")
+
+	code, _, _ := runCLI(t, "", configPath, "chats", "set", "42", "Alice")
+	require.Equal(t, exitOK, code)
+
+	out := filepath.Join(t.TempDir(), "backup.db")
+
+	code, stdout, stderr := runCLI(t, "", configPath, "backup", "--out", out, "--json")
+	require.Equal(t, exitOK, code, stderr)
+	require.JSONEq(t, `{"path": "`+out+`"}`, stdout)
+
+	code, _, _ = runCLI(t, "", configPath, "chats", "set", "42", "Bob")
+	require.Equal(t, exitOK, code)
+
+	code, _, stderr = runCLI(t, "", configPath, "restore", "--from", out)
+	require.Equal(t, exitOK, code, stderr)
+
+	code, stdout, _ = runCLI(t, "", configPath, "chats", "show", "42")
+	require.Equal(t, exitOK, code)
+	require.Contains(t, stdout, "Alice")
+
+	// Без --out копия попадает в database.backup.dir
+	code, stdout, _ = runCLI(t, "", configPath, "backup")
+	require.Equal(t, exitOK, code)
+	require.Contains(t, stdout, filepath.Join(filepath.Dir(configPath), "backups"))
+
+	code, _, _ = runCLI(t, "", configPath, "restore")
+	require.Equal(t, exitUsage, code)
+}
+
+func TestRestoreOnStartup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := config.DatabaseCfg{
+		Path:        filepath.Join(dir, "trash.db"),
+		RestoreFrom: filepath.Join(dir, "backup.db"),
+	}
+
+	repo, err := sqlite.New(cfg.Path)
+	require.NoError(t, err)
+	require.NoError(t, repo.SetEstablish(t.Context(), 42, []string{"Alice"}))
+	require.NoError(t, repo.Backup(t.Context(), cfg.RestoreFrom))
+	require.NoError(t, repo.SetEstablish(t.Context(), 42, []string{"Bob"}))
+	require.NoError(t, repo.Close())
+
+	require.NoError(t, restoreOnStartup(t.Context(), cfg))
+	require.NoFileExists(t, cfg.RestoreFrom)
+	require.FileExists(t, cfg.RestoreFrom+restoredSuffix)
+
+	// Следующий старт с тем же конфигом сохраняет изменения, сделанные после восстановления
+	repo, err = sqlite.New(cfg.Path)
+	require.NoError(t, err)
+	require.NoError(t, repo.SetEstablish(t.Context(), 42, []string{"Carol"}))
+	require.NoError(t, repo.Close())
+
+	require.NoError(t, restoreOnStartup(t.Context(), cfg))
+
+	repo, err = sqlite.New(cfg.Path)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = repo.Close()
+	})
+
+	chat, err := repo.GetChat(t.Context(), 42)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Carol"}, chat.Users)
+
+	missing := cfg
+	missing.RestoreFrom = filepath.Join(dir, "missing.db")
+	require.Error(t, restoreOnStartup(t.Context(), missing))
+}
+
+func TestCLI_AdminCreateUser(t *testing.T) {
+	t.Parallel()
+
+	configPath := writeConfig(t, "")
+
+	code, stdout, stderr := runCLI(t, "operator-password\n", configPath,
+		"admin", "create-user", "--json", "--login", "alice", "--role", "operator", "--password-stdin")
+	require.Equal(t, exitOK, code, stderr)
+	require.Contains(t, stdout, `"role": "operator"`)
+	require.NotContains(t, stdout, "operator-password")
+
+	code, _, _ = runCLI(t, "", configPath, "admin", "create-user", "--login", "bob", "--role", "root", "--password", "bob-password")
+	require.Equal(t, exitUsage, code)
+
+	code, _, _ = runCLI(t, "", configPath, "admin", "create-user", "--login", "bob")
+	require.Equal(t, exitUsage, code)
+
+	code, _, _ = runCLI(t, "", configPath, "admin", "create-user", "--login", "alice", "--password", "other-password")
+	require.Equal(t, exitFailure, code)
+}
+
+func TestCLI_Usage(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+
+	c := &cli{stdout: &stdout, stderr: &stderr}
+	require.Equal(t, exitUsage, c.run([]string{"chats", "delete"}))
+	require.Contains(t, stderr.String(), `unknown command "chats delete"`)
+
+	require.Equal(t, exitOK, c.run([]string{"help"}))
+	require.Contains(t, stdout.String(), "admin create-user")
+}
+
+func TestServe_ReturnsExitCode(t *testing.T) {
+	t.Parallel()
+
+	// Путь к базе указывает на каталог, открыть её нельзя
+	configPath := writeConfig(t, "")
+	dbPath := filepath.Join(filepath.Dir(configPath), "trash.db")
+	require.NoError(t, os.Mkdir(dbPath, 0o700))
+
+	code := serve(options{configPath: configPath, envFile: filepath.Join(t.TempDir(), ".env")})
+	require.Equal(t, exitFailure, code)
+
+	code = serve(options{configPath: filepath.Join(t.TempDir(), "missing.yaml")})
+	require.Equal(t, exitConfig, code)
+}
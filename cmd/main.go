@@ -2,82 +2,477 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/6ermvH/trash-bot/cmd/bot"
 	"github.com/6ermvH/trash-bot/cmd/panel"
 	"github.com/6ermvH/trash-bot/internal/config"
+	"github.com/6ermvH/trash-bot/internal/handlers/telegram"
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
 	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
 	"github.com/6ermvH/trash-bot/internal/repository/sqlite"
+	"github.com/6ermvH/trash-bot/internal/services/adminmanager"
+	"github.com/6ermvH/trash-bot/internal/services/audit"
+	"github.com/6ermvH/trash-bot/internal/services/backup"
+	"github.com/6ermvH/trash-bot/internal/services/feed"
+	"github.com/6ermvH/trash-bot/internal/services/health"
+	"github.com/6ermvH/trash-bot/internal/services/journal"
+	"github.com/6ermvH/trash-bot/internal/services/metrics"
+	"github.com/6ermvH/trash-bot/internal/services/reloader"
+	"github.com/6ermvH/trash-bot/internal/services/stats"
+	"github.com/6ermvH/trash-bot/internal/services/tokenmanager"
 	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/6ermvH/trash-bot/internal/tracing"
+	tgbot "github.com/go-telegram/bot"
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	// Long polling returns at least once a minute, a failed poll is retried within seconds.
+	pollMaxAge = 2 * time.Minute
+	// The scheduler checks reminders every minute.
+	tickMaxAge = 2 * time.Minute
+
+	// restoredSuffix marks a backup restored on startup.
+	restoredSuffix = ".restored"
+
+	readHeaderTimeout = 5 * time.Second
+	shutdownTimeout   = 5 * time.Second
+)
+
 func main() {
-	if err := config.LoadEnvFile(".env"); err != nil {
-		log.Fatalf("load .env file: %v", err)
+	os.Exit(newCLI().run(os.Args[1:]))
+}
+
+// serve runs the bot, the panel or both, selected by the mode, and the
+// background jobs until SIGINT or SIGTERM.
+func serve(opts options) int {
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		slog.Error("load config", logging.Err(err))
+
+		return exitConfig
+	}
+
+	logger, err := logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		return failed("create logger", err, exitConfig)
 	}
 
-	cfg, err := config.NewFromFile("config/base.yaml")
+	// Стандартный log тоже пишет через этот логгер
+	slog.SetDefault(logger)
+
+	configReloader := reloader.New(opts.configPath, cfg).OnReload(func(_ context.Context, cfg *config.Config) error {
+		logger, err := logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level)
+		if err != nil {
+			return fmt.Errorf("create logger: %w", err)
+		}
+
+		slog.SetDefault(logger)
+
+		return nil
+	})
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingConfig(cfg.Tracing))
 	if err != nil {
-		log.Fatalf("load config: %v", err)
+		return failed("set up tracing", err, exitFailure)
 	}
 
-	trashm, cleanup := createService(cfg)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		// Отправляем накопленные спаны до выхода
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("shutdown tracing", logging.Err(err))
+		}
+	}()
+
+	if cfg.Database.Type == "sqlite" && cfg.Database.RestoreFrom != "" {
+		if err := restoreOnStartup(context.Background(), cfg.Database); err != nil {
+			return failed("restore sqlite db from "+cfg.Database.RestoreFrom, err, exitFailure)
+		}
+	}
+
+	repo, sqliteRepo, cleanup, err := openRepository(cfg)
+	if err != nil {
+		return failed("open repository", err, exitFailure)
+	}
 	defer cleanup()
 
+	var metricsService *metrics.Metrics
+
+	if cfg.Metrics.Enabled {
+		metricsService = metrics.New(repo)
+
+		storage := "inmemory"
+		if sqliteRepo != nil {
+			storage = "sqlite"
+		}
+
+		repo = metrics.InstrumentRepository(repo, storage, metricsService)
+	}
+
+	auditLog := audit.New(repo, cfg.Database.Audit.Retention)
+
+	trashm := trashmanager.New(repo).WithAudit(auditLog)
+
+	statsService := stats.New(repo, trashm)
+	trashm.OnEventAsync(statsService.Handler())
+
+	admins := adminmanager.New(repo)
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
 	group, ctx := errgroup.WithContext(ctx)
 
-	if cfg.Server.Enabled {
+	// Процессы с общей базой (бот, панель, CLI) обмениваются изменениями через неё
+	var changes *journal.Journal
+
+	if sqliteRepo != nil {
+		changes = journal.New(sqliteRepo, cfg.Database.Changes.PollInterval, cfg.Database.Changes.Retention)
+		trashm.OnEventAsync(changes.Handler())
+	}
+
+	checker, polls, ticks := newHealthChecker(sqliteRepo, cfg.RunsBot())
+
+	var botApi *tgbot.Bot
+
+	drainer := telegram.NewDrainer()
+
+	if cfg.RunsBot() || cfg.Server.TelegramLogin.Enabled {
+		botApi, err = bot.NewAPI(cfg, polls.Beat, drainer)
+		if err != nil {
+			return failed("create bot", err, exitFailure)
+		}
+	}
+
+	if cfg.RunsPanel() {
+		if err := bootstrapOwner(context.Background(), admins, cfg.Server); err != nil {
+			return failed("bootstrap admin", err, exitFailure)
+		}
+
+		tokens, err := tokenmanager.New(repo, admins, tokenConfig(cfg.Server))
+		if err != nil {
+			return failed("create token manager", err, exitFailure)
+		}
+
 		group.Go(func() error {
-			return panel.Start(ctx, cfg, trashm)
+			tokens.Start(ctx)
+
+			return nil
+		})
+
+		configReloader.OnReload(func(_ context.Context, cfg *config.Config) error {
+			if err := tokens.Reconfigure(tokenConfig(cfg.Server)); err != nil {
+				return fmt.Errorf("reconfigure tokens: %w", err)
+			}
+
+			return nil
 		})
-		log.Printf("Server started on port: %s\n", cfg.Server.Port)
+
+		events := feed.New(cfg.Server.Events.Buffer)
+		trashm.OnEventAsync(events.Handler(trashm))
+
+		if changes != nil {
+			changes.OnChange(events.Handler(trashm))
+		}
+
+		deps := panel.Deps{
+			Trash:    trashm,
+			Admins:   admins,
+			Tokens:   tokens,
+			Audit:    auditLog,
+			Feed:     events,
+			Stats:    statsService,
+			Metrics:  metricsService,
+			Health:   checker,
+			Reloader: configReloader,
+		}
+
+		if sqliteRepo != nil {
+			deps.Snapshotter = sqliteRepo
+		}
+
+		if cfg.Server.TelegramLogin.Enabled {
+			deps.ChatAccess = telegram.NewChatAccess(botApi)
+		}
+
+		group.Go(func() error {
+			return panel.Start(ctx, cfg, deps)
+		})
+		slog.Info("server started", "port", cfg.Server.Port)
 	}
 
+	// Резервные копии делает процесс бота, чтобы при раздельном запуске их не было две
+	if sqliteRepo != nil && cfg.Database.Backup.Enabled && cfg.RunsBot() {
+		backupJob := backup.New(
+			sqliteRepo,
+			cfg.Database.Backup.Dir,
+			cfg.Database.Backup.Keep,
+			cfg.Database.Backup.Interval,
+		)
+
+		configReloader.OnReload(func(_ context.Context, cfg *config.Config) error {
+			backupJob.Reschedule(cfg.Database.Backup.Dir, cfg.Database.Backup.Keep, cfg.Database.Backup.Interval)
+
+			return nil
+		})
+
+		group.Go(func() error {
+			backupJob.Start(ctx)
+
+			return nil
+		})
+		slog.Info("backups enabled", "dir", cfg.Database.Backup.Dir, "interval", cfg.Database.Backup.Interval)
+	}
+
+	// Без панели это единственный адрес для проверок здоровья, поэтому он работает и с выключенными метриками
+	if cfg.Metrics.Addr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", checker.Healthz)
+		mux.HandleFunc("/readyz", checker.Readyz)
+
+		if metricsService != nil {
+			mux.Handle("/metrics", metricsService.Handler())
+		}
+
+		group.Go(func() error {
+			return serveOps(ctx, cfg.Metrics.Addr, mux)
+		})
+		slog.Info("health checks served", "addr", cfg.Metrics.Addr, "metrics", metricsService != nil)
+	} else if !cfg.RunsPanel() {
+		slog.Warn("health checks are not served: the panel is disabled and metrics.addr is empty")
+	}
+
+	group.Go(func() error {
+		auditLog.Start(ctx)
+
+		return nil
+	})
+
 	group.Go(func() error {
-		return bot.Start(ctx, cfg, trashm)
+		configReloader.Start(ctx)
+
+		return nil
 	})
-	log.Printf("Bot started\n")
 
-	if err := group.Wait(); err != nil {
-		log.Printf("application ended with error: %v\n", err)
+	if changes != nil {
+		group.Go(func() error {
+			changes.Start(ctx)
+
+			return nil
+		})
+	}
 
-		return
+	if cfg.RunsBot() {
+		group.Go(func() error {
+			return bot.Start(ctx, botApi, bot.Deps{
+				Trash:   trashm,
+				Stats:   statsService,
+				Metrics: metricsService,
+				Changes: changes,
+				OnTick:  ticks.Beat,
+				Drainer: drainer,
+			})
+		})
+		slog.Info("bot started")
 	}
 
-	log.Println("Application stopped gracefully")
+	// Бот и панель остановлены, начатая ими работа завершена. Доставляем
+	// накопленные события (объявления в чаты, журнал изменений), а база
+	// закрывается последней, в отложенном cleanup
+	groupErr := group.Wait()
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelFlush()
+
+	if err := trashm.Shutdown(flushCtx); err != nil {
+		groupErr = errors.Join(groupErr, err)
+	}
+
+	if groupErr != nil {
+		slog.Error("application ended with error", logging.Err(groupErr))
+
+		return exitFailure
+	}
+
+	slog.Info("application stopped gracefully")
+
+	return exitOK
+}
+
+// store is the repository used by all services.
+type store interface {
+	trashmanager.Repository
+	adminmanager.Repository
+	tokenmanager.Repository
+	audit.Repository
+	stats.Repository
+}
+
+// bootstrapOwner creates the owner account from the config while there are
+// no accounts yet, later accounts are managed in the panel.
+func bootstrapOwner(ctx context.Context, admins *adminmanager.Service, cfg config.ServerCfg) error {
+	created, err := admins.Bootstrap(ctx, cfg.AdminLogin, cfg.AdminPassword)
+	if err != nil {
+		return fmt.Errorf("bootstrap owner: %w", err)
+	}
+
+	if created {
+		slog.InfoContext(ctx, "created owner admin account", "login", cfg.AdminLogin)
+
+		if err := adminmanager.CheckPassword(cfg.AdminPassword); err != nil {
+			slog.WarnContext(ctx, "owner admin password is weak, change it in the panel",
+				"login", cfg.AdminLogin, logging.Err(err))
+		}
+	}
+
+	return nil
+}
+
+// restoreOnStartup restores database.restorefrom once: the backup is renamed to
+// FILE.restored afterwards, so that later starts with the same config keep the
+// data written since.
+func restoreOnStartup(ctx context.Context, cfg config.DatabaseCfg) error {
+	from := cfg.RestoreFrom
+
+	if _, err := os.Stat(from); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(from + restoredSuffix); err == nil {
+			slog.Warn("database.restorefrom is already restored, clear it", "from", from)
+
+			return nil
+		}
+	}
+
+	if err := sqlite.Restore(ctx, from, cfg.Path); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+
+	if err := os.Rename(from, from+restoredSuffix); err != nil {
+		return fmt.Errorf("mark backup as restored: %w", err)
+	}
+
+	slog.Info("restored sqlite database", "from", from, "renamed_to", from+restoredSuffix)
+
+	return nil
+}
+
+// failed logs the error that stops serve and returns the exit code, so that
+// the deferred cleanup of serve still runs.
+func failed(msg string, err error, code int) int {
+	slog.Error(msg, logging.Err(err))
+
+	return code
+}
+
+// newHealthChecker checks the database and, when the process runs the bot,
+// that polling for Telegram updates and the reminder scheduler are alive,
+// reported by the returned heartbeats.
+func newHealthChecker(sqliteRepo *sqlite.RepoSQLite, runsBot bool) (*health.Checker, *health.Heartbeat, *health.Heartbeat) {
+	checker := health.New()
+
+	if sqliteRepo != nil {
+		checker.Add("database", sqliteRepo.Ping)
+	}
+
+	polls := health.NewHeartbeat()
+	ticks := health.NewHeartbeat()
+
+	if runsBot {
+		checker.Add("telegram", polls.Check(pollMaxAge))
+		checker.Add("scheduler", ticks.Check(tickMaxAge))
+	}
+
+	return checker, polls, ticks
+}
+
+// serveOps serves metrics and health checks on a separate listener until ctx is done.
+func serveOps(ctx context.Context, addr string, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		//nolint:contextcheck // need fresh context for shutdown after parent is cancelled
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("shutdown ops server", logging.Err(err))
+		}
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("start ops server on %s: %w", addr, err)
+	}
+
+	return nil
+}
+
+func tokenConfig(cfg config.ServerCfg) tokenmanager.Config {
+	previous := make([]tokenmanager.Key, 0, len(cfg.JWTPreviousKeys))
+	for _, key := range cfg.JWTPreviousKeys {
+		previous = append(previous, tokenmanager.Key{ID: key.ID, Secret: key.Secret, Until: key.Until})
+	}
+
+	return tokenmanager.Config{
+		Current:      tokenmanager.Key{ID: cfg.KeyID(), Secret: cfg.JWTSecret},
+		Previous:     previous,
+		AccessTTL:    cfg.AccessTokenTTL,
+		RefreshTTL:   cfg.RefreshTokenTTL,
+		TelegramRole: repository.Role(cfg.TelegramLogin.Role),
+	}
+}
+
+func tracingConfig(cfg config.TracingCfg) tracing.Config {
+	return tracing.Config{
+		Exporter:    cfg.Exporter,
+		Endpoint:    cfg.Endpoint,
+		Insecure:    cfg.Insecure,
+		SampleRatio: cfg.SampleRatio,
+		ServiceName: cfg.ServiceName,
+	}
 }
 
-func createService(cfg *config.Config) (*trashmanager.Service, func()) {
+// openRepository returns the sqlite repository as well when it is used,
+// so that sqlite-only features like backups can be wired up.
+func openRepository(cfg *config.Config) (store, *sqlite.RepoSQLite, func(), error) {
 	switch cfg.Database.Type {
 	case "sqlite":
 		repo, err := sqlite.New(cfg.Database.Path)
 		if err != nil {
-			log.Fatalf("failed to open sqlite db: %v", err)
+			return nil, nil, nil, fmt.Errorf("open sqlite db: %w", err)
 		}
 
-		log.Printf("Using SQLite database: %s\n", cfg.Database.Path)
+		slog.Info("using sqlite database", "path", cfg.Database.Path)
 
 		cleanup := func() {
 			if err := repo.Close(); err != nil {
-				log.Printf("close sqlite db: %v", err)
+				slog.Error("close sqlite db", logging.Err(err))
 			}
 		}
 
-		return trashmanager.New(repo), cleanup
+		return repo, repo, cleanup, nil
 
-	default:
+	default: // "memory", other types are rejected by config.Validate
 		repo := inmemory.New()
 
-		log.Println("Using in-memory database")
+		slog.Info("using in-memory database")
 
-		return trashmanager.New(repo), func() {}
+		return repo, nil, func() {}, nil
 	}
 }
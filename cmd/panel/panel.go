@@ -5,12 +5,24 @@ import (
 	"embed"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/6ermvH/trash-bot/internal/config"
 	handlers "github.com/6ermvH/trash-bot/internal/handlers/http/v1"
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/adminmanager"
+	"github.com/6ermvH/trash-bot/internal/services/audit"
+	"github.com/6ermvH/trash-bot/internal/services/feed"
+	"github.com/6ermvH/trash-bot/internal/services/health"
+	"github.com/6ermvH/trash-bot/internal/services/metrics"
+	"github.com/6ermvH/trash-bot/internal/services/ratelimit"
+	"github.com/6ermvH/trash-bot/internal/services/reloader"
+	"github.com/6ermvH/trash-bot/internal/services/stats"
+	"github.com/6ermvH/trash-bot/internal/services/telegramauth"
+	"github.com/6ermvH/trash-bot/internal/services/tokenmanager"
 	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
 	"github.com/gin-gonic/gin"
 )
@@ -27,7 +39,7 @@ func serveEmbeddedFile(router *gin.Engine, route, path, contentType string) {
 	router.GET(route, func(ctx *gin.Context) {
 		data, err := webFS.ReadFile(path)
 		if err != nil {
-			log.Printf("read embedded file %s: %v", path, err)
+			slog.ErrorContext(ctx.Request.Context(), "read embedded file", "path", path, logging.Err(err))
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load static file"})
 
 			return
@@ -37,28 +49,166 @@ func serveEmbeddedFile(router *gin.Engine, route, path, contentType string) {
 	})
 }
 
-func Start(ctx context.Context, cfg *config.Config, trashm *trashmanager.Service) error {
-	router := gin.Default()
+// Deps are services used by the panel.
+type Deps struct {
+	Trash  *trashmanager.Service
+	Admins *adminmanager.Service
+	Tokens *tokenmanager.Service
+	Audit  *audit.Service
+	// Snapshotter is nil when the storage does not support backups.
+	Snapshotter handlers.Snapshotter
+	// ChatAccess scopes chats of users logged in with Telegram.
+	ChatAccess handlers.ChatAccess
+	// ResponseErrors is called for responses that do not match the OpenAPI
+	// document when server.validateresponses is set, nil logs them.
+	ResponseErrors handlers.ResponseErrorHandler
+	// Feed streams chat changes to the panel, nil disables /api/events.
+	Feed *feed.Broker
+	// Stats serves /api/stats/detailed, nil disables it.
+	Stats *stats.Service
+	// Metrics counts HTTP requests, nil disables them. /metrics is served by
+	// the panel unless metrics.addr sets a separate listener.
+	Metrics *metrics.Metrics
+	// Health serves /healthz and /readyz, nil disables them.
+	Health *health.Checker
+	// Reloader applies new rate limits and serves /api/admin/config, nil
+	// disables both.
+	Reloader *reloader.Reloader
+}
+
+func newRouter(cfg *config.Config, deps Deps) (*gin.Engine, error) {
+	router := gin.New()
 	router.RedirectTrailingSlash = false
+	router.Use(gin.Recovery(), handlers.TraceMiddleware(), handlers.RequestLogMiddleware())
+
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("set trusted proxies: %w", err)
+	}
+
+	if deps.Metrics != nil {
+		router.Use(deps.Metrics.GinMiddleware())
+
+		if cfg.Metrics.Addr == "" {
+			router.GET("/metrics", gin.WrapH(deps.Metrics.Handler()))
+		}
+	}
+
+	if deps.Health != nil {
+		router.GET("/healthz", gin.WrapF(deps.Health.Healthz))
+		router.GET("/readyz", gin.WrapF(deps.Health.Readyz))
+	}
+
+	spec, err := handlers.LoadOpenAPI()
+	if err != nil {
+		return nil, fmt.Errorf("load openapi document: %w", err)
+	}
 
 	// API routes
 	api := router.Group("/api")
 
-	authHandler := handlers.NewAuthHandler(
-		cfg.Server.AdminLogin,
-		cfg.Server.AdminPassword,
-		cfg.Server.JWTSecret,
-	)
+	var loginGuard handlers.LoginGuard
+
+	if limits := cfg.Server.RateLimit; limits.Enabled {
+		limiter := ratelimit.New(limits.RequestsPerSecond, limits.Burst)
+		guard := ratelimit.NewLoginGuard(limits.LoginPerMinute, limits.LoginBurst, limits.MaxFailures, limits.Lockout)
+
+		api.Use(handlers.RateLimitMiddleware(limiter))
+
+		loginGuard = guard
+
+		if deps.Reloader != nil {
+			deps.Reloader.OnReload(func(_ context.Context, cfg *config.Config) error {
+				limits := cfg.Server.RateLimit
+				limiter.SetLimits(limits.RequestsPerSecond, limits.Burst)
+				guard.SetLimits(limits.LoginPerMinute, limits.LoginBurst, limits.MaxFailures, limits.Lockout)
+
+				return nil
+			})
+		}
+	}
+
+	var onResponseError handlers.ResponseErrorHandler
+
+	if cfg.Server.ValidateResponses {
+		onResponseError = deps.ResponseErrors
+		if onResponseError == nil {
+			onResponseError = handlers.LogResponseError
+		}
+	}
+
+	api.Use(handlers.ValidationMiddleware(spec, onResponseError))
+	api.GET("/openapi.json", handlers.OpenAPI)
+
+	authHandler := handlers.NewAuthHandler(deps.Admins, deps.Tokens, loginGuard).
+		WithSecureCookies(cfg.Server.SecureCookies)
+	if deps.Audit != nil {
+		authHandler.WithAudit(deps.Audit)
+	}
+
+	api.GET("/auth/config", authHandler.Config)
 	api.POST("/login", authHandler.Login)
 
-	handle := handlers.New(trashm)
+	if telegramLogin := cfg.Server.TelegramLogin; telegramLogin.Enabled {
+		verifier := telegramauth.NewVerifier(cfg.Telegram.BotKey, telegramLogin.MaxAge)
+		authHandler.WithTelegram(verifier, telegramLogin.BotUsername)
+		api.POST("/login/telegram", authHandler.TelegramLogin)
+	}
+	api.POST("/refresh", authHandler.Refresh)
+	api.POST("/logout", authHandler.Logout)
+
+	handle := handlers.New(deps.Trash).WithChatAccess(deps.ChatAccess)
+
+	viewer := api.Group("/", handlers.AuthMiddleware(deps.Tokens, repository.RoleViewer))
+	{
+		viewer.GET("/me", authHandler.Me)
+		viewer.GET("/stats", handle.Stats)
+		viewer.GET("/chats", handle.Chats)
+		viewer.GET("/chats/:id", handle.ChatByID)
+	}
+
+	if deps.Stats != nil {
+		handle.WithStats(deps.Stats)
+		viewer.GET("/stats/detailed", handle.DetailedStats)
+	}
+
+	if deps.Feed != nil {
+		handle.WithEvents(deps.Feed, cfg.Server.Events.Heartbeat)
+		viewer.GET("/events", handle.Events)
+	}
+
+	operator := api.Group("/", handlers.AuthMiddleware(deps.Tokens, repository.RoleOperator))
+	{
+		operator.POST("/chats/:id/next", handle.Next)
+		operator.POST("/chats/:id/prev", handle.Prev)
+		operator.PUT("/chats/:id/members", handle.SetMembers)
+		operator.PUT("/chats/:id/subscription", handle.Subscribe)
+		operator.DELETE("/chats/:id/subscription", handle.Unsubscribe)
+	}
+
+	adminsHandler := handlers.NewAdminsHandler(deps.Admins)
 
-	protected := api.Group("/")
-	protected.Use(handlers.AuthMiddleware(cfg.Server.JWTSecret))
+	owner := api.Group("/", handlers.AuthMiddleware(deps.Tokens, repository.RoleOwner))
 	{
-		protected.GET("/stats", handle.Stats)
-		protected.GET("/chats", handle.Chats)
-		protected.GET("/chats/:id", handle.ChatByID)
+		owner.GET("/export", handle.Export)
+		owner.POST("/import", handle.Import)
+		owner.GET("/admins", adminsHandler.List)
+		owner.POST("/admins", adminsHandler.Create)
+		owner.PUT("/admins/:login", adminsHandler.Update)
+		owner.DELETE("/admins/:login", adminsHandler.Delete)
+	}
+
+	if deps.Audit != nil {
+		auditHandler := handlers.NewAuditHandler(deps.Audit)
+		owner.GET("/audit", auditHandler.List)
+	}
+
+	if deps.Reloader != nil {
+		owner.GET("/admin/config", handlers.NewConfigHandler(deps.Reloader).Get)
+	}
+
+	if deps.Snapshotter != nil {
+		backupHandler := handlers.NewBackupHandler(deps.Snapshotter)
+		owner.POST("/admin/backup", backupHandler.Backup)
 	}
 
 	// Static files
@@ -66,6 +216,15 @@ func Start(ctx context.Context, cfg *config.Config, trashm *trashmanager.Service
 	serveEmbeddedFile(router, "/style.css", "web/style.css", "text/css; charset=utf-8")
 	serveEmbeddedFile(router, "/app.js", "web/app.js", "application/javascript; charset=utf-8")
 
+	return router, nil
+}
+
+func Start(ctx context.Context, cfg *config.Config, deps Deps) error {
+	router, err := newRouter(cfg, deps)
+	if err != nil {
+		return err
+	}
+
 	addr := cfg.Server.Addr + ":" + cfg.Server.Port
 
 	srv := &http.Server{
@@ -77,12 +236,17 @@ func Start(ctx context.Context, cfg *config.Config, trashm *trashmanager.Service
 	go func() {
 		<-ctx.Done()
 
+		// Открытые потоки событий иначе задержат Shutdown до таймаута
+		if deps.Feed != nil {
+			deps.Feed.Close()
+		}
+
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer cancel()
 
 		//nolint:contextcheck // need fresh context for shutdown after parent is cancelled
 		if err := srv.Shutdown(shutdownCtx); err != nil {
-			log.Printf("HTTP server shutdown error: %v", err)
+			slog.Error("shutdown http server", logging.Err(err))
 		}
 	}()
 
@@ -0,0 +1,208 @@
+package panel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/config"
+	handlers "github.com/6ermvH/trash-bot/internal/handlers/http/v1"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/6ermvH/trash-bot/internal/services/adminmanager"
+	"github.com/6ermvH/trash-bot/internal/services/audit"
+	"github.com/6ermvH/trash-bot/internal/services/feed"
+	"github.com/6ermvH/trash-bot/internal/services/health"
+	"github.com/6ermvH/trash-bot/internal/services/metrics"
+	"github.com/6ermvH/trash-bot/internal/services/reloader"
+	"github.com/6ermvH/trash-bot/internal/services/stats"
+	"github.com/6ermvH/trash-bot/internal/services/tokenmanager"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type snapshotterStub struct{}
+
+func (snapshotterStub) Snapshot(_ context.Context, w io.Writer) error {
+	_, err := w.Write([]byte("SQLite format 3"))
+
+	return err
+}
+
+// newTestRouter builds the router with every optional route enabled. Responses
+// that do not match the OpenAPI document fail the test.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	repo := inmemory.New()
+	admins := adminmanager.New(repo)
+
+	_, err := admins.Create(t.Context(), "owner", "owner-password", repository.RoleOwner)
+	require.NoError(t, err)
+
+	tokens, err := tokenmanager.New(repo, admins, tokenmanager.Config{
+		Current: tokenmanager.Key{ID: "test", Secret: "test-secret"},
+	})
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		Telegram: config.TelegramCfg{BotKey: "123:test"},
+		Server: config.ServerCfg{
+			TelegramLogin:     config.TelegramLoginCfg{Enabled: true, BotUsername: "trash_bot"},
+			ValidateResponses: true,
+		},
+	}
+
+	trashm := trashmanager.New(repo)
+	statsService := stats.New(repo, trashm)
+	trashm.OnEvent(statsService.Handler())
+
+	router, err := newRouter(cfg, Deps{
+		Trash:       trashm,
+		Admins:      admins,
+		Tokens:      tokens,
+		Audit:       audit.New(repo, 0),
+		Snapshotter: snapshotterStub{},
+		Feed:        feed.New(0),
+		Stats:       statsService,
+		Metrics:     metrics.New(repo),
+		Health:      health.New().Add("database", func(context.Context) error { return nil }),
+		Reloader:    reloader.New("config.yaml", cfg),
+		ResponseErrors: func(ctx *gin.Context, err error) {
+			t.Errorf("%s %s: %v", ctx.Request.Method, ctx.Request.URL, err)
+		},
+	})
+	require.NoError(t, err)
+
+	return router
+}
+
+func TestRouter_Health(t *testing.T) {
+	t.Parallel()
+
+	router := newTestRouter(t)
+
+	for _, path := range []string{"/healthz", "/readyz", "/metrics"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+	}
+}
+
+func TestRouter_RoutesMatchOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	doc, err := handlers.LoadOpenAPI()
+	require.NoError(t, err)
+
+	registered := make(map[string]bool)
+
+	for _, route := range newTestRouter(t).Routes() {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+
+		registered[route.Method+" "+route.Path] = true
+
+		require.NotNil(t, doc.Operation(route.Method, route.Path),
+			"route %s %s is missing from openapi.json", route.Method, route.Path)
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item.Operations() {
+			routePath := doc.BasePath() + strings.NewReplacer("{", ":", "}", "").Replace(path)
+
+			require.True(t, registered[method+" "+routePath],
+				"operation %s %s from openapi.json is not registered", method, path)
+		}
+	}
+}
+
+func TestRouter_Contract(t *testing.T) {
+	t.Parallel()
+
+	router := newTestRouter(t)
+
+	var token string
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequestWithContext(t.Context(), method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/api/login", `{"login": "owner", "password": "wrong"}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/login", `{"login": "owner"}`).Code)
+
+	rec := do(http.MethodPost, "/api/login", `{"login": "owner", "password": "owner-password"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var login handlers.LoginResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+
+	token = login.Token
+
+	steps := []struct {
+		method string
+		target string
+		body   string
+		code   int
+	}{
+		{http.MethodGet, "/api/openapi.json", "", http.StatusOK},
+		{http.MethodGet, "/api/auth/config", "", http.StatusOK},
+		{http.MethodGet, "/api/me", "", http.StatusOK},
+		{http.MethodGet, "/api/chats/1", "", http.StatusNotFound},
+		{http.MethodPut, "/api/chats/1/members", `{"members": ["German", "Anthon"]}`, http.StatusOK},
+		{http.MethodPost, "/api/chats/1/next", "", http.StatusOK},
+		{http.MethodPost, "/api/chats/1/prev", "", http.StatusOK},
+		{http.MethodPut, "/api/chats/1/subscription", `{"notifyTime": "09:00"}`, http.StatusOK},
+		{http.MethodPut, "/api/chats/1/subscription", `{"notifyTime": "9am"}`, http.StatusBadRequest},
+		{http.MethodGet, "/api/chats/1", "", http.StatusOK},
+		{http.MethodDelete, "/api/chats/1/subscription", "", http.StatusOK},
+		{http.MethodGet, "/api/chats", "", http.StatusOK},
+		{http.MethodGet, "/api/chats?member=ger&subscribed=false&sort=members&order=desc&limit=1", "", http.StatusOK},
+		{http.MethodGet, "/api/chats?sort=name", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/chats?cursor=garbage", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/stats", "", http.StatusOK},
+		{http.MethodGet, "/api/stats/detailed?days=7", "", http.StatusOK},
+		{http.MethodGet, "/api/stats/detailed?days=0", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/export", "", http.StatusOK},
+		{http.MethodPost, "/api/import?dryRun=true", `{"version": 1, "chats": [{"id": 2, "currentUser": 0, "activeUsers": ["Vitaly"]}]}`, http.StatusOK},
+		{http.MethodPost, "/api/import", `{"version": 1, "chats": [{"id": 0, "currentUser": 0, "activeUsers": []}]}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/api/admins", "", http.StatusOK},
+		{http.MethodPost, "/api/admins", `{"login": "viewer", "password": "viewer-password", "role": "viewer"}`, http.StatusCreated},
+		{http.MethodPut, "/api/admins/viewer", `{"role": "operator"}`, http.StatusNoContent},
+		{http.MethodPut, "/api/admins/viewer", `{"role": "root"}`, http.StatusBadRequest},
+		{http.MethodDelete, "/api/admins/viewer", "", http.StatusNoContent},
+		{http.MethodGet, "/api/audit?chatId=1&limit=2", "", http.StatusOK},
+		{http.MethodGet, "/api/audit?limit=0", "", http.StatusBadRequest},
+		{http.MethodPost, "/api/admin/backup", "", http.StatusOK},
+		{http.MethodGet, "/api/admin/config", "", http.StatusOK},
+		{http.MethodPost, "/api/login/telegram", `{"id": 1, "auth_date": 1, "hash": "bad"}`, http.StatusUnauthorized},
+		{http.MethodPost, "/api/refresh", `{"refreshToken": "` + login.RefreshToken + `"}`, http.StatusOK},
+		{http.MethodPost, "/api/logout", "", http.StatusNoContent},
+		{http.MethodGet, "/api/me", "", http.StatusUnauthorized},
+	}
+
+	for _, step := range steps {
+		rec := do(step.method, step.target, step.body)
+		require.Equal(t, step.code, rec.Code, "%s %s: %s", step.method, step.target, rec.Body.String())
+	}
+}
@@ -1,7 +1,11 @@
 const API_BASE = '/api';
 
+const ROLES = ['viewer', 'operator', 'owner'];
+
 const state = {
-    token: localStorage.getItem('token') || null
+    token: null,
+    login: null,
+    role: null
 };
 
 const elements = {
@@ -9,16 +13,71 @@ const elements = {
     dashboardSection: document.getElementById('dashboard-section'),
     loginForm: document.getElementById('login-form'),
     loginError: document.getElementById('login-error'),
+    telegramLogin: document.getElementById('telegram-login'),
     logoutBtn: document.getElementById('logout-btn'),
+    backupBtn: document.getElementById('backup-btn'),
     totalChats: document.getElementById('total-chats'),
     totalUsers: document.getElementById('total-users'),
     avgUsers: document.getElementById('avg-users'),
+    activityDays: document.getElementById('activity-days'),
+    activitySummary: document.getElementById('activity-summary'),
+    activityChart: document.getElementById('activity-chart'),
+    activityMembers: document.getElementById('activity-members'),
+    activityCommands: document.getElementById('activity-commands'),
+    fairnessBody: document.getElementById('fairness-body'),
     chatsBody: document.getElementById('chats-body'),
     chatsTable: document.getElementById('chats-table'),
-    noChats: document.getElementById('no-chats')
+    noChats: document.getElementById('no-chats'),
+    chatsFilter: document.getElementById('chats-filter'),
+    chatsMore: document.getElementById('chats-more'),
+    announceChanges: document.getElementById('announce-changes'),
+    currentAdmin: document.getElementById('current-admin'),
+    adminsBody: document.getElementById('admins-body'),
+    adminForm: document.getElementById('admin-form'),
+    adminError: document.getElementById('admin-error'),
+    auditForm: document.getElementById('audit-form'),
+    auditBody: document.getElementById('audit-body'),
+    auditMore: document.getElementById('audit-more')
 };
 
-async function apiRequest(endpoint, options = {}) {
+const EVENTS_RETRY_DELAY = 3000;
+const STATS_REFRESH_DELAY = 500;
+
+let auditNextBefore = 0;
+let chatsNextCursor = '';
+
+// Live updates: the stream is read with fetch to send the token in a header
+let eventsController = null;
+let lastEventId = '';
+let statsTimer = null;
+
+// The access token lives only in memory, the refresh token is an HttpOnly cookie.
+let refreshPromise = null;
+
+function refreshToken() {
+    // Concurrent requests share one refresh: a refresh token can be used only once
+    if (!refreshPromise) {
+        refreshPromise = fetch(`${API_BASE}/refresh`, { method: 'POST' })
+            .then(async response => {
+                if (!response.ok) {
+                    state.token = null;
+                    return false;
+                }
+
+                const data = await response.json();
+                state.token = data.token;
+                return true;
+            })
+            .catch(() => false)
+            .finally(() => {
+                refreshPromise = null;
+            });
+    }
+
+    return refreshPromise;
+}
+
+async function apiRequest(endpoint, options = {}, retry = true) {
     const headers = {
         'Content-Type': 'application/json',
         ...options.headers
@@ -34,7 +93,11 @@ async function apiRequest(endpoint, options = {}) {
     });
 
     if (response.status === 401) {
-        logout();
+        if (retry && await refreshToken()) {
+            return apiRequest(endpoint, options, false);
+        }
+
+        resetSession();
         throw new Error('Unauthorized');
     }
 
@@ -55,15 +118,95 @@ async function login(loginValue, password) {
 
     const data = await response.json();
     state.token = data.token;
-    localStorage.setItem('token', data.token);
 }
 
-function logout() {
+async function telegramLogin(user) {
+    const response = await fetch(`${API_BASE}/login/telegram`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(user)
+    });
+
+    if (!response.ok) {
+        const data = await response.json();
+        throw new Error(data.error || 'Telegram login failed');
+    }
+
+    const data = await response.json();
+    state.token = data.token;
+}
+
+// Called by the Telegram Login Widget
+window.onTelegramAuth = async (user) => {
+    elements.loginError.textContent = '';
+
+    try {
+        await telegramLogin(user);
+        showDashboard();
+    } catch (error) {
+        elements.loginError.textContent = error.message;
+    }
+};
+
+async function setupTelegramLogin() {
+    try {
+        const response = await fetch(`${API_BASE}/auth/config`);
+        const config = await response.json();
+
+        if (!config.telegramBot) {
+            return;
+        }
+
+        const script = document.createElement('script');
+        script.async = true;
+        script.src = 'https://telegram.org/js/telegram-widget.js?22';
+        script.dataset.telegramLogin = config.telegramBot;
+        script.dataset.size = 'large';
+        script.dataset.onauth = 'onTelegramAuth(user)';
+
+        elements.telegramLogin.appendChild(script);
+        elements.telegramLogin.classList.remove('hidden');
+    } catch (error) {
+        console.error('Failed to load login config:', error);
+    }
+}
+
+async function logout() {
+    const headers = {};
+    if (state.token) {
+        headers['Authorization'] = `Bearer ${state.token}`;
+    }
+
+    try {
+        await fetch(`${API_BASE}/logout`, { method: 'POST', headers });
+    } catch (error) {
+        console.error('Failed to logout:', error);
+    }
+
+    resetSession();
+}
+
+function resetSession() {
+    stopEvents();
     state.token = null;
-    localStorage.removeItem('token');
+    state.login = null;
+    state.role = null;
+    setRole(null);
     showLogin();
 }
 
+function setRole(role) {
+    ROLES.forEach(r => document.body.classList.remove(`role-${r}`));
+
+    if (role) {
+        document.body.classList.add(`role-${role}`);
+    }
+}
+
+function canOperate() {
+    return state.role === 'operator' || state.role === 'owner';
+}
+
 function showLogin() {
     elements.loginSection.classList.remove('hidden');
     elements.dashboardSection.classList.add('hidden');
@@ -73,10 +216,34 @@ function showDashboard() {
     elements.loginSection.classList.add('hidden');
     elements.dashboardSection.classList.remove('hidden');
     loadDashboard();
+    startEvents();
 }
 
 async function loadDashboard() {
-    await Promise.all([loadStats(), loadChats()]);
+    await loadMe();
+
+    const loaders = [loadStats(), loadActivity(), loadChats()];
+    if (state.role === 'owner') {
+        loaders.push(loadAdmins(), loadAudit());
+    }
+
+    await Promise.all(loaders);
+}
+
+async function loadMe() {
+    try {
+        const response = await apiRequest('/me');
+        const me = await response.json();
+
+        state.login = me.login;
+        state.role = me.role;
+
+        const name = me.telegramUsername ? `@${me.telegramUsername}` : me.login;
+        elements.currentAdmin.textContent = `${name} (${me.role})`;
+        setRole(me.role);
+    } catch (error) {
+        console.error('Failed to load current admin:', error);
+    }
 }
 
 async function loadStats() {
@@ -92,32 +259,517 @@ async function loadStats() {
     }
 }
 
-async function loadChats() {
+async function loadActivity() {
     try {
-        const response = await apiRequest('/chats');
-        const chats = await response.json();
+        const response = await apiRequest(`/stats/detailed?days=${elements.activityDays.value}`);
+        const stats = await response.json();
 
-        if (chats.length === 0) {
-            elements.chatsTable.classList.add('hidden');
-            elements.noChats.classList.remove('hidden');
-            return;
+        if (!response.ok) {
+            throw new Error(stats.error || 'Failed to load activity');
         }
 
-        elements.chatsTable.classList.remove('hidden');
-        elements.noChats.classList.add('hidden');
+        const summary = [
+            ['Duties', stats.dutiesTotal],
+            ['Active chats, 7 days', stats.activeChats7d],
+            ['Active chats, 30 days', stats.activeChats30d],
+            ['Reminders sent', stats.remindersSent],
+            ['Reminders failed', stats.remindersFailed]
+        ];
 
-        elements.chatsBody.innerHTML = chats.map(chat => `
-            <tr>
-                <td>${chat.id}</td>
-                <td>${chat.activeUsers[chat.currentUser] || '-'}</td>
-                <td>${chat.activeUsers.join(', ')}</td>
-            </tr>
-        `).join('');
+        elements.activitySummary.innerHTML = summary
+            .map(([label, value]) => `<div><strong>${value}</strong>${label}</div>`)
+            .join('');
+
+        elements.activityChart.setAttribute('viewBox', `0 0 ${stats.daily.length * 10} 100`);
+        elements.activityChart.innerHTML = renderDailyChart(stats.daily);
+        elements.activityMembers.innerHTML = renderBars(stats.members.map(m => [m.name, m.duties]));
+        elements.activityCommands.innerHTML = renderBars(stats.commands.map(c => [`/${c.command}`, c.count]));
+        elements.fairnessBody.innerHTML = stats.chats.map(renderFairnessRow).join('');
+    } catch (error) {
+        console.error('Failed to load activity:', error);
+    }
+}
+
+// renderDailyChart draws duties as bars and commands as a line in a 10 by 100
+// box per day, the SVG is stretched to the width of the card.
+function renderDailyChart(daily) {
+    const height = 100;
+    const maxValue = Math.max(1, ...daily.map(d => Math.max(d.duties, d.commands)));
+    const scale = value => height - (Math.max(value, 0) / maxValue) * (height - 5);
+
+    const bars = daily.map((day, index) => {
+        const y = scale(day.duties);
+        const title = `${day.day}: ${day.duties} duties, ${day.commands} commands, ` +
+            `${day.activeChats} active chats, ${day.remindersSent} reminders`;
+
+        return `<rect class="bar" x="${index * 10 + 1}" y="${y}" width="8" height="${height - y}">` +
+            `<title>${escapeHtml(title)}</title></rect>`;
+    }).join('');
+
+    const points = daily.map((day, index) => `${index * 10 + 5},${scale(day.commands)}`).join(' ');
+
+    return `${bars}<polyline class="line" points="${points}"></polyline>`;
+}
+
+function renderBars(items) {
+    if (items.length === 0) {
+        return '<p class="bar-value">No data</p>';
+    }
+
+    const maxValue = Math.max(1, ...items.map(([, value]) => value));
+
+    return items.map(([label, value]) => `
+        <div class="bar-row">
+            <span class="bar-label" title="${escapeHtml(label)}">${escapeHtml(label)}</span>
+            <div class="bar-track">
+                <div class="bar-fill" style="width: ${Math.max(value, 0) / maxValue * 100}%"></div>
+            </div>
+            <span class="bar-value">${value}</span>
+        </div>
+    `).join('');
+}
+
+function renderFairnessRow(chat) {
+    const members = chat.members.map(m => `${m.name} ${m.duties}`).join(', ');
+    const reminders = chat.remindersFailed
+        ? `${chat.remindersSent} (${chat.remindersFailed} failed)`
+        : chat.remindersSent;
+
+    return `
+        <tr>
+            <td>${chat.chatId}</td>
+            <td>${chat.duties}</td>
+            <td>${chat.spread}</td>
+            <td>${escapeHtml(members || '-')}</td>
+            <td>${reminders}</td>
+        </tr>
+    `;
+}
+
+async function loadChats(append = false) {
+    const params = new URLSearchParams();
+    const filters = {
+        member: document.getElementById('chats-member').value.trim(),
+        subscribed: document.getElementById('chats-subscribed').value,
+        empty: document.getElementById('chats-empty').value,
+        sort: document.getElementById('chats-sort').value,
+        order: document.getElementById('chats-order').value
+    };
+
+    Object.entries(filters).forEach(([key, value]) => {
+        if (value) {
+            params.set(key, value);
+        }
+    });
+
+    if (append && chatsNextCursor) {
+        params.set('cursor', chatsNextCursor);
+    }
+
+    try {
+        const response = await apiRequest(`/chats?${params}`);
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Failed to load chats');
+        }
+
+        const rows = data.chats.map(renderChatRow).join('');
+        if (append) {
+            elements.chatsBody.insertAdjacentHTML('beforeend', rows);
+        } else {
+            elements.chatsBody.innerHTML = rows;
+        }
+
+        const empty = elements.chatsBody.children.length === 0;
+        elements.chatsTable.classList.toggle('hidden', empty);
+        elements.noChats.classList.toggle('hidden', !empty);
+
+        chatsNextCursor = data.nextCursor || '';
+        elements.chatsMore.classList.toggle('hidden', !chatsNextCursor);
     } catch (error) {
         console.error('Failed to load chats:', error);
     }
 }
 
+function escapeHtml(value) {
+    return String(value)
+        .replace(/&/g, '&amp;')
+        .replace(/</g, '&lt;')
+        .replace(/>/g, '&gt;')
+        .replace(/"/g, '&quot;')
+        .replace(/'/g, '&#39;');
+}
+
+function renderChatRow(chat) {
+    const current = chat.activeUsers[chat.currentUser] || '-';
+
+    return `
+        <tr data-chat-id="${chat.id}">
+            <td>${chat.id}</td>
+            <td>${escapeHtml(current)}</td>
+            <td>${escapeHtml(chat.activeUsers.join(', '))}</td>
+            <td>${escapeHtml(chat.notifyTime || '-')}</td>
+            ${canOperate() ? `<td class="actions">
+                <button class="btn btn-small" data-action="prev" data-id="${chat.id}">Prev</button>
+                <button class="btn btn-small" data-action="next" data-id="${chat.id}">Next</button>
+                <button class="btn btn-small" data-action="members" data-id="${chat.id}"
+                    data-members="${escapeHtml(chat.activeUsers.join(' '))}">Members</button>
+                <button class="btn btn-small" data-action="subscribe" data-id="${chat.id}"
+                    data-time="${escapeHtml(chat.notifyTime || '')}">Notify</button>
+                ${chat.notifyTime
+                    ? `<button class="btn btn-small" data-action="unsubscribe" data-id="${chat.id}">Unsubscribe</button>`
+                    : ''}
+            </td>` : ''}
+        </tr>
+    `;
+}
+
+function startEvents() {
+    stopEvents();
+
+    eventsController = new AbortController();
+    streamEvents(eventsController.signal);
+}
+
+function stopEvents() {
+    if (eventsController) {
+        eventsController.abort();
+        eventsController = null;
+    }
+
+    lastEventId = '';
+}
+
+async function streamEvents(signal) {
+    while (!signal.aborted) {
+        try {
+            const headers = { 'Accept': 'text/event-stream' };
+            if (lastEventId) {
+                headers['Last-Event-ID'] = lastEventId;
+            }
+
+            const response = await apiRequest('/events', { headers, signal });
+            if (!response.ok) {
+                throw new Error(`Event stream failed with status ${response.status}`);
+            }
+
+            await readEvents(response.body);
+        } catch (error) {
+            if (signal.aborted) {
+                return;
+            }
+
+            console.error('Event stream interrupted:', error);
+        }
+
+        // The server ends the stream when the access token expires, the next
+        // request refreshes it and resumes after the last event
+        await new Promise(resolve => setTimeout(resolve, EVENTS_RETRY_DELAY));
+    }
+}
+
+async function readEvents(body) {
+    const reader = body.pipeThrough(new TextDecoderStream()).getReader();
+    let buffer = '';
+
+    for (;;) {
+        const { value, done } = await reader.read();
+        if (done) {
+            return;
+        }
+
+        buffer += value.replace(/\r\n?/g, '\n');
+
+        let end;
+        while ((end = buffer.indexOf('\n\n')) !== -1) {
+            const block = buffer.slice(0, end);
+            buffer = buffer.slice(end + 2);
+
+            const event = parseEvent(block);
+            if (event) {
+                handleEvent(event);
+            }
+        }
+    }
+}
+
+function parseEvent(block) {
+    const event = { id: '', name: '', data: [] };
+
+    block.split('\n').forEach(line => {
+        // Lines starting with a colon are heartbeat comments
+        if (!line || line.startsWith(':')) {
+            return;
+        }
+
+        const colon = line.indexOf(':');
+        const field = colon === -1 ? line : line.slice(0, colon);
+        const value = colon === -1 ? '' : line.slice(colon + 1).replace(/^ /, '');
+
+        if (field === 'id') {
+            event.id = value;
+        } else if (field === 'event') {
+            event.name = value;
+        } else if (field === 'data') {
+            event.data.push(value);
+        }
+    });
+
+    return event.name ? event : null;
+}
+
+function handleEvent(event) {
+    if (event.id) {
+        lastEventId = event.id;
+    }
+
+    switch (event.name) {
+        case 'ready':
+            return;
+        case 'reset':
+            // Missed changes are no longer buffered on the server
+            loadDashboard();
+            return;
+        default:
+            applyChatEvent(JSON.parse(event.data.join('\n')));
+    }
+}
+
+function applyChatEvent(event) {
+    const row = elements.chatsBody.querySelector(`tr[data-chat-id="${event.chatId}"]`);
+
+    if (!event.chat) {
+        if (row) {
+            row.remove();
+        }
+    } else if (row) {
+        row.outerHTML = renderChatRow(event.chat);
+    } else {
+        // A new chat may belong anywhere in the sorted and filtered table
+        loadChats();
+    }
+
+    const empty = elements.chatsBody.children.length === 0;
+    elements.chatsTable.classList.toggle('hidden', empty);
+    elements.noChats.classList.toggle('hidden', !empty);
+
+    // An import sends an event per chat, stats are loaded once after them
+    clearTimeout(statsTimer);
+    statsTimer = setTimeout(() => {
+        loadStats();
+        loadActivity();
+    }, STATS_REFRESH_DELAY);
+}
+
+async function chatAction(id, path, method, body) {
+    const query = elements.announceChanges.checked ? '?announce=true' : '';
+
+    const response = await apiRequest(`/chats/${id}${path}${query}`, {
+        method,
+        body: body ? JSON.stringify(body) : undefined
+    });
+
+    if (!response.ok) {
+        const data = await response.json().catch(() => ({}));
+        throw new Error(data.error || 'Request failed');
+    }
+}
+
+async function handleChatAction(button) {
+    const id = button.dataset.id;
+
+    switch (button.dataset.action) {
+        case 'next':
+            return chatAction(id, '/next', 'POST');
+        case 'prev':
+            return chatAction(id, '/prev', 'POST');
+        case 'members': {
+            const input = prompt('Members separated by spaces:', button.dataset.members);
+            if (input === null) {
+                return;
+            }
+
+            const members = input.split(/\s+/).filter(Boolean);
+            if (members.length === 0) {
+                throw new Error('At least one member is required');
+            }
+
+            return chatAction(id, '/members', 'PUT', { members });
+        }
+        case 'subscribe': {
+            const notifyTime = prompt('Daily reminder time (HH:MM):', button.dataset.time || '09:00');
+            if (notifyTime === null) {
+                return;
+            }
+
+            if (!/^([01]\d|2[0-3]):[0-5]\d$/.test(notifyTime)) {
+                throw new Error('Time must be in HH:MM format');
+            }
+
+            return chatAction(id, '/subscription', 'PUT', { notifyTime });
+        }
+        case 'unsubscribe':
+            return chatAction(id, '/subscription', 'DELETE');
+    }
+}
+
+async function loadAdmins() {
+    try {
+        const response = await apiRequest('/admins');
+        const admins = await response.json();
+
+        elements.adminsBody.innerHTML = admins.map(renderAdminRow).join('');
+    } catch (error) {
+        console.error('Failed to load admins:', error);
+    }
+}
+
+function renderAdminRow(admin) {
+    const login = escapeHtml(admin.login);
+    const options = ROLES.map(role =>
+        `<option value="${role}" ${role === admin.role ? 'selected' : ''}>${role}</option>`
+    ).join('');
+
+    return `
+        <tr>
+            <td>${login}</td>
+            <td><select data-admin-role="${login}">${options}</select></td>
+            <td>${new Date(admin.createdAt).toLocaleString()}</td>
+            <td class="actions">
+                <button class="btn btn-small" data-admin-action="password" data-login="${login}">Reset password</button>
+                <button class="btn btn-small" data-admin-action="delete" data-login="${login}">Delete</button>
+            </td>
+        </tr>
+    `;
+}
+
+async function adminRequest(path, method, body) {
+    const response = await apiRequest(`/admins${path}`, {
+        method,
+        body: body ? JSON.stringify(body) : undefined
+    });
+
+    if (!response.ok) {
+        const data = await response.json().catch(() => ({}));
+        throw new Error(data.error || 'Request failed');
+    }
+}
+
+async function handleAdminAction(button) {
+    const login = button.dataset.login;
+    const path = `/${encodeURIComponent(login)}`;
+
+    switch (button.dataset.adminAction) {
+        case 'password': {
+            const password = prompt(`New password for ${login}:`);
+            if (password === null) {
+                return;
+            }
+
+            return adminRequest(path, 'PUT', { password });
+        }
+        case 'delete':
+            if (!confirm(`Delete admin ${login}?`)) {
+                return;
+            }
+
+            return adminRequest(path, 'DELETE');
+    }
+}
+
+async function loadAudit(append = false) {
+    const params = new URLSearchParams();
+    const filters = {
+        chatId: document.getElementById('audit-chat').value,
+        actor: document.getElementById('audit-actor').value.trim(),
+        source: document.getElementById('audit-source').value
+    };
+
+    Object.entries(filters).forEach(([key, value]) => {
+        if (value) {
+            params.set(key, value);
+        }
+    });
+
+    if (append && auditNextBefore) {
+        params.set('before', auditNextBefore);
+    }
+
+    try {
+        const response = await apiRequest(`/audit?${params}`);
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Failed to load audit log');
+        }
+
+        const rows = data.entries.map(renderAuditRow).join('');
+        if (append) {
+            elements.auditBody.insertAdjacentHTML('beforeend', rows);
+        } else {
+            elements.auditBody.innerHTML = rows;
+        }
+
+        auditNextBefore = data.nextBefore || 0;
+        elements.auditMore.classList.toggle('hidden', !auditNextBefore);
+    } catch (error) {
+        console.error('Failed to load audit log:', error);
+    }
+}
+
+function describeChat(chat) {
+    if (!chat) {
+        return '—';
+    }
+
+    const users = chat.activeUsers || [];
+    const current = users[chat.currentUser] || '—';
+
+    return `${current} of [${users.join(', ')}]${chat.notifyTime ? ` at ${chat.notifyTime}` : ''}`;
+}
+
+function renderAuditRow(entry) {
+    const actor = entry.actorName || entry.actorId || entry.actorType;
+    const change = entry.details || `${describeChat(entry.before)} → ${describeChat(entry.after)}`;
+
+    return `
+        <tr>
+            <td>${new Date(entry.occurredAt).toLocaleString()}</td>
+            <td title="${escapeHtml(entry.actorType)} ${escapeHtml(entry.actorId)}">${escapeHtml(actor)}</td>
+            <td>${escapeHtml(entry.source)}</td>
+            <td>${entry.chatId || ''}</td>
+            <td>${escapeHtml(entry.action)}</td>
+            <td>${escapeHtml(change)}</td>
+        </tr>
+    `;
+}
+
+async function downloadBackup() {
+    try {
+        const response = await apiRequest('/admin/backup', { method: 'POST' });
+
+        if (!response.ok) {
+            const data = await response.json().catch(() => ({}));
+            throw new Error(data.error || 'Backup failed');
+        }
+
+        const disposition = response.headers.get('Content-Disposition') || '';
+        const match = disposition.match(/filename="([^"]+)"/);
+
+        const blob = await response.blob();
+        const url = URL.createObjectURL(blob);
+        const link = document.createElement('a');
+        link.href = url;
+        link.download = match ? match[1] : 'trash.db';
+        link.click();
+        URL.revokeObjectURL(url);
+    } catch (error) {
+        console.error('Failed to download backup:', error);
+        alert(error.message);
+    }
+}
+
 elements.loginForm.addEventListener('submit', async (e) => {
     e.preventDefault();
     elements.loginError.textContent = '';
@@ -133,11 +785,102 @@ elements.loginForm.addEventListener('submit', async (e) => {
     }
 });
 
+elements.chatsFilter.addEventListener('submit', (e) => {
+    e.preventDefault();
+    loadChats();
+});
+
+elements.chatsMore.addEventListener('click', () => loadChats(true));
+
+elements.activityDays.addEventListener('change', loadActivity);
+
+elements.chatsBody.addEventListener('click', async (e) => {
+    const button = e.target.closest('button[data-action]');
+    if (!button) {
+        return;
+    }
+
+    button.disabled = true;
+
+    try {
+        await handleChatAction(button);
+        await loadDashboard();
+    } catch (error) {
+        console.error('Chat action failed:', error);
+        alert(error.message);
+    } finally {
+        button.disabled = false;
+    }
+});
+
+elements.adminsBody.addEventListener('click', async (e) => {
+    const button = e.target.closest('button[data-admin-action]');
+    if (!button) {
+        return;
+    }
+
+    elements.adminError.textContent = '';
+
+    try {
+        await handleAdminAction(button);
+        await loadAdmins();
+    } catch (error) {
+        elements.adminError.textContent = error.message;
+    }
+});
+
+elements.adminsBody.addEventListener('change', async (e) => {
+    const select = e.target.closest('select[data-admin-role]');
+    if (!select) {
+        return;
+    }
+
+    elements.adminError.textContent = '';
+
+    try {
+        await adminRequest(`/${encodeURIComponent(select.dataset.adminRole)}`, 'PUT', { role: select.value });
+    } catch (error) {
+        elements.adminError.textContent = error.message;
+    }
+
+    await loadAdmins();
+});
+
+elements.adminForm.addEventListener('submit', async (e) => {
+    e.preventDefault();
+    elements.adminError.textContent = '';
+
+    try {
+        await adminRequest('', 'POST', {
+            login: document.getElementById('admin-login').value,
+            password: document.getElementById('admin-password').value,
+            role: document.getElementById('admin-role').value
+        });
+
+        elements.adminForm.reset();
+        await loadAdmins();
+    } catch (error) {
+        elements.adminError.textContent = error.message;
+    }
+});
+
+elements.auditForm.addEventListener('submit', (e) => {
+    e.preventDefault();
+    loadAudit();
+});
+
+elements.auditMore.addEventListener('click', () => loadAudit(true));
+
 elements.logoutBtn.addEventListener('click', logout);
+elements.backupBtn.addEventListener('click', downloadBackup);
 
-// Initial state
-if (state.token) {
-    showDashboard();
-} else {
-    showLogin();
-}
+setupTelegramLogin();
+
+// Initial state: restore the session from the refresh cookie
+refreshToken().then(ok => {
+    if (ok) {
+        showDashboard();
+    } else {
+        showLogin();
+    }
+});
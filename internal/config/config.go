@@ -2,24 +2,92 @@ package config
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Modes select what the process runs. The bot and the panel running as
+// separate processes share a sqlite database.
+const (
+	ModeAll   = "all"
+	ModeBot   = "bot"
+	ModePanel = "panel"
+)
+
+// DefaultJWTKeyID is the kid of server.jwtsecret when server.jwtkeyid is empty.
+const DefaultJWTKeyID = "v1"
+
 // Config is type configuration of service.
 type Config struct {
+	Mode     string      `yaml:"mode"` // "all", "bot" or "panel", empty is "all"
 	Telegram TelegramCfg `yaml:"telegram"`
 	Server   ServerCfg   `yaml:"server"`
 	Database DatabaseCfg `yaml:"database"`
+	Metrics  MetricsCfg  `yaml:"metrics"`
+	Log      LogCfg      `yaml:"log"`
+	Tracing  TracingCfg  `yaml:"tracing"`
+}
+
+// TracingCfg is type OpenTelemetry tracing configuration.
+type TracingCfg struct {
+	Exporter string `yaml:"exporter"` // "otlp", "stdout" or "none"
+	// Endpoint is the "host:port" of the OTLP/HTTP collector, empty uses
+	// OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318.
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`    // plain HTTP to the collector
+	SampleRatio float64 `yaml:"sampleratio"` // share of traces recorded, 0 records all
+	ServiceName string  `yaml:"servicename"`
+}
+
+// LogCfg is type logging configuration.
+type LogCfg struct {
+	Format string `yaml:"format"` // "text" or "json"
+	Level  string `yaml:"level"`  // "debug", "info", "warn" or "error"
+}
+
+// MetricsCfg is type Prometheus metrics configuration.
+type MetricsCfg struct {
+	Enabled bool `yaml:"enabled"`
+	// Addr is a separate "host:port" listener for /healthz, /readyz and, when
+	// enabled, /metrics. When empty they are served by the panel server.
+	Addr string `yaml:"addr"`
 }
 
 // DatabaseCfg is type database configuration.
 type DatabaseCfg struct {
-	Type string `yaml:"type"` // "memory" or "sqlite"
-	Path string `yaml:"path"` // path to sqlite file
+	Type        string     `yaml:"type"`        // "memory" or "sqlite"
+	Path        string     `yaml:"path"`        // path to sqlite file
+	RestoreFrom string     `yaml:"restorefrom"` // path to backup file restored on startup
+	Backup      BackupCfg  `yaml:"backup"`
+	Audit       AuditCfg   `yaml:"audit"`
+	Changes     ChangesCfg `yaml:"changes"`
+}
+
+// ChangesCfg is type configuration of sharing chat changes between processes
+// using the same sqlite database.
+type ChangesCfg struct {
+	PollInterval time.Duration `yaml:"pollinterval"` // how soon changes of other processes are seen, 0 is a second
+	Retention    time.Duration `yaml:"retention"`    // 0 is an hour
+}
+
+// AuditCfg is type audit log configuration.
+type AuditCfg struct {
+	Retention time.Duration `yaml:"retention"` // entries older than this are removed, 0 keeps all
+}
+
+// BackupCfg is type scheduled sqlite backup configuration.
+type BackupCfg struct {
+	Enabled  bool          `yaml:"enabled"`
+	Dir      string        `yaml:"dir"`
+	Interval time.Duration `yaml:"interval"`
+	Keep     int           `yaml:"keep"` // number of copies to keep, 0 keeps all
 }
 
 // TelegramCfg is type telegram configuration.
@@ -35,6 +103,86 @@ type ServerCfg struct {
 	AdminLogin    string `yaml:"adminlogin"`
 	AdminPassword string `yaml:"adminpassword"`
 	JWTSecret     string `yaml:"jwtsecret"`
+	JWTKeyID      string `yaml:"jwtkeyid"` // kid of jwtsecret, change it together with the secret
+	// JWTPreviousKeys are old secrets still accepted for validation after rotation.
+	JWTPreviousKeys []JWTKeyCfg   `yaml:"jwtpreviouskeys"`
+	AccessTokenTTL  time.Duration `yaml:"accesstokenttl"`
+	RefreshTokenTTL time.Duration `yaml:"refreshtokenttl"`
+	// TrustedProxies are addresses or CIDRs allowed to set X-Forwarded-For.
+	// When empty the client IP is always the remote address of the connection.
+	TrustedProxies []string `yaml:"trustedproxies"`
+	// SecureCookies marks the refresh cookie Secure even on plain HTTP requests,
+	// set it when a reverse proxy terminates TLS.
+	SecureCookies bool             `yaml:"securecookies"`
+	RateLimit     RateLimitCfg     `yaml:"ratelimit"`
+	TelegramLogin TelegramLoginCfg `yaml:"telegramlogin"`
+	// ValidateResponses logs API responses that do not match the OpenAPI document.
+	ValidateResponses bool      `yaml:"validateresponses"`
+	Events            EventsCfg `yaml:"events"`
+}
+
+// EventsCfg is type configuration of the live update stream of the panel.
+type EventsCfg struct {
+	Buffer    int           `yaml:"buffer"`    // changes kept for clients resuming with Last-Event-ID
+	Heartbeat time.Duration `yaml:"heartbeat"` // keeps idle connections open through proxies
+}
+
+// TelegramLoginCfg is type configuration of panel login with the Telegram Login Widget.
+type TelegramLoginCfg struct {
+	Enabled     bool          `yaml:"enabled"`
+	BotUsername string        `yaml:"botusername"` // bot linked to the panel domain with /setdomain
+	MaxAge      time.Duration `yaml:"maxage"`      // how long widget login data stays valid
+	// Role is the role of Telegram users in the chats they are members of:
+	// "viewer" (default when empty) or "operator" to allow changes.
+	Role string `yaml:"role"`
+}
+
+// RateLimitCfg is type HTTP API rate limiting configuration.
+type RateLimitCfg struct {
+	Enabled           bool    `yaml:"enabled"`
+	RequestsPerSecond float64 `yaml:"requestspersecond"` // API requests per client IP
+	Burst             int     `yaml:"burst"`
+	LoginPerMinute    float64 `yaml:"loginperminute"` // login attempts per login and per client IP
+	LoginBurst        int     `yaml:"loginburst"`
+	// MaxFailures is the number of failed logins in a row after which the login
+	// and the client IP are locked out.
+	MaxFailures int           `yaml:"maxfailures"`
+	Lockout     time.Duration `yaml:"lockout"`
+}
+
+// JWTKeyCfg is type of a JWT signing key kept for validation during a grace period.
+type JWTKeyCfg struct {
+	ID     string    `yaml:"id"`
+	Secret string    `yaml:"secret"`
+	Until  time.Time `yaml:"until"` // tokens signed with the key are rejected after this time
+}
+
+// RunsBot reports whether the process polls Telegram and sends reminders.
+func (c *Config) RunsBot() bool {
+	return c.Mode != ModePanel
+}
+
+// RunsPanel reports whether the process serves the panel, the panel mode
+// serves it regardless of server.enabled.
+func (c *Config) RunsPanel() bool {
+	switch c.Mode {
+	case ModePanel:
+		return true
+	case ModeBot:
+		return false
+	default:
+		return c.Server.Enabled
+	}
+}
+
+// KeyID is the kid of jwtsecret, DefaultJWTKeyID when jwtkeyid is empty as in
+// configs written before key rotation.
+func (s *ServerCfg) KeyID() string {
+	if s.JWTKeyID == "" {
+		return DefaultJWTKeyID
+	}
+
+	return s.JWTKeyID
 }
 
 // New create empty Config.
@@ -42,18 +190,29 @@ func New() *Config {
 	return &Config{}
 }
 
+// NewFromFile reads the configuration, overrides it with the environment and
+// validates the result. Unknown keys are rejected, a typo must not silently keep a default.
 func NewFromFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file %q: %w", path, err)
 	}
 
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
 	cfg := Config{}
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
 		return nil, fmt.Errorf("decode data from file %q: %w", path, err)
 	}
 
-	cfg.loadFromEnv()
+	if err := cfg.loadFromEnv(); err != nil {
+		return nil, fmt.Errorf("apply environment: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("validate %q: %w", path, err)
+	}
 
 	return &cfg, nil
 }
@@ -109,22 +268,3 @@ func (c *Config) WithTelegramBotKey(key string) *Config {
 
 	return c
 }
-
-// loadFromEnv overrides config values with environment variables.
-func (c *Config) loadFromEnv() {
-	if v := os.Getenv("TELEGRAM_BOT_KEY"); v != "" {
-		c.Telegram.BotKey = v
-	}
-
-	if v := os.Getenv("ADMIN_LOGIN"); v != "" {
-		c.Server.AdminLogin = v
-	}
-
-	if v := os.Getenv("ADMIN_PASSWORD"); v != "" {
-		c.Server.AdminPassword = v
-	}
-
-	if v := os.Getenv("JWT_SECRET"); v != "" {
-		c.Server.JWTSecret = v
-	}
-}
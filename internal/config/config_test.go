@@ -0,0 +1,412 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() *Config {
+	return &Config{
+		Telegram: TelegramCfg{BotKey: "123:token"},
+		Server: ServerCfg{
+			Enabled:         true,
+			Port:            "8080",
+			AdminLogin:      "admin",
+			AdminPassword:   "password",
+			JWTSecret:       "0123456789abcdef0123456789abcdef",
+			JWTKeyID:        "v2",
+			JWTPreviousKeys: []JWTKeyCfg{{ID: "v1", Secret: "old", Until: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}},
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 720 * time.Hour,
+			TrustedProxies:  []string{"127.0.0.1", "10.0.0.0/8", "::1"},
+			RateLimit: RateLimitCfg{
+				Enabled: true, RequestsPerSecond: 10, Burst: 20, LoginPerMinute: 5, LoginBurst: 5,
+				MaxFailures: 5, Lockout: 15 * time.Minute,
+			},
+			TelegramLogin: TelegramLoginCfg{Enabled: true, BotUsername: "trash_bot", MaxAge: 24 * time.Hour},
+			Events:        EventsCfg{Buffer: 256, Heartbeat: 15 * time.Second},
+		},
+		Database: DatabaseCfg{
+			Type:    "sqlite",
+			Path:    "data/trash.db",
+			Backup:  BackupCfg{Enabled: true, Dir: "data/backups", Interval: 24 * time.Hour, Keep: 7},
+			Audit:   AuditCfg{Retention: 90 * 24 * time.Hour},
+			Changes: ChangesCfg{PollInterval: time.Second, Retention: time.Hour},
+		},
+		Metrics: MetricsCfg{Enabled: true, Addr: "127.0.0.1:9090"},
+		Log:     LogCfg{Format: "json", Level: "debug"},
+		Tracing: TracingCfg{Exporter: "otlp", SampleRatio: 0.5},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, validConfig().Validate())
+
+	tests := map[string]struct {
+		modify  func(cfg *Config)
+		problem string
+	}{
+		"Unknown mode": {
+			modify:  func(cfg *Config) { cfg.Mode = "worker" },
+			problem: `mode must be "all", "bot", "panel" or empty, got "worker"`,
+		},
+		"Bot mode with memory": {
+			modify: func(cfg *Config) {
+				cfg.Mode = ModeBot
+				cfg.Database.Type = "memory"
+				cfg.Database.Backup.Enabled = false
+			},
+			problem: `database.type must be sqlite when mode is "bot"`,
+		},
+		"Empty bot key": {
+			modify:  func(cfg *Config) { cfg.Telegram.BotKey = "" },
+			problem: "telegram.botkey is required (TELEGRAM_BOT_KEY)",
+		},
+		"Port is not a number": {
+			modify:  func(cfg *Config) { cfg.Server.Port = "http" },
+			problem: `server.port must be a number from 1 to 65535, got "http"`,
+		},
+		"Port out of range": {
+			modify:  func(cfg *Config) { cfg.Server.Port = "80800" },
+			problem: `server.port must be a number from 1 to 65535, got "80800"`,
+		},
+		"Admin login without password": {
+			modify:  func(cfg *Config) { cfg.Server.AdminPassword = "" },
+			problem: "server.adminlogin and server.adminpassword must be set together (ADMIN_LOGIN, ADMIN_PASSWORD)",
+		},
+		"Empty JWT secret": {
+			modify:  func(cfg *Config) { cfg.Server.JWTSecret = "" },
+			problem: "server.jwtsecret is required when the panel is enabled (JWT_SECRET)",
+		},
+		"Short JWT secret": {
+			modify:  func(cfg *Config) { cfg.Server.JWTSecret = "secret" },
+			problem: "server.jwtsecret must be at least 32 bytes long",
+		},
+		"Previous key with the default id": {
+			modify:  func(cfg *Config) { cfg.Server.JWTKeyID = "" },
+			problem: "server.jwtpreviouskeys[0].id must differ from server.jwtkeyid",
+		},
+		"Previous key without id": {
+			modify:  func(cfg *Config) { cfg.Server.JWTPreviousKeys[0].ID = "" },
+			problem: "server.jwtpreviouskeys[0].id is required",
+		},
+		"Previous key with the current id": {
+			modify:  func(cfg *Config) { cfg.Server.JWTPreviousKeys[0].ID = "v2" },
+			problem: "server.jwtpreviouskeys[0].id must differ from server.jwtkeyid",
+		},
+		"Previous key without secret": {
+			modify:  func(cfg *Config) { cfg.Server.JWTPreviousKeys[0].Secret = "" },
+			problem: "server.jwtpreviouskeys[0].secret is required",
+		},
+		"Previous key without until": {
+			modify:  func(cfg *Config) { cfg.Server.JWTPreviousKeys[0].Until = time.Time{} },
+			problem: "server.jwtpreviouskeys[0].until is required",
+		},
+		"Negative access token TTL": {
+			modify:  func(cfg *Config) { cfg.Server.AccessTokenTTL = -time.Minute },
+			problem: "server.accesstokenttl must not be negative",
+		},
+		"Negative refresh token TTL": {
+			modify:  func(cfg *Config) { cfg.Server.RefreshTokenTTL = -time.Minute },
+			problem: "server.refreshtokenttl must not be negative",
+		},
+		"Refresh token shorter than access token": {
+			modify:  func(cfg *Config) { cfg.Server.RefreshTokenTTL = time.Minute },
+			problem: "server.refreshtokenttl must not be shorter than server.accesstokenttl",
+		},
+		"Invalid trusted proxy": {
+			modify:  func(cfg *Config) { cfg.Server.TrustedProxies = append(cfg.Server.TrustedProxies, "proxy.local") },
+			problem: `server.trustedproxies[3] must be an IP address or a CIDR, got "proxy.local"`,
+		},
+		"Zero rate limit": {
+			modify:  func(cfg *Config) { cfg.Server.RateLimit.RequestsPerSecond = 0 },
+			problem: "server.ratelimit.requestspersecond must be positive",
+		},
+		"Zero burst": {
+			modify:  func(cfg *Config) { cfg.Server.RateLimit.Burst = 0 },
+			problem: "server.ratelimit.burst must be positive",
+		},
+		"Zero login rate limit": {
+			modify:  func(cfg *Config) { cfg.Server.RateLimit.LoginPerMinute = 0 },
+			problem: "server.ratelimit.loginperminute must be positive",
+		},
+		"Zero login burst": {
+			modify:  func(cfg *Config) { cfg.Server.RateLimit.LoginBurst = 0 },
+			problem: "server.ratelimit.loginburst must be positive",
+		},
+		"Negative max failures": {
+			modify:  func(cfg *Config) { cfg.Server.RateLimit.MaxFailures = -1 },
+			problem: "server.ratelimit.maxfailures must not be negative",
+		},
+		"Negative lockout": {
+			modify:  func(cfg *Config) { cfg.Server.RateLimit.Lockout = -time.Minute },
+			problem: "server.ratelimit.lockout must not be negative",
+		},
+		"Telegram login without bot": {
+			modify:  func(cfg *Config) { cfg.Server.TelegramLogin.BotUsername = "" },
+			problem: "server.telegramlogin.botusername is required when Telegram login is enabled",
+		},
+		"Telegram login bot with @": {
+			modify:  func(cfg *Config) { cfg.Server.TelegramLogin.BotUsername = "@trash_bot" },
+			problem: "server.telegramlogin.botusername must not start with @",
+		},
+		"Zero Telegram login max age": {
+			modify:  func(cfg *Config) { cfg.Server.TelegramLogin.MaxAge = 0 },
+			problem: "server.telegramlogin.maxage must be positive",
+		},
+		"Telegram login as owner": {
+			modify:  func(cfg *Config) { cfg.Server.TelegramLogin.Role = "owner" },
+			problem: `server.telegramlogin.role must be "viewer" or "operator", got "owner"`,
+		},
+		"Negative events buffer": {
+			modify:  func(cfg *Config) { cfg.Server.Events.Buffer = -1 },
+			problem: "server.events.buffer must not be negative",
+		},
+		"Negative events heartbeat": {
+			modify:  func(cfg *Config) { cfg.Server.Events.Heartbeat = -time.Second },
+			problem: "server.events.heartbeat must not be negative",
+		},
+		"Unknown database type": {
+			modify:  func(cfg *Config) { cfg.Database.Type = "postgres" },
+			problem: `database.type must be "sqlite", "memory" or empty, got "postgres"`,
+		},
+		"SQLite without path": {
+			modify:  func(cfg *Config) { cfg.Database.Path = "" },
+			problem: "database.path is required for sqlite",
+		},
+		"Backups without dir": {
+			modify:  func(cfg *Config) { cfg.Database.Backup.Dir = "" },
+			problem: "database.backup.dir is required when backups are enabled",
+		},
+		"Zero backup interval": {
+			modify:  func(cfg *Config) { cfg.Database.Backup.Interval = 0 },
+			problem: "database.backup.interval must be positive",
+		},
+		"Negative backup keep": {
+			modify:  func(cfg *Config) { cfg.Database.Backup.Keep = -1 },
+			problem: "database.backup.keep must not be negative",
+		},
+		"Backups of memory": {
+			modify:  func(cfg *Config) { cfg.Database.Type = "memory" },
+			problem: "database.backup.enabled is supported only by sqlite",
+		},
+		"Restore into memory": {
+			modify: func(cfg *Config) {
+				cfg.Database.Type = ""
+				cfg.Database.Backup.Enabled = false
+				cfg.Database.RestoreFrom = "backup.db"
+			},
+			problem: "database.restorefrom is supported only by sqlite",
+		},
+		"Negative audit retention": {
+			modify:  func(cfg *Config) { cfg.Database.Audit.Retention = -time.Hour },
+			problem: "database.audit.retention must not be negative",
+		},
+		"Negative changes poll interval": {
+			modify:  func(cfg *Config) { cfg.Database.Changes.PollInterval = -time.Second },
+			problem: "database.changes.pollinterval must not be negative",
+		},
+		"Negative changes retention": {
+			modify:  func(cfg *Config) { cfg.Database.Changes.Retention = -time.Hour },
+			problem: "database.changes.retention must not be negative",
+		},
+		"Metrics address without port": {
+			modify:  func(cfg *Config) { cfg.Metrics.Addr = "127.0.0.1" },
+			problem: `metrics.addr must be "host:port", got "127.0.0.1"`,
+		},
+		"Metrics without panel and address": {
+			modify: func(cfg *Config) {
+				cfg.Server.Enabled = false
+				cfg.Metrics.Addr = ""
+			},
+			problem: "metrics.addr is required when the panel is disabled",
+		},
+		"Unknown log format": {
+			modify:  func(cfg *Config) { cfg.Log.Format = "xml" },
+			problem: `log.format must be "text" or "json", got "xml"`,
+		},
+		"Unknown log level": {
+			modify:  func(cfg *Config) { cfg.Log.Level = "verbose" },
+			problem: `log.level must be "debug", "info", "warn" or "error", got "verbose"`,
+		},
+		"Unknown trace exporter": {
+			modify:  func(cfg *Config) { cfg.Tracing.Exporter = "jaeger" },
+			problem: `tracing.exporter must be "otlp", "stdout" or "none", got "jaeger"`,
+		},
+		"Sample ratio above one": {
+			modify:  func(cfg *Config) { cfg.Tracing.SampleRatio = 2 },
+			problem: "tracing.sampleratio must be from 0 to 1",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, cfg.Validate(), &validationErr)
+			require.Equal(t, []string{tt.problem}, validationErr.Problems)
+		})
+	}
+}
+
+func TestValidate_DisabledPanel(t *testing.T) {
+	t.Parallel()
+
+	// Настройки панели не нужны, пока она выключена
+	cfg := &Config{
+		Telegram: TelegramCfg{BotKey: "123:token"},
+		Database: DatabaseCfg{Type: "memory"},
+	}
+
+	require.NoError(t, cfg.Validate())
+}
+
+func TestValidate_Modes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Bot mode ignores the panel", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := validConfig()
+		cfg.Mode = ModeBot
+		cfg.Server.JWTSecret = ""
+
+		require.NoError(t, cfg.Validate())
+		require.True(t, cfg.RunsBot())
+		require.False(t, cfg.RunsPanel())
+	})
+
+	t.Run("Panel mode runs the panel without the bot key", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := validConfig()
+		cfg.Mode = ModePanel
+		cfg.Server.Enabled = false
+		cfg.Server.TelegramLogin.Enabled = false
+		cfg.Telegram.BotKey = ""
+
+		require.NoError(t, cfg.Validate())
+		require.False(t, cfg.RunsBot())
+		require.True(t, cfg.RunsPanel())
+
+		cfg.Server.JWTSecret = ""
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, cfg.Validate(), &validationErr)
+		require.Equal(t, []string{"server.jwtsecret is required when the panel is enabled (JWT_SECRET)"},
+			validationErr.Problems)
+	})
+
+	t.Run("Telegram login needs the bot key in panel mode", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := validConfig()
+		cfg.Mode = ModePanel
+		cfg.Telegram.BotKey = ""
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, cfg.Validate(), &validationErr)
+		require.Equal(t, []string{"telegram.botkey is required (TELEGRAM_BOT_KEY)"}, validationErr.Problems)
+	})
+
+	t.Run("No restore on startup with a shared database", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := validConfig()
+		cfg.Mode = ModeBot
+		cfg.Database.Type = "sqlite"
+		cfg.Database.Path = "data/trash.db"
+		cfg.Database.RestoreFrom = "backup.db"
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, cfg.Validate(), &validationErr)
+		require.Equal(t, []string{`database.restorefrom is not supported when mode is "bot", ` +
+			"stop both processes and run trash-bot restore"}, validationErr.Problems)
+	})
+}
+
+func TestValidate_AllProblems(t *testing.T) {
+	t.Parallel()
+
+	cfg := validConfig()
+	cfg.Telegram.BotKey = ""
+	cfg.Server.JWTSecret = ""
+	cfg.Database.Type = "postgres"
+
+	err := cfg.Validate()
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Problems, 3)
+	require.EqualError(t, err, "invalid config: "+
+		"telegram.botkey is required (TELEGRAM_BOT_KEY); "+
+		"server.jwtsecret is required when the panel is enabled (JWT_SECRET); "+
+		`database.type must be "sqlite", "memory" or empty, got "postgres"`)
+}
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	return path
+}
+
+func TestNewFromFile(t *testing.T) {
+	t.Setenv("TELEGRAM_BOT_KEY", "123:token")
+
+	t.Run("Base config", func(t *testing.T) {
+		t.Setenv("JWT_SECRET", "0123456789abcdef0123456789abcdef")
+
+		cfg, err := NewFromFile("../../config/base.yaml")
+		require.NoError(t, err)
+		require.Equal(t, "123:token", cfg.Telegram.BotKey)
+		require.Equal(t, "sqlite", cfg.Database.Type)
+	})
+
+	// Конфиги, написанные до проверки, должны запускаться: 0 и пустые значения означают значения по умолчанию
+	t.Run("Baseline config", func(t *testing.T) {
+		t.Setenv("JWT_SECRET", "0123456789abcdef0123456789abcdef")
+		t.Setenv("ADMIN_LOGIN", "admin")
+		t.Setenv("ADMIN_PASSWORD", "admin")
+
+		cfg, err := NewFromFile("testdata/baseline.yaml")
+		require.NoError(t, err)
+		require.Equal(t, DefaultJWTKeyID, cfg.Server.KeyID())
+		require.Zero(t, cfg.Server.AccessTokenTTL)
+	})
+
+	t.Run("Unknown key", func(t *testing.T) {
+		path := writeConfig(t, "database:\n  type: memory\n  pth: data/trash.db\n")
+
+		_, err := NewFromFile(path)
+		require.ErrorContains(t, err, "field pth not found")
+	})
+
+	t.Run("Empty file", func(t *testing.T) {
+		cfg, err := NewFromFile(writeConfig(t, ""))
+		require.NoError(t, err)
+		require.Empty(t, cfg.Database.Type)
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		path := writeConfig(t, "server:\n  enabled: true\n  port: \"80800\"\n")
+
+		_, err := NewFromFile(path)
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Contains(t, validationErr.Problems, `server.port must be a number from 1 to 65535, got "80800"`)
+	})
+}
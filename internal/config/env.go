@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the names of the variables overriding config fields: the
+// YAML path in upper case joined by "_", e.g. TRASHBOT_SERVER_PORT.
+const EnvPrefix = "TRASHBOT"
+
+// fileSuffix marks a variable holding the path of a file with the value, as
+// Docker and Kubernetes secrets are mounted, e.g. TRASHBOT_SERVER_JWTSECRET_FILE.
+const fileSuffix = "_FILE"
+
+var errEnvAndFile = errors.New("both the variable and its _FILE variant are set")
+
+// envAliases are the older names of variables, kept for existing .env files.
+// The TRASHBOT_ variables take precedence over them.
+var envAliases = map[string]string{
+	"TRASHBOT_TELEGRAM_BOTKEY":      "TELEGRAM_BOT_KEY",
+	"TRASHBOT_SERVER_ADMINLOGIN":    "ADMIN_LOGIN",
+	"TRASHBOT_SERVER_ADMINPASSWORD": "ADMIN_PASSWORD",
+	"TRASHBOT_SERVER_JWTSECRET":     "JWT_SECRET",
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// loadFromEnv overrides config fields with environment variables. Strings
+// are taken as is, lists of strings are comma separated, other values are
+// parsed as YAML, e.g. "15m", "true" or "[{id: v0, secret: old}]".
+func (c *Config) loadFromEnv() error {
+	var errs []error
+
+	walkFields("", func(path string, fields ...reflect.Value) {
+		name := envName(path)
+
+		value, ok, err := lookupEnv(name)
+		if !ok && err == nil {
+			if alias, found := envAliases[name]; found {
+				value, ok, err = lookupEnv(alias)
+			}
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+
+			return
+		}
+
+		if ok {
+			if err := setField(fields[0], value); err != nil {
+				errs = append(errs, fmt.Errorf("parse %s: %w", name, err))
+			}
+		}
+	}, reflect.ValueOf(c).Elem())
+
+	return errors.Join(errs...)
+}
+
+// walkFields calls fn with the YAML path of every field that is not a nested
+// section, e.g. "server.ratelimit.burst", and the field of each of the
+// sections, which must be of the same type.
+func walkFields(path string, fn func(path string, fields ...reflect.Value), sections ...reflect.Value) {
+	sectionType := sections[0].Type()
+
+	for i := range sectionType.NumField() {
+		tag, _, _ := strings.Cut(sectionType.Field(i).Tag.Get("yaml"), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		fieldPath := tag
+		if path != "" {
+			fieldPath = path + "." + tag
+		}
+
+		fields := make([]reflect.Value, len(sections))
+		for j, section := range sections {
+			fields[j] = section.Field(i)
+		}
+
+		if fields[0].Kind() == reflect.Struct && fields[0].Type() != timeType {
+			walkFields(fieldPath, fn, fields...)
+
+			continue
+		}
+
+		fn(fieldPath, fields...)
+	}
+}
+
+// envName is the variable overriding the field at the YAML path.
+func envName(path string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// lookupEnv returns the value of the variable or the content of the file
+// named by its _FILE variant, without the trailing newline. Empty variables
+// are skipped like unset ones, as empty lines of .env files set them.
+func lookupEnv(name string) (string, bool, error) {
+	value := os.Getenv(name)
+
+	path := os.Getenv(name + fileSuffix)
+	if path == "" {
+		return value, value != "", nil
+	}
+
+	if value != "" {
+		return "", false, fmt.Errorf("%s: %w", name, errEnvAndFile)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("read %s: %w", name+fileSuffix, err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+func setField(field reflect.Value, value string) error {
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		items := []string{}
+
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+
+		field.Set(reflect.ValueOf(items).Convert(field.Type()))
+	default:
+		target := reflect.New(field.Type())
+		if err := yaml.Unmarshal([]byte(value), target.Interface()); err != nil {
+			return fmt.Errorf("decode %q: %w", value, err)
+		}
+
+		field.Set(target.Elem())
+	}
+
+	return nil
+}
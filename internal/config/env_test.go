@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:paralleltest // sets environment variables
+func TestLoadFromEnv(t *testing.T) {
+	secretPath := filepath.Join(t.TempDir(), "jwt_secret")
+	require.NoError(t, os.WriteFile(secretPath, []byte("from-file\n"), 0o600))
+
+	t.Setenv("TRASHBOT_SERVER_ENABLED", "false")
+	t.Setenv("TRASHBOT_SERVER_PORT", "9000")
+	t.Setenv("TRASHBOT_SERVER_ACCESSTOKENTTL", "5m")
+	t.Setenv("TRASHBOT_SERVER_TRUSTEDPROXIES", "127.0.0.1, 10.0.0.0/8")
+	t.Setenv("TRASHBOT_SERVER_RATELIMIT_BURST", "30")
+	t.Setenv("TRASHBOT_SERVER_RATELIMIT_REQUESTSPERSECOND", "2.5")
+	t.Setenv("TRASHBOT_SERVER_JWTPREVIOUSKEYS", "[{id: v0, secret: old, until: 2026-01-01T00:00:00Z}]")
+	t.Setenv("TRASHBOT_SERVER_JWTSECRET_FILE", secretPath)
+	t.Setenv("TRASHBOT_DATABASE_PATH", "/data/trash.db")
+	t.Setenv("TRASHBOT_TELEGRAM_BOTKEY", "new-key")
+	t.Setenv("TELEGRAM_BOT_KEY", "old-key")
+	t.Setenv("ADMIN_LOGIN", "admin")
+	t.Setenv("TRASHBOT_LOG_LEVEL", "")
+
+	cfg := &Config{Log: LogCfg{Level: "info"}}
+	require.NoError(t, cfg.loadFromEnv())
+
+	require.False(t, cfg.Server.Enabled)
+	require.Equal(t, "9000", cfg.Server.Port)
+	require.Equal(t, 5*time.Minute, cfg.Server.AccessTokenTTL)
+	require.Equal(t, []string{"127.0.0.1", "10.0.0.0/8"}, cfg.Server.TrustedProxies)
+	require.Equal(t, 30, cfg.Server.RateLimit.Burst)
+	require.InDelta(t, 2.5, cfg.Server.RateLimit.RequestsPerSecond, 0)
+	require.Equal(t, []JWTKeyCfg{
+		{ID: "v0", Secret: "old", Until: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}, cfg.Server.JWTPreviousKeys)
+	require.Equal(t, "from-file", cfg.Server.JWTSecret, "the trailing newline of the file is dropped")
+	require.Equal(t, "/data/trash.db", cfg.Database.Path)
+	require.Equal(t, "new-key", cfg.Telegram.BotKey, "new names win over aliases")
+	require.Equal(t, "admin", cfg.Server.AdminLogin, "aliases still work")
+	require.Equal(t, "info", cfg.Log.Level, "empty variables are skipped")
+}
+
+//nolint:paralleltest // sets environment variables
+func TestLoadFromEnv_Errors(t *testing.T) {
+	t.Setenv("TRASHBOT_SERVER_ENABLED", "maybe")
+	t.Setenv("TRASHBOT_SERVER_ACCESSTOKENTTL", "soon")
+	t.Setenv("TRASHBOT_SERVER_JWTSECRET", "secret")
+	t.Setenv("TRASHBOT_SERVER_JWTSECRET_FILE", "/run/secrets/jwt")
+	t.Setenv("ADMIN_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	err := (&Config{}).loadFromEnv()
+	require.ErrorIs(t, err, errEnvAndFile)
+	require.ErrorContains(t, err, "parse TRASHBOT_SERVER_ENABLED")
+	require.ErrorContains(t, err, "parse TRASHBOT_SERVER_ACCESSTOKENTTL")
+	require.ErrorContains(t, err, "read ADMIN_PASSWORD_FILE")
+}
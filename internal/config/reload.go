@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// reloadable are the YAML paths of fields applied without a restart, a path
+// covers the fields under it. Other fields are read once on startup.
+var reloadable = []string{
+	"server.jwtsecret",
+	"server.jwtkeyid",
+	"server.jwtpreviouskeys",
+	"server.accesstokenttl",
+	"server.refreshtokenttl",
+	"server.telegramlogin.role",
+	"server.ratelimit.requestspersecond",
+	"server.ratelimit.burst",
+	"server.ratelimit.loginperminute",
+	"server.ratelimit.loginburst",
+	"server.ratelimit.maxfailures",
+	"server.ratelimit.lockout",
+	"database.backup.dir",
+	"database.backup.interval",
+	"database.backup.keep",
+	"log",
+}
+
+// Changes are the YAML paths of the fields changed by a reload.
+type Changes struct {
+	Applied []string
+	// Restart are fields whose new values wait for a restart.
+	Restart []string
+}
+
+// Reloadable reports whether the field at the YAML path is applied without a restart.
+func Reloadable(path string) bool {
+	return slices.ContainsFunc(reloadable, func(prefix string) bool {
+		return path == prefix || strings.HasPrefix(path, prefix+".")
+	})
+}
+
+// reloadable reports whether the field is applied without a restart by the
+// running process. Fields of a part that was disabled on startup wait for a
+// restart, nothing would apply them.
+func (c *Config) reloadable(path string) bool {
+	switch {
+	case !Reloadable(path):
+		return false
+	case strings.HasPrefix(path, "server."):
+		return c.RunsPanel() && (!strings.HasPrefix(path, "server.ratelimit.") || c.Server.RateLimit.Enabled)
+	case strings.HasPrefix(path, "database.backup."):
+		return c.RunsBot() && c.Database.Backup.Enabled
+	default:
+		return true
+	}
+}
+
+// Reload returns the configuration with the reloadable fields of next and the
+// other fields of c, which is not modified.
+func (c *Config) Reload(next *Config) (*Config, Changes) {
+	effective := *c
+
+	var changes Changes
+
+	walkFields("", func(path string, fields ...reflect.Value) {
+		current, updated := fields[0], fields[1]
+		if reflect.DeepEqual(current.Interface(), updated.Interface()) {
+			return
+		}
+
+		if !c.reloadable(path) {
+			changes.Restart = append(changes.Restart, path)
+
+			return
+		}
+
+		current.Set(updated)
+		changes.Applied = append(changes.Applied, path)
+	}, reflect.ValueOf(&effective).Elem(), reflect.ValueOf(next).Elem())
+
+	return &effective, changes
+}
+
+// Redacted returns a copy of the configuration with the secrets replaced,
+// unset secrets stay empty.
+func (c *Config) Redacted() *Config {
+	cfg := *c
+	cfg.Telegram.BotKey = redact(c.Telegram.BotKey)
+	cfg.Server.AdminPassword = redact(c.Server.AdminPassword)
+	cfg.Server.JWTSecret = redact(c.Server.JWTSecret)
+
+	cfg.Server.JWTPreviousKeys = slices.Clone(c.Server.JWTPreviousKeys)
+	for i := range cfg.Server.JWTPreviousKeys {
+		cfg.Server.JWTPreviousKeys[i].Secret = redact(cfg.Server.JWTPreviousKeys[i].Secret)
+	}
+
+	return &cfg
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+// Document is the configuration keyed by the YAML names, with durations as
+// strings like "15m0s", as it is shown by the API.
+func (c *Config) Document() (map[string]any, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+
+	return doc, nil
+}
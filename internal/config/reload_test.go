@@ -0,0 +1,123 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Reload(t *testing.T) {
+	t.Parallel()
+
+	current := validConfig()
+
+	next := validConfig()
+	next.Server.Port = "9000"
+	next.Server.JWTSecret = "fedcba9876543210fedcba9876543210"
+	next.Server.RateLimit.Burst = 50
+	next.Server.RateLimit.Enabled = false
+	next.Log.Level = "warn"
+	next.Database.Backup.Keep = 3
+
+	effective, changes := current.Reload(next)
+
+	require.Equal(t, []string{
+		"server.jwtsecret",
+		"server.ratelimit.burst",
+		"database.backup.keep",
+		"log.level",
+	}, changes.Applied)
+	require.Equal(t, []string{"server.port", "server.ratelimit.enabled"}, changes.Restart)
+
+	require.Equal(t, "8080", effective.Server.Port, "the running port is kept")
+	require.True(t, effective.Server.RateLimit.Enabled)
+	require.Equal(t, next.Server.JWTSecret, effective.Server.JWTSecret)
+	require.Equal(t, 50, effective.Server.RateLimit.Burst)
+	require.Equal(t, "warn", effective.Log.Level)
+	require.Equal(t, 3, effective.Database.Backup.Keep)
+
+	require.Equal(t, "debug", current.Log.Level, "the current config is not modified")
+
+	_, changes = effective.Reload(effective)
+	require.Empty(t, changes.Applied)
+	require.Empty(t, changes.Restart)
+}
+
+func TestConfig_ReloadDisabled(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		change func(cfg *Config)
+		field  string
+	}{
+		"Rate limits disabled on startup": {
+			change: func(cfg *Config) { cfg.Server.RateLimit.Enabled = false },
+			field:  "server.ratelimit.burst",
+		},
+		"Backups disabled on startup": {
+			change: func(cfg *Config) { cfg.Database.Backup.Enabled = false },
+			field:  "database.backup.keep",
+		},
+		"Panel in another process": {
+			change: func(cfg *Config) { cfg.Mode = ModeBot },
+			field:  "server.jwtsecret",
+		},
+		"Backups in another process": {
+			change: func(cfg *Config) { cfg.Mode = ModePanel },
+			field:  "database.backup.keep",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			current := validConfig()
+			tt.change(current)
+
+			next := *current
+			next.Server.RateLimit.Burst = 50
+			next.Server.JWTSecret = "fedcba9876543210fedcba9876543210"
+			next.Database.Backup.Keep = 3
+
+			_, changes := current.Reload(&next)
+			require.Contains(t, changes.Restart, tt.field)
+			require.NotContains(t, changes.Applied, tt.field)
+		})
+	}
+}
+
+func TestReloadable(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, Reloadable("log.level"))
+	require.True(t, Reloadable("server.jwtpreviouskeys"))
+	require.False(t, Reloadable("server.port"))
+	require.False(t, Reloadable("server.adminpassword"), "the owner is only created on startup")
+	require.False(t, Reloadable("logs"), "prefixes match whole names")
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	t.Parallel()
+
+	cfg := validConfig()
+	cfg.Server.AdminPassword = ""
+
+	redactedCfg := cfg.Redacted()
+
+	require.Equal(t, "[REDACTED]", redactedCfg.Telegram.BotKey)
+	require.Equal(t, "[REDACTED]", redactedCfg.Server.JWTSecret)
+	require.Equal(t, "[REDACTED]", redactedCfg.Server.JWTPreviousKeys[0].Secret)
+	require.Empty(t, redactedCfg.Server.AdminPassword, "unset secrets stay empty")
+	require.Equal(t, "old", cfg.Server.JWTPreviousKeys[0].Secret, "the config is not modified")
+
+	doc, err := redactedCfg.Document()
+	require.NoError(t, err)
+
+	server, ok := doc["server"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, "8080", server["port"])
+	require.Equal(t, (15 * time.Minute).String(), server["accesstokenttl"])
+	require.Equal(t, "[REDACTED]", server["jwtsecret"])
+}
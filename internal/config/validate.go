@@ -0,0 +1,199 @@
+package config
+
+import (
+	"log/slog"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// minJWTSecretLen is 256 bits, the size of the HS256 hash.
+const minJWTSecretLen = 32
+
+// ValidationError lists every problem of the configuration, so that all of
+// them can be fixed at once.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// validator collects problems of the fields named by their YAML paths.
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(field, problem string) {
+	v.problems = append(v.problems, field+" "+problem)
+}
+
+func (v *validator) check(ok bool, field, problem string) {
+	if !ok {
+		v.add(field, problem)
+	}
+}
+
+// Validate checks the configuration after the environment is applied and
+// returns a *ValidationError with all problems found.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	switch c.Mode {
+	case "", ModeAll:
+	case ModeBot, ModePanel:
+		// Процессы бота и панели видят изменения друг друга только через общую базу
+		v.check(c.Database.Type == "sqlite", "database.type", "must be sqlite when mode is "+strconv.Quote(c.Mode))
+		// Второй процесс держит базу открытой, её нельзя подменить при старте
+		v.check(c.Database.RestoreFrom == "", "database.restorefrom",
+			"is not supported when mode is "+strconv.Quote(c.Mode)+", stop both processes and run trash-bot restore")
+	default:
+		v.add("mode", `must be "all", "bot", "panel" or empty, got `+strconv.Quote(c.Mode))
+	}
+
+	// Панели бот нужен только для проверки входа через Telegram
+	if c.RunsBot() || c.RunsPanel() && c.Server.TelegramLogin.Enabled {
+		v.check(c.Telegram.BotKey != "", "telegram.botkey", "is required (TELEGRAM_BOT_KEY)")
+	}
+
+	if c.RunsPanel() {
+		c.Server.validate(v)
+	}
+
+	c.Database.validate(v)
+	c.Metrics.validate(v, c.RunsPanel())
+	c.Log.validate(v)
+	c.Tracing.validate(v)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+
+	return nil
+}
+
+func (s *ServerCfg) validate(v *validator) {
+	v.check(validPort(s.Port), "server.port", "must be a number from 1 to 65535, got "+strconv.Quote(s.Port))
+	v.check((s.AdminLogin == "") == (s.AdminPassword == ""), "server.adminlogin",
+		"and server.adminpassword must be set together (ADMIN_LOGIN, ADMIN_PASSWORD)")
+	v.check(s.JWTSecret != "", "server.jwtsecret", "is required when the panel is enabled (JWT_SECRET)")
+	v.check(s.JWTSecret == "" || len(s.JWTSecret) >= minJWTSecretLen, "server.jwtsecret",
+		"must be at least "+strconv.Itoa(minJWTSecretLen)+" bytes long")
+
+	for i, key := range s.JWTPreviousKeys {
+		field := "server.jwtpreviouskeys[" + strconv.Itoa(i) + "]"
+		v.check(key.ID != "", field+".id", "is required")
+		v.check(key.ID == "" || key.ID != s.KeyID(), field+".id", "must differ from server.jwtkeyid")
+		v.check(key.Secret != "", field+".secret", "is required")
+		v.check(!key.Until.IsZero(), field+".until", "is required")
+	}
+
+	// 0 означает TTL по умолчанию
+	v.check(s.AccessTokenTTL >= 0, "server.accesstokenttl", "must not be negative")
+	v.check(s.RefreshTokenTTL >= 0, "server.refreshtokenttl", "must not be negative")
+	v.check(s.AccessTokenTTL <= 0 || s.RefreshTokenTTL <= 0 || s.RefreshTokenTTL >= s.AccessTokenTTL,
+		"server.refreshtokenttl", "must not be shorter than server.accesstokenttl")
+
+	for i, proxy := range s.TrustedProxies {
+		v.check(validProxy(proxy), "server.trustedproxies["+strconv.Itoa(i)+"]",
+			"must be an IP address or a CIDR, got "+strconv.Quote(proxy))
+	}
+
+	if limits := s.RateLimit; limits.Enabled {
+		v.check(limits.RequestsPerSecond > 0, "server.ratelimit.requestspersecond", "must be positive")
+		v.check(limits.Burst > 0, "server.ratelimit.burst", "must be positive")
+		v.check(limits.LoginPerMinute > 0, "server.ratelimit.loginperminute", "must be positive")
+		v.check(limits.LoginBurst > 0, "server.ratelimit.loginburst", "must be positive")
+		v.check(limits.MaxFailures >= 0, "server.ratelimit.maxfailures", "must not be negative")
+		v.check(limits.Lockout >= 0, "server.ratelimit.lockout", "must not be negative")
+	}
+
+	if login := s.TelegramLogin; login.Enabled {
+		v.check(login.BotUsername != "", "server.telegramlogin.botusername", "is required when Telegram login is enabled")
+		v.check(!strings.HasPrefix(login.BotUsername, "@"), "server.telegramlogin.botusername", "must not start with @")
+		v.check(login.MaxAge > 0, "server.telegramlogin.maxage", "must be positive")
+
+		switch login.Role {
+		case "", "viewer", "operator":
+		default:
+			v.add("server.telegramlogin.role", `must be "viewer" or "operator", got `+strconv.Quote(login.Role))
+		}
+	}
+
+	v.check(s.Events.Buffer >= 0, "server.events.buffer", "must not be negative")
+	v.check(s.Events.Heartbeat >= 0, "server.events.heartbeat", "must not be negative")
+}
+
+func (d *DatabaseCfg) validate(v *validator) {
+	switch d.Type {
+	case "", "memory":
+		v.check(d.RestoreFrom == "", "database.restorefrom", "is supported only by sqlite")
+		v.check(!d.Backup.Enabled, "database.backup.enabled", "is supported only by sqlite")
+	case "sqlite":
+		v.check(d.Path != "", "database.path", "is required for sqlite")
+
+		if d.Backup.Enabled {
+			v.check(d.Backup.Dir != "", "database.backup.dir", "is required when backups are enabled")
+			v.check(d.Backup.Interval > 0, "database.backup.interval", "must be positive")
+			v.check(d.Backup.Keep >= 0, "database.backup.keep", "must not be negative")
+		}
+	default:
+		v.add("database.type", `must be "sqlite", "memory" or empty, got `+strconv.Quote(d.Type))
+	}
+
+	v.check(d.Audit.Retention >= 0, "database.audit.retention", "must not be negative")
+	v.check(d.Changes.PollInterval >= 0, "database.changes.pollinterval", "must not be negative")
+	v.check(d.Changes.Retention >= 0, "database.changes.retention", "must not be negative")
+}
+
+func (m *MetricsCfg) validate(v *validator, panelEnabled bool) {
+	if m.Addr != "" {
+		_, port, err := net.SplitHostPort(m.Addr)
+		v.check(err == nil && validPort(port), "metrics.addr", `must be "host:port", got `+strconv.Quote(m.Addr))
+	}
+
+	v.check(!m.Enabled || panelEnabled || m.Addr != "", "metrics.addr", "is required when the panel is disabled")
+}
+
+func (l *LogCfg) validate(v *validator) {
+	switch strings.ToLower(l.Format) {
+	case "", "text", "json":
+	default:
+		v.add("log.format", `must be "text" or "json", got `+strconv.Quote(l.Format))
+	}
+
+	var level slog.Level
+	v.check(l.Level == "" || level.UnmarshalText([]byte(l.Level)) == nil, "log.level",
+		`must be "debug", "info", "warn" or "error", got `+strconv.Quote(l.Level))
+}
+
+func (t *TracingCfg) validate(v *validator) {
+	switch t.Exporter {
+	case "", "none", "stdout", "otlp":
+	default:
+		v.add("tracing.exporter", `must be "otlp", "stdout" or "none", got `+strconv.Quote(t.Exporter))
+	}
+
+	v.check(t.SampleRatio >= 0 && t.SampleRatio <= 1, "tracing.sampleratio", "must be from 0 to 1")
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+
+	return err == nil && n > 0 && n <= 65535
+}
+
+// validProxy accepts the values gin accepts as trusted proxies.
+func validProxy(proxy string) bool {
+	if strings.Contains(proxy, "/") {
+		_, err := netip.ParsePrefix(proxy)
+
+		return err == nil
+	}
+
+	_, err := netip.ParseAddr(proxy)
+
+	return err == nil
+}
@@ -0,0 +1,128 @@
+package apiv1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/adminmanager"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminService interface {
+	Admins(ctx context.Context) ([]repository.Admin, error)
+	Create(ctx context.Context, login, password string, role repository.Role) (repository.Admin, error)
+	SetPassword(ctx context.Context, login, password string) error
+	SetRole(ctx context.Context, login string, role repository.Role) error
+	Delete(ctx context.Context, login string) error
+}
+
+type AdminsHandler struct {
+	admins AdminService
+}
+
+func NewAdminsHandler(admins AdminService) *AdminsHandler {
+	return &AdminsHandler{admins: admins}
+}
+
+type CreateAdminRequest struct {
+	Login    string          `json:"login"`
+	Password string          `json:"password"`
+	Role     repository.Role `json:"role"`
+}
+
+type UpdateAdminRequest struct {
+	Password *string          `json:"password"`
+	Role     *repository.Role `json:"role"`
+}
+
+func (h *AdminsHandler) List(ctx *gin.Context) {
+	admins, err := h.admins.Admins(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load admins"})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, admins)
+}
+
+func (h *AdminsHandler) Create(ctx *gin.Context) {
+	var req CreateAdminRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+
+		return
+	}
+
+	admin, err := h.admins.Create(ctx.Request.Context(), req.Login, req.Password, req.Role)
+	if err != nil {
+		writeAdminError(ctx, err, "failed to create admin")
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, admin)
+}
+
+func (h *AdminsHandler) Update(ctx *gin.Context) {
+	login := ctx.Param("login")
+
+	var req UpdateAdminRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+
+		return
+	}
+
+	if req.Password == nil && req.Role == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "nothing to update"})
+
+		return
+	}
+
+	if req.Password != nil {
+		if err := h.admins.SetPassword(ctx.Request.Context(), login, *req.Password); err != nil {
+			writeAdminError(ctx, err, "failed to update admin")
+
+			return
+		}
+	}
+
+	if req.Role != nil {
+		if err := h.admins.SetRole(ctx.Request.Context(), login, *req.Role); err != nil {
+			writeAdminError(ctx, err, "failed to update admin")
+
+			return
+		}
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *AdminsHandler) Delete(ctx *gin.Context) {
+	if err := h.admins.Delete(ctx.Request.Context(), ctx.Param("login")); err != nil {
+		writeAdminError(ctx, err, "failed to delete admin")
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func writeAdminError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, adminmanager.ErrInvalidLogin),
+		errors.Is(err, adminmanager.ErrWeakPassword),
+		errors.Is(err, adminmanager.ErrUnknownRole):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, adminmanager.ErrLastOwner),
+		errors.Is(err, repository.ErrAdminExists):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrAdminNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
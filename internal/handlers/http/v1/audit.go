@@ -0,0 +1,118 @@
+package apiv1
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/audit"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditService interface {
+	Entries(ctx context.Context, filter repository.AuditFilter) ([]repository.AuditEntry, error)
+}
+
+type AuditHandler struct {
+	audit AuditService
+}
+
+func NewAuditHandler(audit AuditService) *AuditHandler {
+	return &AuditHandler{audit: audit}
+}
+
+type AuditResponse struct {
+	Entries []repository.AuditEntry `json:"entries"`
+	// NextBefore is passed as "before" to get the next page, 0 on the last page.
+	NextBefore int64 `json:"nextBefore,omitempty"`
+}
+
+// List returns audit entries, newest first. Query parameters: chatId, actor,
+// action, source, from and to (RFC 3339), before (entry id) and limit.
+func (h *AuditHandler) List(ctx *gin.Context) {
+	filter, ok := parseAuditFilter(ctx)
+	if !ok {
+		return
+	}
+
+	entries, err := h.audit.Entries(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load audit log"})
+
+		return
+	}
+
+	resp := AuditResponse{Entries: entries}
+	if filter.Limit > 0 && len(entries) == filter.Limit {
+		resp.NextBefore = entries[len(entries)-1].ID
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func parseAuditFilter(ctx *gin.Context) (repository.AuditFilter, bool) {
+	filter := repository.AuditFilter{
+		ActorID: ctx.Query("actor"),
+		Action:  ctx.Query("action"),
+		Source:  ctx.Query("source"),
+	}
+
+	badRequest := func(message string) (repository.AuditFilter, bool) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": message})
+
+		return repository.AuditFilter{}, false
+	}
+
+	if value := ctx.Query("chatId"); value != "" {
+		chatID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return badRequest("invalid chatId")
+		}
+
+		filter.ChatID = &chatID
+	}
+
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{name: "from", target: &filter.From},
+		{name: "to", target: &filter.To},
+	} {
+		value := ctx.Query(param.name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return badRequest("invalid " + param.name + ", expected RFC 3339 time")
+		}
+
+		*param.target = parsed
+	}
+
+	if value := ctx.Query("before"); value != "" {
+		beforeID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || beforeID < 0 {
+			return badRequest("invalid before")
+		}
+
+		filter.BeforeID = beforeID
+	}
+
+	filter.Limit = audit.DefaultLimit
+
+	if value := ctx.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > audit.MaxLimit {
+			return badRequest("limit must be between 1 and " + strconv.Itoa(audit.MaxLimit))
+		}
+
+		filter.Limit = limit
+	}
+
+	return filter, true
+}
@@ -0,0 +1,78 @@
+package apiv1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/6ermvH/trash-bot/internal/services/audit"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditHandler_List(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	repo := inmemory.New()
+	require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 1, Users: []string{"German", "Anthon"}}))
+	require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 2, Users: []string{"Vitaly"}}))
+
+	auditLog := audit.New(repo, 0)
+	handle := New(trashmanager.New(repo).WithAudit(auditLog))
+	tokens := newTestTokens(t)
+
+	router := gin.New()
+	api := router.Group("/", AuthMiddleware(tokens, repository.RoleOwner))
+	api.POST("/chats/:id/next", handle.Next)
+	api.GET("/audit", NewAuditHandler(auditLog).List)
+
+	token := issueTestToken(t, tokens, repository.RoleOwner)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(t.Context(), method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	for _, path := range []string{"/chats/1/next", "/chats/1/next", "/chats/2/next"} {
+		require.Equal(t, http.StatusOK, request(http.MethodPost, path).Code)
+	}
+
+	var resp AuditResponse
+
+	rec := request(http.MethodGet, "/audit?chatId=1&limit=1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Entries, 1)
+
+	entry := resp.Entries[0]
+	require.Equal(t, "admin", entry.ActorType)
+	require.Equal(t, "a", entry.ActorID)
+	require.Equal(t, "panel", entry.Source)
+	require.Equal(t, "rotation.advanced", entry.Action)
+	require.Equal(t, 1, entry.Before.Current)
+	require.Equal(t, 0, entry.After.Current)
+	require.NotZero(t, resp.NextBefore)
+
+	rec = request(http.MethodGet, "/audit?chatId=1&before="+strconv.FormatInt(resp.NextBefore, 10))
+
+	resp = AuditResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Entries, 1)
+	require.Zero(t, resp.NextBefore)
+
+	for _, query := range []string{"chatId=x", "from=yesterday", "limit=0", "limit=1000", "before=-1"} {
+		require.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/audit?"+query).Code, query)
+	}
+}
@@ -1,36 +1,113 @@
 package apiv1
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/adminmanager"
+	"github.com/6ermvH/trash-bot/internal/services/telegramauth"
+	"github.com/6ermvH/trash-bot/internal/services/tokenmanager"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-const tokenTTL = 24 * time.Hour
+const (
+	refreshCookieName = "refresh_token"
+	refreshCookiePath = "/api"
+
+	auditActionLoginFailed = "auth.login_failed"
+)
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, login, password string) (repository.Admin, error)
+}
+
+type TokenIssuer interface {
+	Issue(ctx context.Context, admin repository.Admin) (tokenmanager.Pair, error)
+	IssueTelegram(ctx context.Context, telegramID int64, username string) (tokenmanager.Pair, repository.Admin, error)
+	Refresh(ctx context.Context, refreshToken string) (tokenmanager.Pair, repository.Admin, error)
+	Logout(ctx context.Context, accessToken, refreshToken string) error
+}
+
+// LoginGuard protects login from brute force.
+type LoginGuard interface {
+	Allow(ip, login string) (time.Duration, bool)
+	Failed(ip, login string) (time.Duration, bool)
+	Succeeded(ip, login string)
+}
+
+// TelegramVerifier checks the payload of the Telegram Login Widget.
+type TelegramVerifier interface {
+	Verify(data map[string]string) (telegramauth.User, error)
+}
 
 type AuthHandler struct {
-	adminLogin    string
-	adminPassword string
-	jwtSecret     string
+	admins Authenticator
+	tokens TokenIssuer
+	guard  LoginGuard
+
+	telegram    TelegramVerifier
+	telegramBot string
+
+	secureCookies bool
+
+	audit trashmanager.AuditRecorder
 }
 
-func NewAuthHandler(adminLogin, adminPassword, jwtSecret string) *AuthHandler {
+// NewAuthHandler creates the handler, guard may be nil to allow unlimited attempts.
+func NewAuthHandler(admins Authenticator, tokens TokenIssuer, guard LoginGuard) *AuthHandler {
 	return &AuthHandler{
-		adminLogin:    adminLogin,
-		adminPassword: adminPassword,
-		jwtSecret:     jwtSecret,
+		admins: admins,
+		tokens: tokens,
+		guard:  guard,
 	}
 }
 
+// WithSecureCookies marks the refresh cookie Secure on every request, not only
+// on requests that reached the panel over TLS.
+func (h *AuthHandler) WithSecureCookies(secure bool) *AuthHandler {
+	h.secureCookies = secure
+
+	return h
+}
+
+// WithTelegram enables login with the Telegram Login Widget of the bot.
+func (h *AuthHandler) WithTelegram(verifier TelegramVerifier, botUsername string) *AuthHandler {
+	h.telegram = verifier
+	h.telegramBot = botUsername
+
+	return h
+}
+
+// WithAudit records failed logins to the audit log.
+func (h *AuthHandler) WithAudit(recorder trashmanager.AuditRecorder) *AuthHandler {
+	h.audit = recorder
+
+	return h
+}
+
 type LoginRequest struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
 }
 
+// RefreshRequest is accepted by refresh and logout for clients that cannot use
+// the refresh cookie.
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string          `json:"token"`
+	ExpiresAt    time.Time       `json:"expiresAt"`
+	RefreshToken string          `json:"refreshToken"`
+	Login        string          `json:"login"`
+	Role         repository.Role `json:"role"`
 }
 
 func (h *AuthHandler) Login(ctx *gin.Context) {
@@ -41,23 +118,244 @@ func (h *AuthHandler) Login(ctx *gin.Context) {
 		return
 	}
 
-	if req.Login != h.adminLogin || req.Password != h.adminPassword {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+	ip := ctx.ClientIP()
+
+	if h.guard != nil {
+		if wait, ok := h.guard.Allow(ip, req.Login); !ok {
+			h.auditLoginFailure(ctx, trashmanager.ActorAdmin, req.Login, "rate limited")
+			tooManyRequests(ctx, wait)
+
+			return
+		}
+	}
+
+	admin, err := h.admins.Authenticate(ctx.Request.Context(), req.Login, req.Password)
+	if err != nil {
+		if errors.Is(err, adminmanager.ErrInvalidCredentials) {
+			h.auditLoginFailure(ctx, trashmanager.ActorAdmin, req.Login, "invalid credentials")
+
+			if h.guard != nil {
+				if lockedFor, locked := h.guard.Failed(ip, req.Login); locked {
+					h.auditLoginFailure(ctx, trashmanager.ActorAdmin, req.Login, "locked out for "+lockedFor.String())
+				}
+			}
+
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check credentials"})
+
+		return
+	}
+
+	if h.guard != nil {
+		h.guard.Succeeded(ip, req.Login)
+	}
+
+	pair, err := h.tokens.Issue(ctx.Request.Context(), admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+
+		return
+	}
+
+	h.respondTokens(ctx, pair, admin)
+}
+
+func (h *AuthHandler) auditLoginFailure(ctx *gin.Context, actorType trashmanager.ActorType, login, reason string) {
+	ip := ctx.ClientIP()
 
+	slog.WarnContext(ctx.Request.Context(), "failed login", "login", login, "client_ip", ip, "reason", reason)
+
+	if h.audit == nil {
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"login": req.Login,
-		"exp":   time.Now().Add(tokenTTL).Unix(),
+	h.audit.Record(ctx.Request.Context(), repository.AuditEntry{
+		OccurredAt: time.Now().UTC(),
+		ActorType:  string(actorType),
+		ActorID:    login,
+		ActorName:  login,
+		Source:     string(trashmanager.SourcePanel),
+		Action:     auditActionLoginFailed,
+		Details:    "ip " + ip + ": " + reason,
 	})
+}
+
+// TelegramLogin logs in a Telegram user with the data sent by the Login Widget.
+func (h *AuthHandler) TelegramLogin(ctx *gin.Context) {
+	if h.telegram == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "telegram login is disabled"})
+
+		return
+	}
+
+	var raw map[string]any
+
+	decoder := json.NewDecoder(ctx.Request.Body)
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&raw); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+
+		return
+	}
 
-	tokenString, err := token.SignedString([]byte(h.jwtSecret))
+	// Подпись считается от строковых значений полей, как их прислал виджет
+	data := make(map[string]string, len(raw))
+
+	for key, value := range raw {
+		switch value := value.(type) {
+		case string:
+			data[key] = value
+		case json.Number:
+			data[key] = value.String()
+		}
+	}
+
+	// Попытки ограничиваются так же, как вход по паролю, ключом служит Telegram ID
+	ip, login := ctx.ClientIP(), "tg:"+data["id"]
+
+	if h.guard != nil {
+		if wait, ok := h.guard.Allow(ip, login); !ok {
+			h.auditLoginFailure(ctx, trashmanager.ActorTelegram, data["id"], "rate limited")
+			tooManyRequests(ctx, wait)
+
+			return
+		}
+	}
+
+	user, err := h.telegram.Verify(data)
+	if err != nil {
+		h.auditLoginFailure(ctx, trashmanager.ActorTelegram, data["id"], err.Error())
+
+		if h.guard != nil {
+			if lockedFor, locked := h.guard.Failed(ip, login); locked {
+				h.auditLoginFailure(ctx, trashmanager.ActorTelegram, data["id"], "locked out for "+lockedFor.String())
+			}
+		}
+
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid telegram login data"})
+
+		return
+	}
+
+	if h.guard != nil {
+		h.guard.Succeeded(ip, login)
+	}
+
+	pair, admin, err := h.tokens.IssueTelegram(ctx.Request.Context(), user.ID, user.Username)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 
 		return
 	}
 
-	ctx.JSON(http.StatusOK, LoginResponse{Token: tokenString})
+	h.respondTokens(ctx, pair, admin)
+}
+
+// Config tells the login page which login methods are available.
+func (h *AuthHandler) Config(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"telegramBot": h.telegramBot})
+}
+
+// Refresh exchanges the refresh token for a new access and refresh token.
+func (h *AuthHandler) Refresh(ctx *gin.Context) {
+	refreshToken := refreshTokenFromRequest(ctx)
+	if refreshToken == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token required"})
+
+		return
+	}
+
+	pair, admin, err := h.tokens.Refresh(ctx.Request.Context(), refreshToken)
+	if err != nil {
+		if errors.Is(err, tokenmanager.ErrInvalidToken) || errors.Is(err, tokenmanager.ErrTokenRevoked) {
+			h.clearRefreshCookie(ctx)
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+
+		return
+	}
+
+	h.respondTokens(ctx, pair, admin)
+}
+
+// Logout revokes the access token from the Authorization header and the
+// refresh token family.
+func (h *AuthHandler) Logout(ctx *gin.Context) {
+	accessToken, _ := bearerToken(ctx)
+
+	if err := h.tokens.Logout(ctx.Request.Context(), accessToken, refreshTokenFromRequest(ctx)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+
+		return
+	}
+
+	h.clearRefreshCookie(ctx)
+	ctx.Status(http.StatusNoContent)
+}
+
+// Me returns the account of the authenticated admin.
+func (h *AuthHandler) Me(ctx *gin.Context) {
+	login, role := CurrentAdmin(ctx)
+
+	me := gin.H{"login": login, "role": role}
+
+	if user, ok := CurrentTelegramUser(ctx); ok {
+		me["telegramId"] = user.ID
+		me["telegramUsername"] = user.Username
+	}
+
+	ctx.JSON(http.StatusOK, me)
+}
+
+func (h *AuthHandler) respondTokens(ctx *gin.Context, pair tokenmanager.Pair, admin repository.Admin) {
+	ctx.SetSameSite(http.SameSiteStrictMode)
+	ctx.SetCookie(
+		refreshCookieName,
+		pair.RefreshToken,
+		int(time.Until(pair.RefreshExpiresAt).Seconds()),
+		refreshCookiePath,
+		"",
+		h.secureCookie(ctx),
+		true,
+	)
+
+	ctx.JSON(http.StatusOK, LoginResponse{
+		Token:        pair.AccessToken,
+		ExpiresAt:    pair.AccessExpiresAt,
+		RefreshToken: pair.RefreshToken,
+		Login:        admin.Login,
+		Role:         admin.Role,
+	})
+}
+
+func (h *AuthHandler) clearRefreshCookie(ctx *gin.Context) {
+	ctx.SetSameSite(http.SameSiteStrictMode)
+	ctx.SetCookie(refreshCookieName, "", -1, refreshCookiePath, "", h.secureCookie(ctx), true)
+}
+
+// secureCookie reports whether the refresh cookie must be sent only over HTTPS.
+func (h *AuthHandler) secureCookie(ctx *gin.Context) bool {
+	return h.secureCookies || ctx.Request.TLS != nil
+}
+
+func refreshTokenFromRequest(ctx *gin.Context) string {
+	if token, err := ctx.Cookie(refreshCookieName); err == nil && token != "" {
+		return token
+	}
+
+	var req RefreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ""
+	}
+
+	return req.RefreshToken
 }
@@ -0,0 +1,228 @@
+package apiv1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/6ermvH/trash-bot/internal/services/adminmanager"
+	"github.com/6ermvH/trash-bot/internal/services/ratelimit"
+	"github.com/6ermvH/trash-bot/internal/services/telegramauth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthHandler_LoginLockout(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	repo := inmemory.New()
+	admins := adminmanager.New(repo)
+
+	_, err := admins.Create(t.Context(), "admin", "password123", repository.RoleOwner)
+	require.NoError(t, err)
+
+	handler := NewAuthHandler(admins, newTestTokens(t), ratelimit.NewLoginGuard(60, 10, 2, time.Minute))
+
+	router := gin.New()
+	router.POST("/login", handler.Login)
+
+	login := func(password string) *httptest.ResponseRecorder {
+		body := `{"login": "admin", "password": "` + password + `"}`
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	require.Equal(t, http.StatusOK, login("password123").Code)
+
+	for range 2 {
+		require.Equal(t, http.StatusUnauthorized, login("wrong").Code)
+	}
+
+	rec := login("password123")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "60", rec.Header().Get("Retry-After"))
+}
+
+func TestAuthHandler_SecureCookie(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	admins := adminmanager.New(inmemory.New())
+
+	_, err := admins.Create(t.Context(), "admin", "password123", repository.RoleOwner)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		secureCookies bool
+		want          bool
+	}{
+		"Plain HTTP":     {secureCookies: false, want: false},
+		"TLS at a proxy": {secureCookies: true, want: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := NewAuthHandler(admins, newTestTokens(t), nil).WithSecureCookies(tt.secureCookies)
+
+			router := gin.New()
+			router.POST("/login", handler.Login)
+
+			body := `{"login": "admin", "password": "password123"}`
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/login", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			cookies := rec.Result().Cookies()
+			require.Len(t, cookies, 1)
+			require.Equal(t, refreshCookieName, cookies[0].Name)
+			require.Equal(t, tt.want, cookies[0].Secure)
+		})
+	}
+}
+
+type verifierStub struct{}
+
+func (verifierStub) Verify(data map[string]string) (telegramauth.User, error) {
+	if data["hash"] != "valid" {
+		return telegramauth.User{}, telegramauth.ErrInvalidHash
+	}
+
+	id, err := strconv.ParseInt(data["id"], 10, 64)
+	if err != nil {
+		return telegramauth.User{}, telegramauth.ErrMissingData
+	}
+
+	return telegramauth.User{ID: id, Username: data["username"]}, nil
+}
+
+func TestAuthHandler_TelegramLogin(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	tokens := newTestTokens(t)
+	handler := NewAuthHandler(adminmanager.New(inmemory.New()), tokens, nil).WithTelegram(verifierStub{}, "trash_bot")
+
+	router := gin.New()
+	router.POST("/login/telegram", handler.TelegramLogin)
+
+	login := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/login/telegram", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := login(`{"id": 42, "username": "german", "auth_date": 1700000000, "hash": "valid"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp LoginResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "tg:42", resp.Login)
+
+	claims, err := tokens.Validate(t.Context(), resp.Token)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), claims.TelegramID)
+	require.Equal(t, "german", claims.TelegramUsername)
+
+	require.Equal(t, http.StatusUnauthorized, login(`{"id": 42, "hash": "forged"}`).Code)
+	require.Equal(t, http.StatusBadRequest, login(`not json`).Code)
+}
+
+func TestAuthHandler_TelegramLoginLockout(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	guard := ratelimit.NewLoginGuard(60, 10, 2, time.Minute)
+	handler := NewAuthHandler(adminmanager.New(inmemory.New()), newTestTokens(t), guard).
+		WithTelegram(verifierStub{}, "trash_bot")
+
+	router := gin.New()
+	router.POST("/login/telegram", handler.TelegramLogin)
+
+	login := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/login/telegram", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	valid := `{"id": 42, "username": "german", "auth_date": 1700000000, "hash": "valid"}`
+
+	require.Equal(t, http.StatusOK, login(valid).Code)
+
+	for range 2 {
+		require.Equal(t, http.StatusUnauthorized, login(`{"id": 42, "hash": "forged"}`).Code)
+	}
+
+	rec := login(valid)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "60", rec.Header().Get("Retry-After"))
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies([]string{"10.0.0.1"}))
+	router.Use(RateLimitMiddleware(ratelimit.New(1, 1)))
+	router.GET("/", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	request := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	require.Equal(t, http.StatusOK, request("192.168.0.1:1000", "").Code)
+
+	rec := request("192.168.0.1:1000", "")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	// Заголовок от недоверенного клиента игнорируется
+	require.Equal(t, http.StatusTooManyRequests, request("192.168.0.1:1000", "172.16.0.1").Code)
+
+	// За доверенным прокси каждый клиент получает свой лимит
+	require.Equal(t, http.StatusOK, request("10.0.0.1:1000", "172.16.0.1").Code)
+	require.Equal(t, http.StatusOK, request("10.0.0.1:1000", "172.16.0.2").Code)
+	require.Equal(t, http.StatusTooManyRequests, request("10.0.0.1:1000", "172.16.0.2").Code)
+}
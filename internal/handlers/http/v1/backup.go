@@ -0,0 +1,41 @@
+package apiv1
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+type Snapshotter interface {
+	Snapshot(ctx context.Context, w io.Writer) error
+}
+
+type BackupHandler struct {
+	snapshotter Snapshotter
+}
+
+func NewBackupHandler(snapshotter Snapshotter) *BackupHandler {
+	return &BackupHandler{snapshotter: snapshotter}
+}
+
+func (h *BackupHandler) Backup(ctx *gin.Context) {
+	filename := "trash-" + time.Now().UTC().Format("20060102-150405") + ".db"
+
+	ctx.Header("Content-Type", "application/vnd.sqlite3")
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if err := h.snapshotter.Snapshot(ctx.Request.Context(), ctx.Writer); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "backup snapshot", logging.Err(err))
+
+		if !ctx.Writer.Written() {
+			ctx.Writer.Header().Del("Content-Type")
+			ctx.Writer.Header().Del("Content-Disposition")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create backup"})
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package apiv1
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/gin-gonic/gin"
+)
+
+type MembersRequest struct {
+	Members []string `json:"members"`
+}
+
+type SubscriptionRequest struct {
+	NotifyTime string `json:"notifyTime"`
+}
+
+func (h *HandlerM) Next(ctx *gin.Context) {
+	chatID, ok := h.chatID(ctx)
+	if !ok {
+		return
+	}
+
+	if _, err := h.service.Next(serviceContext(ctx), chatID); err != nil {
+		writeServiceError(ctx, err, "failed to move to next user")
+
+		return
+	}
+
+	h.respondChat(ctx, chatID)
+}
+
+func (h *HandlerM) Prev(ctx *gin.Context) {
+	chatID, ok := h.chatID(ctx)
+	if !ok {
+		return
+	}
+
+	if _, err := h.service.Prev(serviceContext(ctx), chatID); err != nil {
+		writeServiceError(ctx, err, "failed to move to previous user")
+
+		return
+	}
+
+	h.respondChat(ctx, chatID)
+}
+
+func (h *HandlerM) SetMembers(ctx *gin.Context) {
+	chatID, ok := h.chatID(ctx)
+	if !ok {
+		return
+	}
+
+	var req MembersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+
+		return
+	}
+
+	members := make([]string, 0, len(req.Members))
+
+	for _, member := range req.Members {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "member names must not be empty"})
+
+			return
+		}
+
+		members = append(members, member)
+	}
+
+	if len(members) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "at least one member is required"})
+
+		return
+	}
+
+	if err := h.service.SetEstablish(serviceContext(ctx), chatID, members); err != nil {
+		writeServiceError(ctx, err, "failed to set members")
+
+		return
+	}
+
+	h.respondChat(ctx, chatID)
+}
+
+func (h *HandlerM) Subscribe(ctx *gin.Context) {
+	chatID, ok := h.chatID(ctx)
+	if !ok {
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+
+		return
+	}
+
+	if err := trashmanager.ValidateNotifyTime(req.NotifyTime); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	if err := h.service.Subscribe(serviceContext(ctx), chatID, req.NotifyTime); err != nil {
+		writeServiceError(ctx, err, "failed to subscribe")
+
+		return
+	}
+
+	h.respondChat(ctx, chatID)
+}
+
+func (h *HandlerM) Unsubscribe(ctx *gin.Context) {
+	chatID, ok := h.chatID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Unsubscribe(serviceContext(ctx), chatID); err != nil {
+		writeServiceError(ctx, err, "failed to unsubscribe")
+
+		return
+	}
+
+	h.respondChat(ctx, chatID)
+}
+
+func (h *HandlerM) respondChat(ctx *gin.Context, chatID int64) {
+	chat, err := h.service.Chat(ctx.Request.Context(), chatID)
+	if err != nil {
+		writeServiceError(ctx, err, "failed to load chat")
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chat)
+}
+
+// serviceContext returns the request context for write operations. The change is
+// announced in the Telegram chat when the request has "announce=true".
+func serviceContext(ctx *gin.Context) context.Context {
+	if announce, _ := strconv.ParseBool(ctx.Query("announce")); announce {
+		return trashmanager.WithAnnounce(ctx.Request.Context())
+	}
+
+	return ctx.Request.Context()
+}
+
+func parseChatID(ctx *gin.Context) (int64, bool) {
+	chatID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+
+		return 0, false
+	}
+
+	return chatID, true
+}
+
+func writeServiceError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrChatIsNotInitialize),
+		errors.Is(err, trashmanager.ErrTryToInitialize):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "chat not found"})
+	case errors.Is(err, repository.ErrChatIsEmpty),
+		errors.Is(err, trashmanager.ErrTryToAddUsers):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "chat has no members"})
+	default:
+		slog.ErrorContext(ctx.Request.Context(), message, logging.Err(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,217 @@
+package apiv1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(t *testing.T, chats ...repository.Chat) *gin.Engine {
+	t.Helper()
+
+	router, _ := newTestRouterWithService(t, chats...)
+
+	return router
+}
+
+func newTestRouterWithService(t *testing.T, chats ...repository.Chat) (*gin.Engine, *trashmanager.Service) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	repo := inmemory.New()
+	for _, chat := range chats {
+		require.NoError(t, repo.SaveChat(t.Context(), chat))
+	}
+
+	service := trashmanager.New(repo)
+	handle := New(service)
+
+	router := gin.New()
+	router.GET("/chats/:id", handle.ChatByID)
+	router.POST("/chats/:id/next", handle.Next)
+	router.POST("/chats/:id/prev", handle.Prev)
+	router.PUT("/chats/:id/members", handle.SetMembers)
+	router.PUT("/chats/:id/subscription", handle.Subscribe)
+	router.DELETE("/chats/:id/subscription", handle.Unsubscribe)
+
+	return router, service
+}
+
+func doRequest(t *testing.T, router *gin.Engine, method, path, body string) (int, repository.Chat) {
+	t.Helper()
+
+	req := httptest.NewRequestWithContext(t.Context(), method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var chat repository.Chat
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &chat))
+	}
+
+	return rec.Code, chat
+}
+
+func TestHandlerM_Rotation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Next and prev", func(t *testing.T) {
+		t.Parallel()
+
+		router := newTestRouter(t, repository.Chat{ID: 1, Users: []string{"German", "Anthon", "Vitaly"}})
+
+		code, chat := doRequest(t, router, http.MethodPost, "/chats/1/next", "")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, 1, chat.Current)
+
+		code, chat = doRequest(t, router, http.MethodPost, "/chats/1/prev", "")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, 0, chat.Current)
+	})
+
+	t.Run("Announce flag", func(t *testing.T) {
+		t.Parallel()
+
+		router, service := newTestRouterWithService(t, repository.Chat{ID: 1, Users: []string{"German", "Anthon"}})
+
+		var announced []bool
+
+		service.OnEvent(func(ctx context.Context, event trashmanager.Event) {
+			announced = append(announced, event.Meta().Announce)
+		})
+
+		code, _ := doRequest(t, router, http.MethodPost, "/chats/1/next", "")
+		require.Equal(t, http.StatusOK, code)
+
+		code, _ = doRequest(t, router, http.MethodPost, "/chats/1/next?announce=true", "")
+		require.Equal(t, http.StatusOK, code)
+
+		require.Equal(t, []bool{false, true}, announced)
+	})
+
+	t.Run("Unknown chat", func(t *testing.T) {
+		t.Parallel()
+
+		router := newTestRouter(t)
+
+		code, _ := doRequest(t, router, http.MethodPost, "/chats/1/next", "")
+		require.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("Empty chat", func(t *testing.T) {
+		t.Parallel()
+
+		router := newTestRouter(t, repository.Chat{ID: 1})
+
+		code, _ := doRequest(t, router, http.MethodPost, "/chats/1/next", "")
+		require.Equal(t, http.StatusConflict, code)
+	})
+
+	t.Run("Invalid chat id", func(t *testing.T) {
+		t.Parallel()
+
+		router := newTestRouter(t)
+
+		code, _ := doRequest(t, router, http.MethodPost, "/chats/abc/next", "")
+		require.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestHandlerM_SetMembers(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		body         string
+		expectedCode int
+		expected     []string
+	}{
+		{
+			name:         "Creates chat with trimmed members",
+			body:         `{"members": [" German ", "Anthon"]}`,
+			expectedCode: http.StatusOK,
+			expected:     []string{"German", "Anthon"},
+		},
+		{
+			name:         "Empty list",
+			body:         `{"members": []}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Blank member",
+			body:         `{"members": ["German", "  "]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Malformed body",
+			body:         `{"members": "German"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := newTestRouter(t)
+
+			code, chat := doRequest(t, router, http.MethodPut, "/chats/5/members", tc.body)
+			require.Equal(t, tc.expectedCode, code)
+
+			if tc.expected != nil {
+				require.Equal(t, tc.expected, chat.Users)
+			}
+		})
+	}
+}
+
+func TestHandlerM_Subscription(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Subscribe and unsubscribe", func(t *testing.T) {
+		t.Parallel()
+
+		router := newTestRouter(t, repository.Chat{ID: 1, Users: []string{"German"}})
+
+		code, chat := doRequest(t, router, http.MethodPut, "/chats/1/subscription", `{"notifyTime": "08:30"}`)
+		require.Equal(t, http.StatusOK, code)
+		require.NotNil(t, chat.NotifyTime)
+		require.Equal(t, "08:30", *chat.NotifyTime)
+
+		code, chat = doRequest(t, router, http.MethodDelete, "/chats/1/subscription", "")
+		require.Equal(t, http.StatusOK, code)
+		require.Nil(t, chat.NotifyTime)
+	})
+
+	t.Run("Invalid time", func(t *testing.T) {
+		t.Parallel()
+
+		router := newTestRouter(t, repository.Chat{ID: 1, Users: []string{"German"}})
+
+		for _, body := range []string{`{"notifyTime": "8:30"}`, `{"notifyTime": "25:00"}`, `{}`} {
+			code, _ := doRequest(t, router, http.MethodPut, "/chats/1/subscription", body)
+			require.Equal(t, http.StatusBadRequest, code, body)
+		}
+	})
+
+	t.Run("Unknown chat", func(t *testing.T) {
+		t.Parallel()
+
+		router := newTestRouter(t)
+
+		code, _ := doRequest(t, router, http.MethodPut, "/chats/1/subscription", `{"notifyTime": "08:30"}`)
+		require.Equal(t, http.StatusNotFound, code)
+	})
+}
@@ -0,0 +1,61 @@
+package apiv1
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/services/reloader"
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigSource is the effective configuration of the running application.
+type ConfigSource interface {
+	Status() reloader.Status
+}
+
+type ConfigHandler struct {
+	source ConfigSource
+}
+
+func NewConfigHandler(source ConfigSource) *ConfigHandler {
+	return &ConfigHandler{source: source}
+}
+
+type ConfigResponse struct {
+	// Config is keyed by the YAML names, secrets are redacted.
+	Config          map[string]any `json:"config"`
+	LoadedAt        time.Time      `json:"loadedAt"`
+	RestartRequired []string       `json:"restartRequired"`
+	// Error is the error of the last reload, the config before it stays in effect.
+	Error string `json:"error,omitempty"`
+}
+
+func (h *ConfigHandler) Get(ctx *gin.Context) {
+	status := h.source.Status()
+
+	doc, err := status.Config.Redacted().Document()
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "encode config", logging.Err(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load config"})
+
+		return
+	}
+
+	resp := ConfigResponse{
+		Config:          doc,
+		LoadedAt:        status.LoadedAt,
+		RestartRequired: status.RestartRequired,
+	}
+
+	if resp.RestartRequired == nil {
+		resp.RestartRequired = []string{}
+	}
+
+	if status.Err != nil {
+		resp.Error = status.Err.Error()
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,61 @@
+package apiv1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/config"
+	"github.com/6ermvH/trash-bot/internal/services/reloader"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type configSourceStub reloader.Status
+
+func (s configSourceStub) Status() reloader.Status {
+	return reloader.Status(s)
+}
+
+func TestConfigHandler_Get(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.New().WithTelegramBotKey("123:token")
+	cfg.Server.Port = "8080"
+	cfg.Server.AccessTokenTTL = 15 * time.Minute
+
+	loadedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	router := gin.New()
+	router.GET("/admin/config", NewConfigHandler(configSourceStub{
+		Config:          cfg,
+		LoadedAt:        loadedAt,
+		RestartRequired: []string{"server.port"},
+		Err:             errors.New("invalid config: log.level must be set"),
+	}).Get)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/admin/config", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp ConfigResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	require.Equal(t, loadedAt, resp.LoadedAt)
+	require.Equal(t, []string{"server.port"}, resp.RestartRequired)
+	require.Equal(t, "invalid config: log.level must be set", resp.Error)
+
+	require.Equal(t, map[string]any{"botkey": "[REDACTED]"}, resp.Config["telegram"])
+
+	server, ok := resp.Config["server"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, "8080", server["port"])
+	require.Equal(t, "15m0s", server["accesstokenttl"])
+	require.Empty(t, server["jwtsecret"])
+	require.NotContains(t, rec.Body.String(), "123:token")
+}
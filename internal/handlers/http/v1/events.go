@@ -0,0 +1,130 @@
+package apiv1
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/services/feed"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultHeartbeat = 15 * time.Second
+
+	eventReady = "ready"
+	eventReset = "reset"
+)
+
+// EventFeed streams chat changes to the panel.
+type EventFeed interface {
+	Subscribe(lastID uint64, resume bool) *feed.Subscription
+}
+
+// WithEvents enables the event stream, heartbeat 0 means the default interval.
+func (h *HandlerM) WithEvents(events EventFeed, heartbeat time.Duration) *HandlerM {
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+
+	h.events = events
+	h.heartbeat = heartbeat
+
+	return h
+}
+
+// Events streams chat changes as Server-Sent Events. A client sending
+// Last-Event-ID gets the changes it missed, or a reset event when they are no
+// longer buffered and the state has to be reloaded. A new client gets a ready
+// event with the id to resume from. The stream ends when the access token
+// expires, the client reconnects with a fresh one.
+func (h *HandlerM) Events(ctx *gin.Context) {
+	var (
+		lastID uint64
+		resume bool
+	)
+
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		// Непонятный номер приводит к reset, как и вытесненный из буфера
+		lastID, _ = strconv.ParseUint(header, 10, 64)
+		resume = true
+	}
+
+	sub := h.events.Subscribe(lastID, resume)
+	defer sub.Close()
+
+	ctx.Header("Content-Type", sse.ContentType)
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	switch {
+	case sub.Reset:
+		h.sendEvent(ctx, eventReset, sub.LastID, gin.H{})
+	case !resume:
+		h.sendEvent(ctx, eventReady, sub.LastID, gin.H{})
+	}
+
+	for _, event := range sub.Missed {
+		h.sendChatEvent(ctx, event)
+	}
+
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	var expired <-chan time.Time
+
+	if expiresAt, ok := tokenExpiresAt(ctx); ok {
+		timer := time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-expired:
+			return
+		case <-heartbeat.C:
+			_, _ = ctx.Writer.WriteString(": ping\n\n")
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+
+			h.sendChatEvent(ctx, event)
+		}
+
+		ctx.Writer.Flush()
+	}
+}
+
+// sendChatEvent sends a change of a chat the current user may see.
+func (h *HandlerM) sendChatEvent(ctx *gin.Context, event feed.Event) {
+	if user, ok := CurrentTelegramUser(ctx); ok {
+		// Удалённый чат уже нельзя проверить, пользователи Telegram его не получат
+		if event.Chat == nil {
+			return
+		}
+
+		allowed, err := h.canAccess(ctx.Request.Context(), user, *event.Chat)
+		if err != nil || !allowed {
+			return
+		}
+	}
+
+	h.sendEvent(ctx, event.Type, event.ID, event)
+}
+
+func (h *HandlerM) sendEvent(ctx *gin.Context, name string, id uint64, data any) {
+	_ = sse.Encode(ctx.Writer, sse.Event{
+		Event: name,
+		Id:    strconv.FormatUint(id, 10),
+		Data:  data,
+	})
+}
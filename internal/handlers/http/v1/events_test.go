@@ -0,0 +1,171 @@
+package apiv1
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/6ermvH/trash-bot/internal/services/feed"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	id   string
+	name string
+	data string
+}
+
+// readEvent reads the next event from the stream, comments are returned as
+// events named by their text.
+func readEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var event sseEvent
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			return event
+		case strings.HasPrefix(line, ":"):
+			event.name = strings.TrimSpace(line)
+		case strings.HasPrefix(line, "id:"):
+			event.id = strings.TrimPrefix(line, "id:")
+		case strings.HasPrefix(line, "event:"):
+			event.name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			event.data = strings.TrimPrefix(line, "data:")
+		}
+	}
+}
+
+// nextEvent skips heartbeat comments.
+func nextEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+
+	for {
+		if event := readEvent(t, reader); event.name != ": ping" {
+			return event
+		}
+	}
+}
+
+func TestHandlerM_Events(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	repo := inmemory.New()
+	require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 1, Users: []string{"german"}}))
+	require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 2, Users: []string{"vitaly"}}))
+
+	service := trashmanager.New(repo)
+	broker := feed.New(3)
+	service.OnEvent(broker.Handler(service))
+
+	tokens := newTestTokens(t)
+	handle := New(service).WithChatAccess(memberAccess{}).WithEvents(broker, 20*time.Millisecond)
+
+	router := gin.New()
+	router.GET("/events", AuthMiddleware(tokens, repository.RoleViewer), handle.Events)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	adminToken := issueTestToken(t, tokens, repository.RoleViewer)
+
+	pair, _, err := tokens.IssueTelegram(t.Context(), 42, "german")
+	require.NoError(t, err)
+
+	connect := func(t *testing.T, token, lastEventID string) *bufio.Reader {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+"/events", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+
+		return bufio.NewReader(resp.Body)
+	}
+
+	admin := connect(t, adminToken, "")
+	ready := nextEvent(t, admin)
+	require.Equal(t, "ready", ready.name)
+
+	telegram := connect(t, pair.AccessToken, ready.id)
+
+	_, err = service.Next(t.Context(), 2)
+	require.NoError(t, err)
+	_, err = service.Next(t.Context(), 1)
+	require.NoError(t, err)
+
+	first := nextEvent(t, admin)
+	require.Equal(t, string(trashmanager.EventRotationAdvanced), first.name)
+	require.Contains(t, first.data, `"chatId":2`)
+
+	second := nextEvent(t, admin)
+	require.Contains(t, second.data, `"chatId":1`)
+
+	// Пользователь Telegram не получает изменения чужого чата
+	own := nextEvent(t, telegram)
+	require.Equal(t, second.id, own.id)
+
+	require.Equal(t, ": ping", readEvent(t, admin).name)
+
+	t.Run("Resume", func(t *testing.T) {
+		missed := connect(t, adminToken, first.id)
+		require.Equal(t, second.id, nextEvent(t, missed).id)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		for range 3 {
+			_, err := service.Next(t.Context(), 1)
+			require.NoError(t, err)
+		}
+
+		stale := connect(t, adminToken, first.id)
+		require.Equal(t, "reset", nextEvent(t, stale).name)
+
+		unknown := connect(t, adminToken, "garbage")
+		require.Equal(t, "reset", nextEvent(t, unknown).name)
+	})
+
+	t.Run("Token expiry ends the stream", func(t *testing.T) {
+		// exp в секундах, токен истечёт через одну-две секунды
+		expiring := signTestToken(t, jwt.MapClaims{
+			"login": "a",
+			"role":  "viewer",
+			"jti":   "expiring",
+			"exp":   time.Now().Add(2 * time.Second).Unix(),
+		})
+
+		stream := connect(t, expiring, "")
+
+		var err error
+		for err == nil {
+			_, err = stream.ReadString('\n')
+		}
+	})
+}
@@ -2,17 +2,27 @@ package apiv1
 
 import (
 	"context"
-	"errors"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/6ermvH/trash-bot/internal/repository"
 	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	defaultChatsLimit = 50
+	maxChatsLimit     = 500
+)
+
 type Service interface {
 	Chats(ctx context.Context) ([]repository.Chat, error)
+	FindChats(ctx context.Context, query repository.ChatQuery) (trashmanager.ChatPage, error)
 	Chat(ctx context.Context, chatID int64) (*repository.Chat, error)
 	Stats(ctx context.Context) (trashmanager.Stats, error)
 
@@ -22,60 +32,184 @@ type Service interface {
 	SetEstablish(ctx context.Context, chatID int64, users []string) error
 	Subscribe(ctx context.Context, chatID int64, notifyTime string) error
 	Unsubscribe(ctx context.Context, chatID int64) error
+
+	Export(ctx context.Context) (trashmanager.Export, error)
+	Import(
+		ctx context.Context,
+		data trashmanager.Export,
+		opts trashmanager.ImportOptions,
+	) (trashmanager.ImportResult, error)
 }
 
 type HandlerM struct {
 	service Service
+	access  ChatAccess
+
+	events    EventFeed
+	heartbeat time.Duration
+
+	stats DetailedStats
 }
 
 func New(service Service) *HandlerM {
 	return &HandlerM{service: service}
 }
 
+type ChatsResponse struct {
+	Chats []repository.Chat `json:"chats"`
+	// NextCursor is passed as "cursor" to get the next page, empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Chats returns a page of chats. Query parameters: subscribed, member, empty,
+// sort (id, members or notifyTime), order (asc or desc), cursor and limit.
 func (h *HandlerM) Chats(ctx *gin.Context) {
-	chats, err := h.service.Chats(ctx.Request.Context())
+	query, ok := parseChatQuery(ctx)
+	if !ok {
+		return
+	}
+
+	page, err := h.findVisibleChats(ctx, query)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load chats"})
 
 		return
 	}
 
-	ctx.JSON(http.StatusOK, chats)
+	resp := ChatsResponse{Chats: page.Chats}
+	if page.Next != nil {
+		resp.NextCursor = encodeCursor(*page.Next)
+	}
+
+	ctx.JSON(http.StatusOK, resp)
 }
 
 func (h *HandlerM) ChatByID(ctx *gin.Context) {
-	idStr := ctx.Param("id")
+	chatID, ok := h.chatID(ctx)
+	if !ok {
+		return
+	}
+
+	h.respondChat(ctx, chatID)
+}
 
-	chatID, err := strconv.ParseInt(idStr, 10, 64)
+func (h *HandlerM) Stats(ctx *gin.Context) {
+	if _, ok := CurrentTelegramUser(ctx); ok {
+		h.scopedStats(ctx)
+
+		return
+	}
+
+	stats, err := h.service.Stats(ctx.Request.Context())
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load stats"})
 
 		return
 	}
 
-	chat, err := h.service.Chat(ctx.Request.Context(), chatID)
+	ctx.JSON(http.StatusOK, stats)
+}
+
+// scopedStats counts only the chats visible to a Telegram user.
+func (h *HandlerM) scopedStats(ctx *gin.Context) {
+	chats, err := h.service.Chats(ctx.Request.Context())
+	if err == nil {
+		chats, err = h.visibleChats(ctx, chats)
+	}
+
 	if err != nil {
-		if errors.Is(err, repository.ErrChatIsNotInitialize) {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "chat not found"})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load stats"})
 
-			return
+		return
+	}
+
+	ctx.JSON(http.StatusOK, trashmanager.StatsOf(chats))
+}
+
+func parseChatQuery(ctx *gin.Context) (repository.ChatQuery, bool) {
+	query := repository.ChatQuery{
+		Member: strings.TrimSpace(ctx.Query("member")),
+		Sort:   repository.ChatSort(ctx.DefaultQuery("sort", string(repository.ChatSortID))),
+		Limit:  defaultChatsLimit,
+	}
+
+	badRequest := func(message string) (repository.ChatQuery, bool) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": message})
+
+		return repository.ChatQuery{}, false
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **bool
+	}{
+		{name: "subscribed", target: &query.Subscribed},
+		{name: "empty", target: &query.Empty},
+	} {
+		value := ctx.Query(param.name)
+		if value == "" {
+			continue
 		}
 
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load chat"})
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return badRequest("invalid " + param.name + " value")
+		}
 
-		return
+		*param.target = &parsed
+	}
+
+	if !query.Sort.Valid() {
+		return badRequest("sort must be one of id, members, notifyTime")
+	}
+
+	switch ctx.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return badRequest("order must be asc or desc")
+	}
+
+	if value := ctx.Query("cursor"); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil {
+			return badRequest("invalid cursor")
+		}
+
+		query.After = &cursor
+	}
+
+	if value := ctx.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxChatsLimit {
+			return badRequest("limit must be between 1 and " + strconv.Itoa(maxChatsLimit))
+		}
+
+		query.Limit = limit
 	}
 
-	ctx.JSON(http.StatusOK, chat)
+	return query, true
 }
 
-func (h *HandlerM) Stats(ctx *gin.Context) {
-	stats, err := h.service.Stats(ctx.Request.Context())
+// encodeCursor makes an opaque page token of the cursor.
+func encodeCursor(cursor repository.ChatCursor) string {
+	data, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (repository.ChatCursor, error) {
+	var cursor repository.ChatCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load stats"})
+		return cursor, fmt.Errorf("decode cursor: %w", err)
+	}
 
-		return
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("parse cursor: %w", err)
 	}
 
-	ctx.JSON(http.StatusOK, stats)
+	return cursor, nil
 }
@@ -0,0 +1,64 @@
+package apiv1
+
+import (
+	"crypto/rand"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits request ids set by clients or proxies, other values are replaced.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestLogMiddleware sets the request id, taken from X-Request-ID or
+// generated, on the response and on the context of the request, so that
+// records logged while handling it are correlated, and logs the request.
+func RequestLogMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = rand.Text()
+		}
+
+		ctx.Header(RequestIDHeader, requestID)
+
+		attrs := []slog.Attr{slog.String(logging.KeyRequestID, requestID)}
+
+		// Чат берём из маршрута, чтобы не разбирать id в каждом обработчике
+		if strings.HasPrefix(ctx.FullPath(), "/api/chats/:id") {
+			if chatID, err := strconv.ParseInt(ctx.Param("id"), 10, 64); err == nil {
+				attrs = append(attrs, slog.Int64(logging.KeyChatID, chatID))
+			}
+		}
+
+		ctx.Request = ctx.Request.WithContext(logging.With(ctx.Request.Context(), attrs...))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.Log(ctx.Request.Context(), level, "http request",
+			"method", ctx.Request.Method,
+			"route", ctx.FullPath(),
+			"path", ctx.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+			"client_ip", ctx.ClientIP(),
+		)
+	}
+}
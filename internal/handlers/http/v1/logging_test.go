@@ -0,0 +1,54 @@
+package apiv1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogMiddleware(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+
+	logger, err := logging.New(&buf, logging.FormatJSON, "info")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(RequestLogMiddleware())
+	router.GET("/api/chats/:id", func(ctx *gin.Context) {
+		// Так пишут сервисы, получая контекст запроса
+		logger.InfoContext(ctx.Request.Context(), "inside")
+		ctx.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/chats/-100", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, "req-1", rec.Header().Get(RequestIDHeader))
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "req-1", record[logging.KeyRequestID])
+	require.InDelta(t, -100, record[logging.KeyChatID], 0)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/chats/1", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	generated := rec.Header().Get(RequestIDHeader)
+	require.NotEqual(t, "bad id\n", generated)
+	require.Regexp(t, requestIDPattern, generated)
+}
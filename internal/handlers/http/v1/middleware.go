@@ -1,45 +1,113 @@
 package apiv1
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/tokenmanager"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-const authHeaderParts = 2
+const (
+	authHeaderParts = 2
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+	ctxKeyLogin        = "login"
+	ctxKeyRole         = "role"
+	ctxKeyTelegramUser = "telegramUser"
+	ctxKeyExpiresAt    = "expiresAt"
+)
+
+var (
+	errAuthHeaderRequired = errors.New("authorization header required")
+	errAuthHeaderFormat   = errors.New("invalid authorization header format")
+)
+
+type TokenValidator interface {
+	Validate(ctx context.Context, accessToken string) (tokenmanager.Claims, error)
+}
+
+// AuthMiddleware checks the access token and that its role grants at least the required access.
+func AuthMiddleware(tokens TokenValidator, required repository.Role) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		authHeader := ctx.GetHeader("Authorization")
-		if authHeader == "" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
+		tokenString, err := bearerToken(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			ctx.Abort()
 
 			return
 		}
 
-		parts := strings.SplitN(authHeader, " ", authHeaderParts)
-		if len(parts) != authHeaderParts || parts[0] != "Bearer" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
+		claims, err := tokens.Validate(ctx.Request.Context(), tokenString)
+		if err != nil {
+			if errors.Is(err, tokenmanager.ErrInvalidToken) || errors.Is(err, tokenmanager.ErrTokenRevoked) {
+				ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+			}
+
 			ctx.Abort()
 
 			return
 		}
 
-		tokenString := parts[1]
+		if !claims.Role.Allows(required) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			ctx.Abort()
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
+			return
+		}
+
+		ctx.Set(ctxKeyLogin, claims.Login)
+		ctx.Set(ctxKeyRole, claims.Role)
 
-			return []byte(jwtSecret), nil
-		})
+		if claims.ExpiresAt != nil {
+			ctx.Set(ctxKeyExpiresAt, claims.ExpiresAt.Time)
+		}
+
+		actor := trashmanager.Actor{
+			Type:   trashmanager.ActorAdmin,
+			ID:     claims.Login,
+			Name:   claims.Login,
+			Source: trashmanager.SourcePanel,
+		}
+
+		user := slog.String(logging.KeyAdmin, claims.Login)
+
+		if claims.TelegramID != 0 {
+			ctx.Set(ctxKeyTelegramUser, TelegramUser{ID: claims.TelegramID, Username: claims.TelegramUsername})
+
+			actor.Type = trashmanager.ActorTelegram
+			actor.ID = strconv.FormatInt(claims.TelegramID, 10)
+			actor.Name = claims.TelegramUsername
+			user = slog.Int64(logging.KeyUserID, claims.TelegramID)
+		}
+
+		// Изменения через сервис попадут в журнал аудита от имени этого пользователя
+		reqCtx := trashmanager.WithActor(ctx.Request.Context(), actor)
+		ctx.Request = ctx.Request.WithContext(logging.With(reqCtx, user))
+
+		ctx.Next()
+	}
+}
 
-		if err != nil || !token.Valid {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+type RateLimiter interface {
+	Allow(key string) (time.Duration, bool)
+}
+
+// RateLimitMiddleware limits requests per client IP.
+func RateLimitMiddleware(limiter RateLimiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if wait, ok := limiter.Allow(ctx.ClientIP()); !ok {
+			tooManyRequests(ctx, wait)
 			ctx.Abort()
 
 			return
@@ -48,3 +116,56 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+func tooManyRequests(ctx *gin.Context, wait time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+}
+
+// CurrentAdmin returns the login and role set by AuthMiddleware.
+func CurrentAdmin(ctx *gin.Context) (string, repository.Role) {
+	login := ctx.GetString(ctxKeyLogin)
+	role, _ := ctx.Get(ctxKeyRole)
+	typedRole, _ := role.(repository.Role)
+
+	return login, typedRole
+}
+
+// CurrentTelegramUser returns the Telegram user set by AuthMiddleware, if the
+// request was made by one.
+func CurrentTelegramUser(ctx *gin.Context) (TelegramUser, bool) {
+	value, ok := ctx.Get(ctxKeyTelegramUser)
+	if !ok {
+		return TelegramUser{}, false
+	}
+
+	user, ok := value.(TelegramUser)
+
+	return user, ok
+}
+
+// tokenExpiresAt returns when the access token of the request expires, if it does.
+func tokenExpiresAt(ctx *gin.Context) (time.Time, bool) {
+	value, ok := ctx.Get(ctxKeyExpiresAt)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	expiresAt, ok := value.(time.Time)
+
+	return expiresAt, ok
+}
+
+func bearerToken(ctx *gin.Context) (string, error) {
+	authHeader := ctx.GetHeader("Authorization")
+	if authHeader == "" {
+		return "", errAuthHeaderRequired
+	}
+
+	parts := strings.SplitN(authHeader, " ", authHeaderParts)
+	if len(parts) != authHeaderParts || parts[0] != "Bearer" {
+		return "", errAuthHeaderFormat
+	}
+
+	return parts[1], nil
+}
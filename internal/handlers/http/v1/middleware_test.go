@@ -0,0 +1,163 @@
+package apiv1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/6ermvH/trash-bot/internal/services/tokenmanager"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSecret = "test-secret"
+	testKeyID  = "test"
+)
+
+type staticAccounts map[string]repository.Admin
+
+func (a staticAccounts) Admin(_ context.Context, login string) (repository.Admin, error) {
+	admin, ok := a[login]
+	if !ok {
+		return repository.Admin{}, repository.ErrAdminNotFound
+	}
+
+	return admin, nil
+}
+
+func newTestTokens(t *testing.T) *tokenmanager.Service {
+	t.Helper()
+
+	tokens, err := tokenmanager.New(inmemory.New(), staticAccounts{}, tokenmanager.Config{
+		Current: tokenmanager.Key{ID: testKeyID, Secret: testSecret},
+	})
+	require.NoError(t, err)
+
+	return tokens
+}
+
+func issueTestToken(t *testing.T, tokens *tokenmanager.Service, role repository.Role) string {
+	t.Helper()
+
+	pair, err := tokens.Issue(t.Context(), repository.Admin{Login: "a", Role: role})
+	require.NoError(t, err)
+
+	return pair.AccessToken
+}
+
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = testKeyID
+
+	signed, err := token.SignedString([]byte(testSecret))
+	require.NoError(t, err)
+
+	return signed
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	tokens := newTestTokens(t)
+
+	router := gin.New()
+	router.GET("/view", AuthMiddleware(tokens, repository.RoleViewer), func(ctx *gin.Context) {
+		login, role := CurrentAdmin(ctx)
+		ctx.JSON(http.StatusOK, gin.H{"login": login, "role": role})
+	})
+	router.GET("/own", AuthMiddleware(tokens, repository.RoleOwner), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	revoked := issueTestToken(t, tokens, repository.RoleOwner)
+	require.NoError(t, tokens.Logout(t.Context(), revoked, ""))
+
+	testCases := []struct {
+		name         string
+		path         string
+		header       string
+		expectedCode int
+	}{
+		{
+			name:         "No header",
+			path:         "/view",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Wrong scheme",
+			path:         "/view",
+			header:       "Basic abc",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Viewer reads",
+			path:         "/view",
+			header:       "Bearer " + issueTestToken(t, tokens, repository.RoleViewer),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Viewer is not owner",
+			path:         "/own",
+			header:       "Bearer " + issueTestToken(t, tokens, repository.RoleViewer),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Owner passes everything",
+			path:         "/own",
+			header:       "Bearer " + issueTestToken(t, tokens, repository.RoleOwner),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Token without role",
+			path:         "/view",
+			header:       "Bearer " + signTestToken(t, jwt.MapClaims{"login": "a", "jti": "x", "exp": exp}),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Token without jti",
+			path:         "/view",
+			header:       "Bearer " + signTestToken(t, jwt.MapClaims{"login": "a", "role": "owner", "exp": exp}),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Expired token",
+			path:         "/view",
+			header:       "Bearer " + signTestToken(t, jwt.MapClaims{"login": "a", "role": "owner", "jti": "y", "exp": 1}),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Revoked token",
+			path:         "/view",
+			header:       "Bearer " + revoked,
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
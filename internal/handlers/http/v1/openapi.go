@@ -0,0 +1,101 @@
+package apiv1
+
+import (
+	"bytes"
+	_ "embed"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// LoadOpenAPI parses the OpenAPI document of the v1 API.
+func LoadOpenAPI() (*openapi.Document, error) {
+	return openapi.Load(openAPISpec)
+}
+
+// OpenAPI serves the OpenAPI document of the v1 API.
+func OpenAPI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+}
+
+// ResponseErrorHandler is called when a response does not match the document.
+type ResponseErrorHandler func(ctx *gin.Context, err error)
+
+// LogResponseError logs responses that do not match the document.
+func LogResponseError(ctx *gin.Context, err error) {
+	slog.WarnContext(ctx.Request.Context(), "response does not match openapi document",
+		"method", ctx.Request.Method, "route", ctx.FullPath(), logging.Err(err))
+}
+
+// ValidationMiddleware rejects requests that do not match the operation of
+// the route with 400. When onResponseError is not nil, responses are checked
+// too; the response is already sent by then, so mismatches are only reported.
+// Routes missing from the document are passed through.
+func ValidationMiddleware(doc *openapi.Document, onResponseError ResponseErrorHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		op := doc.Operation(ctx.Request.Method, ctx.FullPath())
+		if op == nil {
+			ctx.Next()
+
+			return
+		}
+
+		if err := op.ValidateRequest(ctx.Request, ctx.Param); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			ctx.Abort()
+
+			return
+		}
+
+		if onResponseError == nil {
+			ctx.Next()
+
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+
+		ctx.Next()
+
+		err := op.ValidateResponse(recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		if err != nil {
+			onResponseError(ctx, err)
+		}
+	}
+}
+
+// responseRecorder keeps a copy of JSON response bodies for validation. Other
+// bodies, like database backups, are not kept and so not validated.
+type responseRecorder struct {
+	gin.ResponseWriter
+
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.isJSON() {
+		r.body.Write(data)
+	}
+
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(data string) (int, error) {
+	if r.isJSON() {
+		r.body.WriteString(data)
+	}
+
+	return r.ResponseWriter.WriteString(data)
+}
+
+func (r *responseRecorder) isJSON() bool {
+	return strings.HasPrefix(r.Header().Get("Content-Type"), gin.MIMEJSON)
+}
@@ -0,0 +1,129 @@
+package apiv1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/feed"
+	"github.com/6ermvH/trash-bot/internal/services/stats"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAPI_SchemasMatchTypes keeps the document in sync with the JSON
+// encoding of the types sent and accepted by the handlers.
+func TestOpenAPI_SchemasMatchTypes(t *testing.T) {
+	t.Parallel()
+
+	doc, err := LoadOpenAPI()
+	require.NoError(t, err)
+
+	types := map[string]any{
+		"Chat":                repository.Chat{},
+		"ChatsResponse":       ChatsResponse{},
+		"ChatEvent":           feed.Event{},
+		"DetailedStats":       stats.Detailed{},
+		"MemberDuties":        stats.MemberDuties{},
+		"ChatStats":           stats.ChatStats{},
+		"CommandUsage":        stats.CommandUsage{},
+		"DailyStats":          stats.DailyStats{},
+		"Admin":               repository.Admin{},
+		"AuditEntry":          repository.AuditEntry{},
+		"Stats":               trashmanager.Stats{},
+		"Export":              trashmanager.Export{},
+		"ImportResult":        trashmanager.ImportResult{},
+		"ImportChatError":     trashmanager.ImportChatError{},
+		"LoginRequest":        LoginRequest{},
+		"LoginResponse":       LoginResponse{},
+		"RefreshRequest":      RefreshRequest{},
+		"MembersRequest":      MembersRequest{},
+		"SubscriptionRequest": SubscriptionRequest{},
+		"CreateAdminRequest":  CreateAdminRequest{},
+		"UpdateAdminRequest":  UpdateAdminRequest{},
+		"AuditResponse":       AuditResponse{},
+		"ConfigResponse":      ConfigResponse{},
+	}
+
+	for name, value := range types {
+		schema := doc.Components.Schemas[name]
+		require.NotNil(t, schema, "schema %s", name)
+
+		var properties []string
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+
+		slices.Sort(properties)
+		require.Equal(t, jsonFields(reflect.TypeOf(value)), properties, "schema %s", name)
+	}
+}
+
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+
+	for ind := range typ.NumField() {
+		field := typ.Field(ind)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields = append(fields, name)
+	}
+
+	slices.Sort(fields)
+
+	return fields
+}
+
+func TestValidationMiddleware(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	doc, err := LoadOpenAPI()
+	require.NoError(t, err)
+
+	var responseErrors []error
+
+	router := gin.New()
+	api := router.Group("/api", ValidationMiddleware(doc, func(ctx *gin.Context, err error) {
+		responseErrors = append(responseErrors, err)
+	}))
+	api.PUT("/chats/:id/members", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, repository.Chat{ID: 1, Users: []string{"German"}})
+	})
+	api.GET("/stats", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"totalChats": "one", "totalUsers": 1, "avgUsersPerChat": 1})
+	})
+
+	do := func(method, target, body string) int {
+		req := httptest.NewRequestWithContext(t.Context(), method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/api/chats/1/members", `{"members": ["German"]}`))
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/api/chats/abc/members", `{"members": ["German"]}`))
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/api/chats/1/members", `{"members": []}`))
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/api/chats/1/members?announce=maybe", `{"members": ["German"]}`))
+	require.Empty(t, responseErrors)
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/api/stats", ""))
+	require.Len(t, responseErrors, 1)
+	require.ErrorContains(t, responseErrors[0], "totalChats: must be a number")
+}
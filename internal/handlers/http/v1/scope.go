@@ -0,0 +1,139 @@
+package apiv1
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/gin-gonic/gin"
+)
+
+// TelegramUser is a panel user logged in with the Telegram Login Widget.
+type TelegramUser struct {
+	ID       int64
+	Username string
+}
+
+// ChatAccess decides whether a Telegram user may see and change a chat.
+type ChatAccess interface {
+	CanAccess(ctx context.Context, user TelegramUser, chat repository.Chat) (bool, error)
+}
+
+// WithChatAccess sets the access check for Telegram users. Without it they see no chats.
+func (h *HandlerM) WithChatAccess(access ChatAccess) *HandlerM {
+	h.access = access
+
+	return h
+}
+
+// visibleChats returns the chats the current user may see.
+func (h *HandlerM) visibleChats(ctx *gin.Context, chats []repository.Chat) ([]repository.Chat, error) {
+	user, ok := CurrentTelegramUser(ctx)
+	if !ok {
+		return chats, nil
+	}
+
+	visible := make([]repository.Chat, 0, len(chats))
+
+	for _, chat := range chats {
+		allowed, err := h.canAccess(ctx.Request.Context(), user, chat)
+		if err != nil {
+			return nil, err
+		}
+
+		if allowed {
+			visible = append(visible, chat)
+		}
+	}
+
+	return visible, nil
+}
+
+// findVisibleChats returns a page of the chats the current user may see. Access
+// of Telegram users is checked through the Bot API and cannot be part of the
+// query, so pages are read until the limit is filled with visible chats. The
+// page after the last visible chat may be empty then.
+func (h *HandlerM) findVisibleChats(ctx *gin.Context, query repository.ChatQuery) (trashmanager.ChatPage, error) {
+	if _, ok := CurrentTelegramUser(ctx); !ok {
+		return h.service.FindChats(ctx.Request.Context(), query)
+	}
+
+	chats := make([]repository.Chat, 0, query.Limit)
+
+	for {
+		page, err := h.service.FindChats(ctx.Request.Context(), query)
+		if err != nil {
+			return trashmanager.ChatPage{}, err
+		}
+
+		visible, err := h.visibleChats(ctx, page.Chats)
+		if err != nil {
+			return trashmanager.ChatPage{}, err
+		}
+
+		for _, chat := range visible {
+			chats = append(chats, chat)
+
+			if len(chats) == query.Limit {
+				next := repository.CursorOf(chat)
+
+				return trashmanager.ChatPage{Chats: chats, Next: &next}, nil
+			}
+		}
+
+		if page.Next == nil {
+			return trashmanager.ChatPage{Chats: chats}, nil
+		}
+
+		query.After = page.Next
+	}
+}
+
+// chatID parses the chat id of the route and checks that the current user may
+// access the chat. Chats of other users look like missing ones.
+func (h *HandlerM) chatID(ctx *gin.Context) (int64, bool) {
+	chatID, ok := parseChatID(ctx)
+	if !ok {
+		return 0, false
+	}
+
+	user, ok := CurrentTelegramUser(ctx)
+	if !ok {
+		return chatID, true
+	}
+
+	chat, err := h.service.Chat(ctx.Request.Context(), chatID)
+	if err != nil {
+		writeServiceError(ctx, err, "failed to load chat")
+
+		return 0, false
+	}
+
+	allowed, err := h.canAccess(ctx.Request.Context(), user, *chat)
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "check chat access",
+			logging.KeyUserID, user.ID, logging.KeyChatID, chatID, logging.Err(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check chat access"})
+
+		return 0, false
+	}
+
+	if !allowed {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "chat not found"})
+
+		return 0, false
+	}
+
+	return chatID, true
+}
+
+func (h *HandlerM) canAccess(ctx context.Context, user TelegramUser, chat repository.Chat) (bool, error) {
+	if h.access == nil {
+		return false, nil
+	}
+
+	return h.access.CanAccess(ctx, user, chat)
+}
@@ -0,0 +1,99 @@
+package apiv1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// memberAccess lets users into chats where their username is a member.
+type memberAccess struct{}
+
+func (memberAccess) CanAccess(_ context.Context, user TelegramUser, chat repository.Chat) (bool, error) {
+	return slices.Contains(chat.Users, user.Username), nil
+}
+
+func TestHandlerM_TelegramScope(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	repo := inmemory.New()
+	require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 1, Users: []string{"german", "anthon"}}))
+	require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 2, Users: []string{"vitaly"}}))
+
+	tokens := newTestTokens(t)
+	handle := New(trashmanager.New(repo)).WithChatAccess(memberAccess{})
+
+	router := gin.New()
+	api := router.Group("/", AuthMiddleware(tokens, repository.RoleViewer))
+	api.GET("/stats", handle.Stats)
+	api.GET("/chats", handle.Chats)
+	api.GET("/chats/:id", handle.ChatByID)
+	api.POST("/chats/:id/next", handle.Next)
+
+	pair, _, err := tokens.IssueTelegram(t.Context(), 42, "german")
+	require.NoError(t, err)
+
+	telegramToken := pair.AccessToken
+	adminToken := issueTestToken(t, tokens, repository.RoleOwner)
+
+	request := func(token, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(t.Context(), method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	var chats ChatsResponse
+
+	rec := request(telegramToken, http.MethodGet, "/chats")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &chats))
+	require.Len(t, chats.Chats, 1)
+	require.Equal(t, int64(1), chats.Chats[0].ID)
+
+	// Страница добирается видимыми чатами, даже если первый чат в выборке чужой
+	chats = ChatsResponse{}
+	rec = request(telegramToken, http.MethodGet, "/chats?order=desc&limit=1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &chats))
+	require.Len(t, chats.Chats, 1)
+	require.Equal(t, int64(1), chats.Chats[0].ID)
+
+	chats = ChatsResponse{}
+	rec = request(adminToken, http.MethodGet, "/chats")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &chats))
+	require.Len(t, chats.Chats, 2)
+
+	var stats trashmanager.Stats
+
+	rec = request(telegramToken, http.MethodGet, "/stats")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	require.Equal(t, 1, stats.TotalChats)
+	require.Equal(t, 2, stats.TotalUsers)
+
+	require.Equal(t, http.StatusOK, request(telegramToken, http.MethodGet, "/chats/1").Code)
+	require.Equal(t, http.StatusOK, request(telegramToken, http.MethodPost, "/chats/1/next").Code)
+	require.Equal(t, http.StatusNotFound, request(telegramToken, http.MethodGet, "/chats/2").Code)
+	require.Equal(t, http.StatusNotFound, request(telegramToken, http.MethodPost, "/chats/2/next").Code)
+	require.Equal(t, http.StatusNotFound, request(telegramToken, http.MethodGet, "/chats/3").Code)
+	require.Equal(t, http.StatusOK, request(adminToken, http.MethodGet, "/chats/2").Code)
+
+	chat, err := repo.GetChat(t.Context(), 2)
+	require.NoError(t, err)
+	require.Equal(t, 0, chat.Current, "chat of another user is not changed")
+}
@@ -0,0 +1,67 @@
+package apiv1
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/6ermvH/trash-bot/internal/services/stats"
+	"github.com/gin-gonic/gin"
+)
+
+// DetailedStats builds the statistics of a period from the activity counters.
+type DetailedStats interface {
+	Detailed(ctx context.Context, query stats.Query) (stats.Detailed, error)
+}
+
+// WithStats enables the detailed statistics.
+func (h *HandlerM) WithStats(detailed DetailedStats) *HandlerM {
+	h.stats = detailed
+
+	return h
+}
+
+// DetailedStats returns duties per member and chat, active chats, reminders,
+// command usage and a daily time series of the last days. Telegram users get
+// the statistics of their chats only.
+func (h *HandlerM) DetailedStats(ctx *gin.Context) {
+	query := stats.Query{Days: stats.DefaultDays}
+
+	if raw := ctx.Query("days"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days < 1 || days > stats.MaxDays {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and " + strconv.Itoa(stats.MaxDays)})
+
+			return
+		}
+
+		query.Days = days
+	}
+
+	if _, ok := CurrentTelegramUser(ctx); ok {
+		chats, err := h.service.Chats(ctx.Request.Context())
+		if err == nil {
+			chats, err = h.visibleChats(ctx, chats)
+		}
+
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load stats"})
+
+			return
+		}
+
+		query.ChatIDs = make([]int64, 0, len(chats))
+		for _, chat := range chats {
+			query.ChatIDs = append(query.ChatIDs, chat.ID)
+		}
+	}
+
+	detailed, err := h.stats.Detailed(ctx.Request.Context(), query)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load stats"})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, detailed)
+}
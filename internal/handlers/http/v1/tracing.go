@@ -0,0 +1,61 @@
+package apiv1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/6ermvH/trash-bot/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceMiddleware starts the server span of a request, continuing the trace
+// from the traceparent header when a client or a proxy sends one. Spans are
+// named by the route pattern, so chat ids do not make every name unique.
+func TraceMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		request := ctx.Request
+		parent := otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+
+		route := ctx.FullPath()
+
+		name := request.Method
+		if route != "" {
+			name += " " + route
+		}
+
+		opts := []trace.SpanStartOption{
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(request.Method),
+				semconv.URLPath(request.URL.Path),
+				semconv.HTTPRoute(route),
+			),
+		}
+
+		if strings.HasPrefix(route, "/api/chats/:id") {
+			if chatID, err := strconv.ParseInt(ctx.Param("id"), 10, 64); err == nil {
+				opts = append(opts, trace.WithAttributes(tracing.KeyChatID.Int64(chatID)))
+			}
+		}
+
+		spanCtx, span := tracing.Start(parent, name, opts...)
+		defer span.End()
+
+		ctx.Request = request.WithContext(spanCtx)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package apiv1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/tracing"
+	"github.com/6ermvH/trash-bot/internal/tracing/tracingtest"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//nolint:paralleltest // sets the global tracer provider
+func TestTraceMiddleware(t *testing.T) {
+	recorder := tracingtest.Record(t)
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(TraceMiddleware())
+	router.POST("/api/chats/:id/next", func(ctx *gin.Context) {
+		// Спаны сервисов — дочерние для спана запроса
+		_, span := tracing.Start(ctx.Request.Context(), "trashmanager.Next")
+		span.End()
+
+		ctx.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/chats/-100/next", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Equal(t, []string{"trashmanager.Next", "POST /api/chats/:id/next"}, tracingtest.Names(recorder))
+
+	spans := recorder.Ended()
+	server := spans[1]
+
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", server.SpanContext().TraceID().String(), "the trace of the client continues")
+	require.Equal(t, "b7ad6b7169203331", server.Parent().SpanID().String())
+	require.Equal(t, server.SpanContext().SpanID(), spans[0].Parent().SpanID())
+
+	require.Equal(t, trace.SpanKindServer, server.SpanKind())
+	require.Contains(t, server.Attributes(), semconv.HTTPRoute("/api/chats/:id/next"))
+	require.Contains(t, server.Attributes(), semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+	require.Contains(t, server.Attributes(), tracing.KeyChatID.Int64(-100))
+	require.Equal(t, codes.Error, server.Status().Code)
+}
@@ -0,0 +1,74 @@
+package apiv1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *HandlerM) Export(ctx *gin.Context) {
+	data, err := h.service.Export(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export chats"})
+
+		return
+	}
+
+	filename := "trash-export-" + data.ExportedAt.Format(time.DateOnly) + ".json"
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.JSON(http.StatusOK, data)
+}
+
+// Import accepts an export document in the body. The "mode" query parameter
+// selects merge (default) or replace, "dryRun=true" only validates the input.
+func (h *HandlerM) Import(ctx *gin.Context) {
+	opts := trashmanager.ImportOptions{
+		Mode: trashmanager.ImportMode(ctx.DefaultQuery("mode", string(trashmanager.ImportModeMerge))),
+	}
+
+	if dryRun := ctx.Query("dryRun"); dryRun != "" {
+		parsed, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid dryRun value"})
+
+			return
+		}
+
+		opts.DryRun = parsed
+	}
+
+	var data trashmanager.Export
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+
+		return
+	}
+
+	result, err := h.service.Import(ctx.Request.Context(), data, opts)
+
+	switch {
+	case err == nil:
+		break
+	case errors.Is(err, trashmanager.ErrUnsupportedExportVersion),
+		errors.Is(err, trashmanager.ErrUnknownImportMode):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import chats"})
+
+		return
+	}
+
+	if len(result.Errors) > 0 {
+		ctx.JSON(http.StatusUnprocessableEntity, result)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
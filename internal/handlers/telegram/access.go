@@ -0,0 +1,162 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "github.com/6ermvH/trash-bot/internal/handlers/http/v1"
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const memberCacheTTL = 5 * time.Minute
+
+// ChatMemberGetter is the part of the bot API used to find chat members.
+type ChatMemberGetter interface {
+	GetChatMember(ctx context.Context, params *bot.GetChatMemberParams) (*models.ChatMember, error)
+}
+
+// ChatAccess lets a Telegram user into the chats where they are an admin or a
+// rotation member. The status in the chat is checked by the user ID with
+// getChatMember. Rotation members are names typed by hand in /set, so such a
+// name only lets in a chat member whose verified username matches it.
+type ChatAccess struct {
+	botAPI ChatMemberGetter
+
+	mu      sync.Mutex
+	members map[memberKey]memberEntry
+	now     func() time.Time
+}
+
+type memberKey struct {
+	chatID int64
+	userID int64
+}
+
+// memberStatus is the part of the chat member status that decides access.
+type memberStatus int
+
+const (
+	statusNotInChat memberStatus = iota
+	statusMember
+	statusAdmin
+)
+
+type memberEntry struct {
+	status    memberStatus
+	checkedAt time.Time
+}
+
+func NewChatAccess(botAPI ChatMemberGetter) *ChatAccess {
+	return &ChatAccess{
+		botAPI:  botAPI,
+		members: make(map[memberKey]memberEntry),
+		now:     time.Now,
+	}
+}
+
+func (a *ChatAccess) CanAccess(ctx context.Context, user apiv1.TelegramUser, chat repository.Chat) (bool, error) {
+	status, err := a.memberStatus(ctx, chat.ID, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	switch status {
+	case statusAdmin:
+		return true, nil
+	case statusMember:
+		return inRotation(user.Username, chat.Users), nil
+	default:
+		return false, nil
+	}
+}
+
+// inRotation reports whether the Telegram username is one of the rotation
+// names, which are often written with @ and in any case.
+func inRotation(username string, users []string) bool {
+	if username == "" {
+		return false
+	}
+
+	for _, user := range users {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(user), "@"), username) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// statusOf maps the chat member to the status that decides access.
+func statusOf(member *models.ChatMember) memberStatus {
+	switch member.Type {
+	case models.ChatMemberTypeOwner, models.ChatMemberTypeAdministrator:
+		return statusAdmin
+	case models.ChatMemberTypeMember:
+		return statusMember
+	case models.ChatMemberTypeRestricted:
+		if member.Restricted != nil && member.Restricted.IsMember {
+			return statusMember
+		}
+
+		return statusNotInChat
+	default:
+		return statusNotInChat
+	}
+}
+
+// notInChat reports whether the error of getChatMember is a definite answer
+// that the user is not in the chat: the user or the chat is unknown, or the
+// bot was removed from the chat.
+func notInChat(err error) bool {
+	return errors.Is(err, bot.ErrorForbidden) ||
+		errors.Is(err, bot.ErrorBadRequest) && strings.Contains(strings.ToLower(err.Error()), "not found")
+}
+
+func (a *ChatAccess) memberStatus(ctx context.Context, chatID, userID int64) (memberStatus, error) {
+	key := memberKey{chatID: chatID, userID: userID}
+
+	a.mu.Lock()
+	entry, ok := a.members[key]
+	a.mu.Unlock()
+
+	if ok && a.now().Sub(entry.checkedAt) < memberCacheTTL {
+		return entry.status, nil
+	}
+
+	status := statusNotInChat
+
+	member, err := a.botAPI.GetChatMember(ctx, &bot.GetChatMemberParams{ChatID: chatID, UserID: userID})
+
+	switch {
+	case err == nil:
+		status = statusOf(member)
+	case notInChat(err):
+		slog.DebugContext(ctx, "not a chat member", logging.KeyUserID, userID, logging.KeyChatID, chatID, logging.Err(err))
+	default:
+		// Сбой сети или ответ 429/5xx ничего не говорит о членстве и не кешируется
+		return statusNotInChat, fmt.Errorf("get chat member: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+
+	for cached, entry := range a.members {
+		if now.Sub(entry.checkedAt) >= memberCacheTTL {
+			delete(a.members, cached)
+		}
+	}
+
+	a.members[key] = memberEntry{status: status, checkedAt: now}
+
+	return status, nil
+}
@@ -0,0 +1,119 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	apiv1 "github.com/6ermvH/trash-bot/internal/handlers/http/v1"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/require"
+)
+
+type memberGetterStub struct {
+	calls   int
+	members map[int64]models.ChatMember
+	errs    map[int64]error
+}
+
+func (s *memberGetterStub) GetChatMember(
+	_ context.Context,
+	params *bot.GetChatMemberParams,
+) (*models.ChatMember, error) {
+	s.calls++
+
+	if err, ok := s.errs[params.UserID]; ok {
+		return nil, err
+	}
+
+	member, ok := s.members[params.UserID]
+	if !ok {
+		return nil, fmt.Errorf("%w, Bad Request: user not found", bot.ErrorBadRequest)
+	}
+
+	return &member, nil
+}
+
+func TestChatAccess(t *testing.T) {
+	t.Parallel()
+
+	getter := &memberGetterStub{members: map[int64]models.ChatMember{
+		1: {Type: models.ChatMemberTypeOwner},
+		2: {Type: models.ChatMemberTypeAdministrator},
+		3: {Type: models.ChatMemberTypeMember},
+		4: {Type: models.ChatMemberTypeMember},
+		5: {Type: models.ChatMemberTypeRestricted, Restricted: &models.ChatMemberRestricted{IsMember: true}},
+		6: {Type: models.ChatMemberTypeRestricted, Restricted: &models.ChatMemberRestricted{IsMember: false}},
+		7: {Type: models.ChatMemberTypeLeft},
+		8: {Type: models.ChatMemberTypeBanned},
+	}}
+	access := NewChatAccess(getter)
+	chat := repository.Chat{ID: 100, Users: []string{"@German", "vitaly", "Anthon"}}
+
+	testCases := []struct {
+		name     string
+		user     apiv1.TelegramUser
+		expected bool
+	}{
+		{name: "Chat owner", user: apiv1.TelegramUser{ID: 1}, expected: true},
+		{name: "Chat admin outside the rotation", user: apiv1.TelegramUser{ID: 2, Username: "oleg"}, expected: true},
+		{name: "Rotation member", user: apiv1.TelegramUser{ID: 3, Username: "german"}, expected: true},
+		{name: "Chat member outside the rotation", user: apiv1.TelegramUser{ID: 4, Username: "ivan"}, expected: false},
+		{name: "Restricted rotation member", user: apiv1.TelegramUser{ID: 5, Username: "Vitaly"}, expected: true},
+		{name: "Restricted non-member", user: apiv1.TelegramUser{ID: 6, Username: "anthon"}, expected: false},
+		{name: "Left the chat", user: apiv1.TelegramUser{ID: 7, Username: "anthon"}, expected: false},
+		{name: "Banned", user: apiv1.TelegramUser{ID: 8, Username: "anthon"}, expected: false},
+		// Имя из /set не подтверждает аккаунт: такой username может взять кто угодно вне чата
+		{name: "Rotation name outside the chat", user: apiv1.TelegramUser{ID: 9, Username: "german"}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		allowed, err := access.CanAccess(t.Context(), tc.user, chat)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, allowed, tc.name)
+	}
+
+	calls := getter.calls
+
+	allowed, err := access.CanAccess(t.Context(), apiv1.TelegramUser{ID: 2}, chat)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	require.Equal(t, calls, getter.calls, "membership is cached")
+}
+
+func TestChatAccess_Errors(t *testing.T) {
+	t.Parallel()
+
+	getter := &memberGetterStub{
+		members: map[int64]models.ChatMember{1: {Type: models.ChatMemberTypeAdministrator}},
+		errs: map[int64]error{
+			1: &bot.TooManyRequestsError{Message: "too many requests", RetryAfter: 5},
+			2: fmt.Errorf("%w, Forbidden: bot was kicked from the group chat", bot.ErrorForbidden),
+		},
+	}
+	access := NewChatAccess(getter)
+	chat := repository.Chat{ID: 100}
+
+	// Временная ошибка возвращается и не кешируется
+	_, err := access.CanAccess(t.Context(), apiv1.TelegramUser{ID: 1}, chat)
+	require.Error(t, err)
+
+	delete(getter.errs, 1)
+
+	allowed, err := access.CanAccess(t.Context(), apiv1.TelegramUser{ID: 1}, chat)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Бота удалили из чата: это определённый ответ, он кешируется
+	allowed, err = access.CanAccess(t.Context(), apiv1.TelegramUser{ID: 2}, chat)
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	calls := getter.calls
+
+	_, err = access.CanAccess(t.Context(), apiv1.TelegramUser{ID: 2}, chat)
+	require.NoError(t, err)
+	require.Equal(t, calls, getter.calls)
+}
@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// ActorMiddleware sets the sender of the update as the actor of changes made
+// while handling it, so that they are attributed in the audit log. It also
+// covers inline keyboard callbacks, whose handlers do not see the sender.
+func ActorMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, botAPI *bot.Bot, update *models.Update) {
+		if user := updateSender(update); user != nil {
+			ctx = trashmanager.WithActor(ctx, actorOf(user))
+		}
+
+		next(ctx, botAPI, update)
+	}
+}
+
+func updateSender(update *models.Update) *models.User {
+	switch {
+	case update.Message != nil:
+		return update.Message.From
+	case update.CallbackQuery != nil:
+		return &update.CallbackQuery.From
+	default:
+		return nil
+	}
+}
+
+func actorOf(user *models.User) trashmanager.Actor {
+	name := user.Username
+	if name == "" {
+		name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+
+	return trashmanager.Actor{
+		Type:   trashmanager.ActorTelegram,
+		ID:     strconv.FormatInt(user.ID, 10),
+		Name:   name,
+		Source: trashmanager.SourceBot,
+	}
+}
@@ -0,0 +1,62 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/go-telegram/bot"
+)
+
+// Announcer posts changes made outside of the chat (e.g. from the admin panel)
+// to the affected chat.
+type Announcer struct {
+	botAPI *bot.Bot
+}
+
+func NewAnnouncer(botAPI *bot.Bot) *Announcer {
+	return &Announcer{botAPI: botAPI}
+}
+
+func (a *Announcer) Handle(ctx context.Context, event trashmanager.Event) {
+	meta := event.Meta()
+	if !meta.Announce {
+		return
+	}
+
+	text, ok := announcementText(event)
+	if !ok {
+		return
+	}
+
+	if _, err := a.botAPI.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: meta.ChatID,
+		Text:   text,
+	}); err != nil {
+		slog.ErrorContext(ctx, "send announcement", logging.KeyChatID, meta.ChatID, logging.Err(err))
+	}
+}
+
+func announcementText(event trashmanager.Event) (string, bool) {
+	switch event := event.(type) {
+	case trashmanager.RotationAdvanced:
+		return "Админ изменил очередь: сейчас выносит " + event.Current, true
+	case trashmanager.RotationReverted:
+		return "Админ изменил очередь: сейчас выносит " + event.Current, true
+	case trashmanager.MembersChanged:
+		if len(event.Users) == 0 {
+			return "Админ очистил список дежурных", true
+		}
+
+		return "Админ обновил список дежурных: " + strings.Join(event.Users, ", ") +
+			". Сейчас выносит " + event.Users[0], true
+	case trashmanager.Subscribed:
+		return "Админ включил ежедневное напоминание в " + event.NotifyTime, true
+	case trashmanager.Unsubscribed:
+		return "Админ отключил ежедневные напоминания", true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnouncementText(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		event    trashmanager.Event
+		expected string
+	}{
+		{
+			name:     "Next",
+			event:    trashmanager.RotationAdvanced{Current: "German"},
+			expected: "Админ изменил очередь: сейчас выносит German",
+		},
+		{
+			name:     "Prev",
+			event:    trashmanager.RotationReverted{Current: "Anthon"},
+			expected: "Админ изменил очередь: сейчас выносит Anthon",
+		},
+		{
+			name:     "Members",
+			event:    trashmanager.MembersChanged{Users: []string{"German", "Anthon"}},
+			expected: "Админ обновил список дежурных: German, Anthon. Сейчас выносит German",
+		},
+		{
+			name:     "Subscribe",
+			event:    trashmanager.Subscribed{NotifyTime: "09:00"},
+			expected: "Админ включил ежедневное напоминание в 09:00",
+		},
+		{
+			name:     "Unsubscribe",
+			event:    trashmanager.Unsubscribed{},
+			expected: "Админ отключил ежедневные напоминания",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			text, ok := announcementText(tc.event)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, text)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Drainer tracks work started before shutdown, like handled updates and sent
+// reminders, so that it is finished rather than cut off. The tracked work is
+// not cancelled with the context it was started with, only when Wait gives up.
+type Drainer struct {
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	closed bool
+	abort  context.Context //nolint:containedctx // cancels tracked work when draining times out
+	cancel context.CancelFunc
+}
+
+func NewDrainer() *Drainer {
+	abort, cancel := context.WithCancel(context.Background())
+
+	return &Drainer{abort: abort, cancel: cancel}
+}
+
+// Track registers work and returns the context to do it with, it keeps the
+// values of ctx. done must be called when the work is finished. After Wait was
+// called no work is accepted and ok is false.
+func (d *Drainer) Track(ctx context.Context) (context.Context, func(), bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return ctx, func() {}, false
+	}
+
+	d.wg.Add(1)
+
+	work, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(d.abort, cancel)
+
+	return work, func() {
+		stop()
+		cancel()
+		d.wg.Done()
+	}, true
+}
+
+// Middleware tracks every handled update. The bot must be created with
+// bot.WithNotAsyncHandlers: the update is then tracked before the worker of
+// the bot takes the next one, and the handler runs in its own goroutine.
+func (d *Drainer) Middleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, botAPI *bot.Bot, update *models.Update) {
+		work, done, ok := d.Track(ctx)
+		if !ok {
+			slog.WarnContext(ctx, "telegram update dropped on shutdown", logging.KeyUpdateID, update.ID)
+
+			return
+		}
+
+		go func() {
+			defer done()
+
+			next(work, botAPI, update)
+		}()
+	}
+}
+
+// Wait stops accepting work and waits until the tracked work is finished.
+// When ctx is done first, the remaining work is cancelled and an error is returned.
+func (d *Drainer) Wait(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	finished := make(chan struct{})
+
+	go func() {
+		d.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+
+		return fmt.Errorf("wait for in-flight work: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Waits for in-flight work", func(t *testing.T) {
+		t.Parallel()
+
+		drainer := NewDrainer()
+		started := make(chan struct{})
+		release := make(chan struct{})
+		handled := make(chan error, 1)
+
+		handler := drainer.Middleware(func(ctx context.Context, _ *bot.Bot, _ *models.Update) {
+			close(started)
+			<-release
+
+			// Контекст бота уже отменён, но обработчик должен успеть ответить
+			handled <- ctx.Err()
+		})
+
+		ctx, cancel := context.WithCancel(t.Context())
+		handler(ctx, nil, &models.Update{ID: 1})
+		<-started
+		cancel()
+
+		waited := make(chan error, 1)
+
+		go func() {
+			waited <- drainer.Wait(t.Context())
+		}()
+
+		select {
+		case <-waited:
+			t.Fatal("Wait returned before the handler finished")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		require.NoError(t, <-handled)
+		require.NoError(t, <-waited)
+
+		// После Wait новые обновления не принимаются
+		handler(t.Context(), nil, &models.Update{ID: 2})
+
+		_, _, ok := drainer.Track(t.Context())
+		require.False(t, ok)
+	})
+
+	t.Run("Cancels work on timeout", func(t *testing.T) {
+		t.Parallel()
+
+		drainer := NewDrainer()
+
+		work, done, ok := drainer.Track(t.Context())
+		require.True(t, ok)
+
+		defer done()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		require.ErrorIs(t, drainer.Wait(ctx), context.DeadlineExceeded)
+
+		select {
+		case <-work.Done():
+		case <-time.After(time.Second):
+			t.Fatal("tracked work was not cancelled")
+		}
+	})
+}
@@ -3,8 +3,9 @@ package telegram
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 
+	"github.com/6ermvH/trash-bot/internal/logging"
 	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
 	"github.com/go-telegram/bot"
 )
@@ -24,18 +25,18 @@ func (t *TgBotHandler) sendServiceError(
 	botApi *bot.Bot,
 	chatID int64,
 	err error,
-	logPrefix string,
 ) {
 	if err == nil {
 		return
 	}
 
-	log.Printf("%s: %v", logPrefix, err)
-	t.sendMessage(
-		ctx,
-		botApi,
-		chatID,
-		userErrorMessage(err),
-		logPrefix+" send message error",
-	)
+	message := userErrorMessage(err)
+
+	// Ошибки пользователя ожидаемы, как сбои пишем только остальные
+	if message == err.Error() {
+		slog.InfoContext(ctx, "user error", logging.Err(err))
+	} else {
+		slog.ErrorContext(ctx, "handle update", logging.Err(err))
+	}
+	t.sendMessage(ctx, botApi, chatID, message)
 }
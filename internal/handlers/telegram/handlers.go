@@ -2,9 +2,12 @@ package telegram
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 )
@@ -18,10 +21,12 @@ type Service interface {
 	SetEstablish(ctx context.Context, chatID int64, users []string) error
 	Subscribe(ctx context.Context, chatID int64, notifyTime string) error
 	Unsubscribe(ctx context.Context, chatID int64) error
+	DutyReport(ctx context.Context, chatID int64, since time.Time) (trashmanager.DutyReport, error)
 }
 
 type TgBotHandler struct {
-	service Service
+	service   Service
+	callbacks CallbackRecorder
 }
 
 func New(service Service) *TgBotHandler {
@@ -30,6 +35,13 @@ func New(service Service) *TgBotHandler {
 	}
 }
 
+// WithCallbackRecorder sets where pressed inline keyboard buttons are counted.
+func (t *TgBotHandler) WithCallbackRecorder(recorder CallbackRecorder) *TgBotHandler {
+	t.callbacks = recorder
+
+	return t
+}
+
 func (t *TgBotHandler) Start(ctx context.Context, botApi *bot.Bot, update *models.Update) {
 	_, err := botApi.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      update.Message.Chat.ID,
@@ -37,7 +49,7 @@ func (t *TgBotHandler) Start(ctx context.Context, botApi *bot.Bot, update *model
 		ReplyMarkup: t.getKeyboardOnStart(botApi),
 	})
 	if err != nil {
-		log.Printf("Start. send message: %v", err)
+		slog.ErrorContext(ctx, "send message", logging.Err(err))
 	}
 }
 
@@ -46,13 +58,7 @@ func (t *TgBotHandler) SetEstablish(ctx context.Context, botApi *bot.Bot, update
 	parts := strings.Fields(update.Message.Text)
 
 	if len(parts) < minSetParts {
-		t.sendMessage(
-			ctx,
-			botApi,
-			chatID,
-			"Provide at least one username after /set",
-			"SetEstablish send message error",
-		)
+		t.sendMessage(ctx, botApi, chatID, "Provide at least one username after /set")
 
 		return
 	}
@@ -60,7 +66,7 @@ func (t *TgBotHandler) SetEstablish(ctx context.Context, botApi *bot.Bot, update
 	users := parts[1:]
 
 	if err := t.service.SetEstablish(ctx, chatID, users); err != nil {
-		t.sendServiceError(ctx, botApi, chatID, err, "SetEstablish")
+		t.sendServiceError(ctx, botApi, chatID, err)
 
 		return
 	}
@@ -70,7 +76,7 @@ func (t *TgBotHandler) SetEstablish(ctx context.Context, botApi *bot.Bot, update
 		Text:   "Establish success",
 	})
 	if err != nil {
-		log.Printf("SetEstablish. send message: %v", err)
+		slog.ErrorContext(ctx, "send message", logging.Err(err))
 	}
 }
 
@@ -79,7 +85,7 @@ func (t *TgBotHandler) Next(ctx context.Context, botApi *bot.Bot, update *models
 
 	username, err := t.service.Next(ctx, chatID)
 	if err != nil {
-		t.sendServiceError(ctx, botApi, chatID, err, "Next")
+		t.sendServiceError(ctx, botApi, chatID, err)
 
 		return
 	}
@@ -88,7 +94,7 @@ func (t *TgBotHandler) Next(ctx context.Context, botApi *bot.Bot, update *models
 		ChatID: update.Message.Chat.ID,
 		Text:   username,
 	}); err != nil {
-		log.Printf("Next. send message: %v", err)
+		slog.ErrorContext(ctx, "send message", logging.Err(err))
 	}
 }
 
@@ -97,7 +103,7 @@ func (t *TgBotHandler) Prev(ctx context.Context, botApi *bot.Bot, update *models
 
 	username, err := t.service.Prev(ctx, chatID)
 	if err != nil {
-		t.sendServiceError(ctx, botApi, chatID, err, "Prev")
+		t.sendServiceError(ctx, botApi, chatID, err)
 
 		return
 	}
@@ -106,7 +112,7 @@ func (t *TgBotHandler) Prev(ctx context.Context, botApi *bot.Bot, update *models
 		ChatID: update.Message.Chat.ID,
 		Text:   username,
 	}); err != nil {
-		log.Printf("Prev. send message: %v", err)
+		slog.ErrorContext(ctx, "send message", logging.Err(err))
 	}
 }
 
@@ -115,7 +121,7 @@ func (t *TgBotHandler) Who(ctx context.Context, botApi *bot.Bot, update *models.
 
 	username, err := t.service.Who(ctx, chatID)
 	if err != nil {
-		t.sendServiceError(ctx, botApi, chatID, err, "Who")
+		t.sendServiceError(ctx, botApi, chatID, err)
 
 		return
 	}
@@ -124,7 +130,7 @@ func (t *TgBotHandler) Who(ctx context.Context, botApi *bot.Bot, update *models.
 		ChatID: update.Message.Chat.ID,
 		Text:   username,
 	}); err != nil {
-		log.Printf("Who. send message: %v", err)
+		slog.ErrorContext(ctx, "send message", logging.Err(err))
 	}
 }
 
@@ -137,7 +143,7 @@ func (t *TgBotHandler) Subscribe(ctx context.Context, botApi *bot.Bot, update *m
 		ReplyMarkup: t.getTimeSelectionKeyboard(botApi),
 	})
 	if err != nil {
-		log.Printf("Subscribe. send message: %v", err)
+		slog.ErrorContext(ctx, "send message", logging.Err(err))
 	}
 }
 
@@ -145,16 +151,10 @@ func (t *TgBotHandler) Unsubscribe(ctx context.Context, botApi *bot.Bot, update
 	chatID := update.Message.Chat.ID
 
 	if err := t.service.Unsubscribe(ctx, chatID); err != nil {
-		t.sendServiceError(ctx, botApi, chatID, err, "Unsubscribe")
+		t.sendServiceError(ctx, botApi, chatID, err)
 
 		return
 	}
 
-	t.sendMessage(
-		ctx,
-		botApi,
-		chatID,
-		"Вы отписались от ежедневных напоминаний",
-		"Unsubscribe send message error",
-	)
+	t.sendMessage(ctx, botApi, chatID, "Вы отписались от ежедневных напоминаний")
 }
@@ -2,8 +2,9 @@ package telegram
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"github.com/6ermvH/trash-bot/internal/logging"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/go-telegram/ui/keyboard/inline"
@@ -11,11 +12,11 @@ import (
 
 func (t *TgBotHandler) getKeyboardOnStart(botApi *bot.Bot) *inline.Keyboard {
 	keyboard := inline.New(botApi).Row().
-		Button("Кто выносит", []byte(""), t.handleWho).
+		Button("Кто выносит", []byte(""), t.counted("who", t.handleWho)).
 		Row().
-		Button("Следующий", []byte(""), t.handleNext).
+		Button("Следующий", []byte(""), t.counted("next", t.handleNext)).
 		Row().
-		Button("Предыдущий", []byte(""), t.handlePrev)
+		Button("Предыдущий", []byte(""), t.counted("prev", t.handlePrev))
 
 	return keyboard
 }
@@ -28,18 +29,12 @@ func (t *TgBotHandler) handleWho(
 ) {
 	username, err := t.service.Who(ctx, mes.Message.Chat.ID)
 	if err != nil {
-		t.sendServiceError(ctx, botApi, mes.Message.Chat.ID, err, "Inline Who")
+		t.sendServiceError(ctx, botApi, mes.Message.Chat.ID, err)
 
 		return
 	}
 
-	t.sendMessage(
-		ctx,
-		botApi,
-		mes.Message.Chat.ID,
-		"Мусор выносит: "+username,
-		"Handler Who send message error",
-	)
+	t.sendMessage(ctx, botApi, mes.Message.Chat.ID, "Мусор выносит: "+username)
 }
 
 func (t *TgBotHandler) handleNext(
@@ -50,18 +45,12 @@ func (t *TgBotHandler) handleNext(
 ) {
 	username, err := t.service.Next(ctx, mes.Message.Chat.ID)
 	if err != nil {
-		t.sendServiceError(ctx, botApi, mes.Message.Chat.ID, err, "Inline Next")
+		t.sendServiceError(ctx, botApi, mes.Message.Chat.ID, err)
 
 		return
 	}
 
-	t.sendMessage(
-		ctx,
-		botApi,
-		mes.Message.Chat.ID,
-		"Мусор выносит: "+username,
-		"Handler Next send message error",
-	)
+	t.sendMessage(ctx, botApi, mes.Message.Chat.ID, "Мусор выносит: "+username)
 }
 
 func (t *TgBotHandler) handlePrev(
@@ -72,18 +61,23 @@ func (t *TgBotHandler) handlePrev(
 ) {
 	username, err := t.service.Prev(ctx, mes.Message.Chat.ID)
 	if err != nil {
-		t.sendServiceError(ctx, botApi, mes.Message.Chat.ID, err, "Inline Prev")
+		t.sendServiceError(ctx, botApi, mes.Message.Chat.ID, err)
 
 		return
 	}
 
-	t.sendMessage(
-		ctx,
-		botApi,
-		mes.Message.Chat.ID,
-		"Мусор выносит: "+username,
-		"Handler Prev send message error",
-	)
+	t.sendMessage(ctx, botApi, mes.Message.Chat.ID, "Мусор выносит: "+username)
+}
+
+// counted makes the button handler count presses as the action.
+func (t *TgBotHandler) counted(action string, handler inline.OnSelect) inline.OnSelect {
+	return func(ctx context.Context, botApi *bot.Bot, mes models.MaybeInaccessibleMessage, data []byte) {
+		if t.callbacks != nil && mes.Message != nil {
+			t.callbacks.CallbackPressed(ctx, mes.Message.Chat.ID, action)
+		}
+
+		handler(ctx, botApi, mes, data)
+	}
 }
 
 func (t *TgBotHandler) sendMessage(
@@ -91,41 +85,42 @@ func (t *TgBotHandler) sendMessage(
 	botApi *bot.Bot,
 	chatID int64,
 	text string,
-	errPrefix string,
 ) {
 	if _, err := botApi.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: chatID,
 		Text:   text,
 	}); err != nil {
-		log.Printf("%s: %v", errPrefix, err.Error())
+		slog.ErrorContext(ctx, "send message", logging.KeyChatID, chatID, logging.Err(err))
 	}
 }
 
 func (t *TgBotHandler) getTimeSelectionKeyboard(botApi *bot.Bot) *inline.Keyboard {
+	timeSelection := t.counted("subscribe", t.handleTimeSelection)
+
 	keyboard := inline.New(botApi).
 		Row().
-		Button("06:00", []byte("06:00"), t.handleTimeSelection).
-		Button("07:00", []byte("07:00"), t.handleTimeSelection).
-		Button("08:00", []byte("08:00"), t.handleTimeSelection).
-		Button("09:00", []byte("09:00"), t.handleTimeSelection).
+		Button("06:00", []byte("06:00"), timeSelection).
+		Button("07:00", []byte("07:00"), timeSelection).
+		Button("08:00", []byte("08:00"), timeSelection).
+		Button("09:00", []byte("09:00"), timeSelection).
 		Row().
-		Button("10:00", []byte("10:00"), t.handleTimeSelection).
-		Button("11:00", []byte("11:00"), t.handleTimeSelection).
-		Button("12:00", []byte("12:00"), t.handleTimeSelection).
-		Button("13:00", []byte("13:00"), t.handleTimeSelection).
+		Button("10:00", []byte("10:00"), timeSelection).
+		Button("11:00", []byte("11:00"), timeSelection).
+		Button("12:00", []byte("12:00"), timeSelection).
+		Button("13:00", []byte("13:00"), timeSelection).
 		Row().
-		Button("14:00", []byte("14:00"), t.handleTimeSelection).
-		Button("15:00", []byte("15:00"), t.handleTimeSelection).
-		Button("16:00", []byte("16:00"), t.handleTimeSelection).
-		Button("17:00", []byte("17:00"), t.handleTimeSelection).
+		Button("14:00", []byte("14:00"), timeSelection).
+		Button("15:00", []byte("15:00"), timeSelection).
+		Button("16:00", []byte("16:00"), timeSelection).
+		Button("17:00", []byte("17:00"), timeSelection).
 		Row().
-		Button("18:00", []byte("18:00"), t.handleTimeSelection).
-		Button("19:00", []byte("19:00"), t.handleTimeSelection).
-		Button("20:00", []byte("20:00"), t.handleTimeSelection).
-		Button("21:00", []byte("21:00"), t.handleTimeSelection).
+		Button("18:00", []byte("18:00"), timeSelection).
+		Button("19:00", []byte("19:00"), timeSelection).
+		Button("20:00", []byte("20:00"), timeSelection).
+		Button("21:00", []byte("21:00"), timeSelection).
 		Row().
-		Button("22:00", []byte("22:00"), t.handleTimeSelection).
-		Button("23:00", []byte("23:00"), t.handleTimeSelection)
+		Button("22:00", []byte("22:00"), timeSelection).
+		Button("23:00", []byte("23:00"), timeSelection)
 
 	return keyboard
 }
@@ -140,7 +135,7 @@ func (t *TgBotHandler) handleTimeSelection(
 	selectedTime := string(data)
 
 	if err := t.service.Subscribe(ctx, chatID, selectedTime); err != nil {
-		t.sendServiceError(ctx, botApi, chatID, err, "TimeSelection Subscribe")
+		t.sendServiceError(ctx, botApi, chatID, err)
 
 		return
 	}
@@ -151,11 +146,5 @@ func (t *TgBotHandler) handleTimeSelection(
 		MessageID: mes.Message.ID,
 	})
 
-	t.sendMessage(
-		ctx,
-		botApi,
-		chatID,
-		"✅ Вы подписались на ежедневные напоминания в "+selectedTime,
-		"TimeSelection send message error",
-	)
+	t.sendMessage(ctx, botApi, chatID, "✅ Вы подписались на ежедневные напоминания в "+selectedTime)
 }
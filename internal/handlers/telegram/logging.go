@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// LogMiddleware sets the update, the chat, the sender and the command on the
+// context, so that records logged while handling the update are correlated.
+func LogMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, botAPI *bot.Bot, update *models.Update) {
+		start := time.Now()
+		ctx = logging.With(ctx, updateAttrs(update)...)
+
+		next(ctx, botAPI, update)
+
+		slog.DebugContext(ctx, "telegram update handled", "duration", time.Since(start))
+	}
+}
+
+func updateAttrs(update *models.Update) []slog.Attr {
+	attrs := []slog.Attr{slog.Int64(logging.KeyUpdateID, update.ID)}
+
+	if user := updateSender(update); user != nil {
+		attrs = append(attrs, slog.Int64(logging.KeyUserID, user.ID))
+	}
+
+	switch {
+	case update.Message != nil:
+		attrs = append(attrs, slog.Int64(logging.KeyChatID, update.Message.Chat.ID))
+
+		if command := commandOf(update.Message); command != "" {
+			attrs = append(attrs, slog.String(logging.KeyCommand, command))
+		}
+	case update.CallbackQuery != nil && update.CallbackQuery.Message.Message != nil:
+		attrs = append(attrs, slog.Int64(logging.KeyChatID, update.CallbackQuery.Message.Message.Chat.ID))
+	}
+
+	return attrs
+}
@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateAttrs(t *testing.T) {
+	t.Parallel()
+
+	message := &models.Update{
+		ID: 7,
+		Message: &models.Message{
+			Text: "/next@trash_bot",
+			Chat: models.Chat{ID: -100},
+			From: &models.User{ID: 42},
+		},
+	}
+
+	require.Equal(t, []slog.Attr{
+		slog.Int64(logging.KeyUpdateID, 7),
+		slog.Int64(logging.KeyUserID, 42),
+		slog.Int64(logging.KeyChatID, -100),
+		slog.String(logging.KeyCommand, "next"),
+	}, updateAttrs(message))
+
+	callback := &models.Update{
+		ID: 8,
+		CallbackQuery: &models.CallbackQuery{
+			From:    models.User{ID: 42},
+			Message: models.MaybeInaccessibleMessage{Message: &models.Message{Chat: models.Chat{ID: -100}}},
+		},
+	}
+
+	require.Equal(t, []slog.Attr{
+		slog.Int64(logging.KeyUpdateID, 8),
+		slog.Int64(logging.KeyUserID, 42),
+		slog.Int64(logging.KeyChatID, -100),
+	}, updateAttrs(callback))
+}
@@ -0,0 +1,29 @@
+package telegram
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-telegram/bot"
+)
+
+// pollClient calls onPoll after every successful getUpdates request of the bot.
+type pollClient struct {
+	client bot.HttpClient
+	onPoll func()
+}
+
+// ObservePolling wraps the HTTP client of the bot to report that polling for
+// updates works. Telegram answers 200 only to successful requests.
+func ObservePolling(client bot.HttpClient, onPoll func()) bot.HttpClient {
+	return &pollClient{client: client, onPoll: onPoll}
+}
+
+func (c *pollClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err == nil && resp.StatusCode == http.StatusOK && strings.HasSuffix(req.URL.Path, "/getUpdates") {
+		c.onPoll()
+	}
+
+	return resp, err //nolint:wrapcheck // the bot handles errors of its client
+}
@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-telegram/bot"
+	"github.com/stretchr/testify/require"
+)
+
+type clientStub struct {
+	status int
+}
+
+func (c clientStub) Do(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: c.status, Body: http.NoBody}, nil
+}
+
+func TestObservePolling(t *testing.T) {
+	t.Parallel()
+
+	polls := 0
+	onPoll := func() { polls++ }
+
+	request := func(method string) *http.Request {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "https://api.telegram.org/bot1:x/"+method, nil)
+		require.NoError(t, err)
+
+		return req
+	}
+
+	ok := ObservePolling(clientStub{status: http.StatusOK}, onPoll)
+	conflict := ObservePolling(clientStub{status: http.StatusConflict}, onPoll)
+
+	for _, call := range []struct {
+		client bot.HttpClient
+		method string
+	}{
+		{ok, "getUpdates"},
+		{ok, "sendMessage"},
+		{conflict, "getUpdates"},
+	} {
+		resp, err := call.client.Do(request(call.method))
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	require.Equal(t, 1, polls)
+}
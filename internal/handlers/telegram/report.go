@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const defaultPeriod = "month"
+
+// periods are the arguments of /stats and the start of the period they cover.
+var periods = map[string]func(now time.Time) time.Time{
+	"week":  func(now time.Time) time.Time { return now.AddDate(0, 0, -7) },
+	"month": func(now time.Time) time.Time { return now.AddDate(0, -1, 0) },
+	"year":  func(now time.Time) time.Time { return now.AddDate(-1, 0, 0) },
+	"all":   func(time.Time) time.Time { return time.Time{} },
+}
+
+var periodTitles = map[string]string{
+	"week":  "за неделю",
+	"month": "за месяц",
+	"year":  "за год",
+	"all":   "за всё время",
+}
+
+const statsUsage = "Использование: /stats [week|month|year|all], по умолчанию month"
+
+// Stats sends the fairness report of the chat: duties, skips and streaks of
+// every member in the period given by the argument.
+func (t *TgBotHandler) Stats(ctx context.Context, botApi *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	period, since, ok := parsePeriod(update.Message.Text, time.Now())
+	if !ok {
+		t.sendMessage(ctx, botApi, chatID, statsUsage)
+
+		return
+	}
+
+	report, err := t.service.DutyReport(ctx, chatID, since)
+	if err != nil {
+		t.sendServiceError(ctx, botApi, chatID, err)
+
+		return
+	}
+
+	t.sendMessage(ctx, botApi, chatID, formatDutyReport(period, report))
+}
+
+// parsePeriod returns the period of the /stats command and its start.
+func parsePeriod(text string, now time.Time) (string, time.Time, bool) {
+	fields := strings.Fields(text)
+
+	period := defaultPeriod
+	if len(fields) > 1 {
+		period = strings.ToLower(fields[1])
+	}
+
+	start, ok := periods[period]
+	if !ok || len(fields) > 2 {
+		return "", time.Time{}, false
+	}
+
+	return period, start(now), true
+}
+
+func formatDutyReport(period string, report trashmanager.DutyReport) string {
+	if len(report.Members) == 0 {
+		return trashmanager.ErrTryToAddUsers.Error()
+	}
+
+	var text strings.Builder
+
+	fmt.Fprintf(&text, "Дежурства %s:\n", periodTitles[period])
+
+	for _, member := range report.Members {
+		fmt.Fprintf(&text, "\n%s: вынесено %d", member.Name, member.Done)
+
+		if member.Skipped > 0 {
+			fmt.Fprintf(&text, ", пропусков %d", member.Skipped)
+		}
+
+		if member.Streak > 1 {
+			fmt.Fprintf(&text, ", подряд без пропусков %d", member.Streak)
+		}
+	}
+
+	return text.String()
+}
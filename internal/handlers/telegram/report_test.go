@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeriod(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		text   string
+		period string
+		since  time.Time
+		ok     bool
+	}{
+		{text: "/stats", period: "month", since: now.AddDate(0, -1, 0), ok: true},
+		{text: "/stats@trash_bot Week", period: "week", since: now.AddDate(0, 0, -7), ok: true},
+		{text: "/stats year", period: "year", since: now.AddDate(-1, 0, 0), ok: true},
+		{text: "/stats all", period: "all", ok: true},
+		{text: "/stats fortnight"},
+		{text: "/stats month week"},
+	}
+
+	for _, tt := range tests {
+		period, since, ok := parsePeriod(tt.text, now)
+		require.Equal(t, tt.ok, ok, tt.text)
+		require.Equal(t, tt.period, period, tt.text)
+		require.Equal(t, tt.since, since, tt.text)
+	}
+}
+
+func TestFormatDutyReport(t *testing.T) {
+	t.Parallel()
+
+	text := formatDutyReport("week", trashmanager.DutyReport{Members: []trashmanager.MemberDuty{
+		{Name: "German", Done: 3, Streak: 5},
+		{Name: "Anthon", Done: 1, Skipped: 2, Streak: 1},
+		{Name: "Vitaly"},
+	}})
+
+	require.Equal(t, "Дежурства за неделю:\n"+
+		"\nGerman: вынесено 3, подряд без пропусков 5"+
+		"\nAnthon: вынесено 1, пропусков 2"+
+		"\nVitaly: вынесено 0", text)
+
+	require.Equal(t, trashmanager.ErrTryToAddUsers.Error(), formatDutyReport("all", trashmanager.DutyReport{}))
+}
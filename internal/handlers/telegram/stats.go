@@ -0,0 +1,51 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// CommandRecorder counts bot commands.
+type CommandRecorder interface {
+	CommandUsed(ctx context.Context, chatID int64, command string)
+}
+
+// CallbackRecorder counts pressed inline keyboard buttons.
+type CallbackRecorder interface {
+	CallbackPressed(ctx context.Context, chatID int64, action string)
+}
+
+// CommandMiddleware counts the command of the message in every recorder. It is
+// set on command handlers only, so unknown commands are not counted.
+func CommandMiddleware(recorders ...CommandRecorder) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, botAPI *bot.Bot, update *models.Update) {
+			if command := commandOf(update.Message); command != "" {
+				for _, recorder := range recorders {
+					recorder.CommandUsed(ctx, update.Message.Chat.ID, command)
+				}
+			}
+
+			next(ctx, botAPI, update)
+		}
+	}
+}
+
+// commandOf returns the command without the slash and the bot username.
+func commandOf(message *models.Message) string {
+	if message == nil {
+		return ""
+	}
+
+	fields := strings.Fields(message.Text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return ""
+	}
+
+	command, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+
+	return strings.ToLower(command)
+}
@@ -0,0 +1,33 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/require"
+)
+
+type commandRecorderStub struct {
+	commands []string
+}
+
+func (s *commandRecorderStub) CommandUsed(_ context.Context, _ int64, command string) {
+	s.commands = append(s.commands, command)
+}
+
+func TestCommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	recorder := &commandRecorderStub{}
+	handler := CommandMiddleware(recorder)(func(context.Context, *bot.Bot, *models.Update) {})
+
+	for _, text := range []string{"/next", "/Who@trash_bot", "/set German Anthon", "hello"} {
+		handler(t.Context(), nil, &models.Update{Message: &models.Message{Text: text}})
+	}
+
+	handler(t.Context(), nil, &models.Update{CallbackQuery: &models.CallbackQuery{}})
+
+	require.Equal(t, []string{"next", "who", "set"}, recorder.commands)
+}
@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"context"
+
+	"github.com/6ermvH/trash-bot/internal/tracing"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	keyUpdateID = attribute.Key("telegram.update_id")
+	keyUserID   = attribute.Key("telegram.user_id")
+	keyCommand  = attribute.Key("telegram.command")
+)
+
+// TraceMiddleware starts the root span of an update, spans of the services and
+// the storage used by the handler are its children, so an update is one trace.
+func TraceMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, botAPI *bot.Bot, update *models.Update) {
+		name, attrs := updateSpan(update)
+
+		ctx, span := tracing.Start(ctx, name,
+			trace.WithNewRoot(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+
+		next(ctx, botAPI, update)
+	}
+}
+
+// updateSpan names the span by the command or the kind of the update.
+func updateSpan(update *models.Update) (string, []attribute.KeyValue) {
+	name := "telegram update"
+	attrs := []attribute.KeyValue{keyUpdateID.Int64(update.ID)}
+
+	if user := updateSender(update); user != nil {
+		attrs = append(attrs, keyUserID.Int64(user.ID))
+	}
+
+	switch {
+	case update.Message != nil:
+		name = "telegram message"
+		attrs = append(attrs, tracing.KeyChatID.Int64(update.Message.Chat.ID))
+
+		if command := commandOf(update.Message); command != "" {
+			name = "telegram /" + command
+			attrs = append(attrs, keyCommand.String(command))
+		}
+	case update.CallbackQuery != nil:
+		name = "telegram callback"
+
+		if message := update.CallbackQuery.Message.Message; message != nil {
+			attrs = append(attrs, tracing.KeyChatID.Int64(message.Chat.ID))
+		}
+	}
+
+	return name, attrs
+}
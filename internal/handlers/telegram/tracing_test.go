@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/sqlite"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/6ermvH/trash-bot/internal/tracing"
+	"github.com/6ermvH/trash-bot/internal/tracing/tracingtest"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TestTraceMiddleware checks that spans of the service and the storage used
+// while handling an update belong to the trace of the update.
+//
+//nolint:paralleltest // sets the global tracer provider
+func TestTraceMiddleware(t *testing.T) {
+	repo, err := sqlite.New(filepath.Join(t.TempDir(), "trash.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: -100, Users: []string{"German", "Ivan"}}))
+
+	service := trashmanager.New(repo)
+	t.Cleanup(service.Close)
+
+	recorder := tracingtest.Record(t)
+
+	// Трейс обновления не продолжает трейс контекста опроса
+	ctx, polling := tracing.Start(t.Context(), "polling")
+	defer polling.End()
+
+	handler := TraceMiddleware(func(ctx context.Context, _ *bot.Bot, update *models.Update) {
+		_, err := service.Next(ctx, update.Message.Chat.ID)
+		require.NoError(t, err)
+	})
+
+	handler(ctx, nil, &models.Update{
+		ID: 7,
+		Message: &models.Message{
+			Text: "/next",
+			Chat: models.Chat{ID: -100},
+			From: &models.User{ID: 42},
+		},
+	})
+
+	spans := recorder.Ended()
+	require.NotEmpty(t, spans)
+
+	root := spans[len(spans)-1]
+	require.Equal(t, "telegram /next", root.Name())
+	require.False(t, root.Parent().IsValid(), "an update starts a new trace")
+	require.Contains(t, root.Attributes(), tracing.KeyChatID.Int64(-100))
+	require.Contains(t, root.Attributes(), keyCommand.String("next"))
+
+	names := tracingtest.Names(recorder)
+	require.Contains(t, names, "trashmanager.Next")
+	require.Contains(t, names, "sqlite UPDATE")
+
+	for _, span := range spans {
+		require.Equal(t, root.SpanContext().TraceID(), span.SpanContext().TraceID(), span.Name())
+	}
+}
+
+func TestUpdateSpan(t *testing.T) {
+	t.Parallel()
+
+	name, attrs := updateSpan(&models.Update{
+		ID: 8,
+		CallbackQuery: &models.CallbackQuery{
+			From:    models.User{ID: 42},
+			Message: models.MaybeInaccessibleMessage{Message: &models.Message{Chat: models.Chat{ID: -100}}},
+		},
+	})
+
+	require.Equal(t, "telegram callback", name)
+	require.Equal(t, []attribute.KeyValue{
+		keyUpdateID.Int64(8),
+		keyUserID.Int64(42),
+		tracing.KeyChatID.Int64(-100),
+	}, attrs)
+}
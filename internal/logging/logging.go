@@ -0,0 +1,149 @@
+// Package logging configures log/slog and carries log fields in a context, so
+// that records logged by services and storages while handling a Telegram
+// update, an HTTP request or a scheduler check are correlated with it.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Keys of the contextual fields.
+const (
+	KeyChatID    = "chat_id"
+	KeyUserID    = "user_id" // Telegram user
+	KeyUpdateID  = "update_id"
+	KeyCommand   = "command"
+	KeyRequestID = "request_id"
+	KeyAdmin     = "admin" // login of the panel admin
+	KeyError     = "error"
+	KeyTraceID   = "trace_id"
+	KeySpanID    = "span_id"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var ErrUnknownFormat = errors.New("unknown log format")
+
+// New creates a logger writing in the format ("text" or "json") records of the
+// level ("debug", "info", "warn" or "error") and above, with the fields of the
+// context of each record. Empty values mean text and info.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var minLevel slog.Level
+
+	if level != "" {
+		if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("parse log level %q: %w", level, err)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: minLevel}
+
+	var handler slog.Handler
+
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Err is the attribute of an error.
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+type fieldsKey struct{}
+
+// With returns a context whose records get the attributes. An attribute
+// replaces the one of the parent context with the same key.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	parent := fields(ctx)
+
+	merged := make([]slog.Attr, 0, len(parent)+len(attrs))
+	for _, attr := range parent {
+		if !slices.ContainsFunc(attrs, func(a slog.Attr) bool { return a.Key == attr.Key }) {
+			merged = append(merged, attr)
+		}
+	}
+
+	return context.WithValue(ctx, fieldsKey{}, append(merged, attrs...))
+}
+
+func fields(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+
+	attrs, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+
+	return attrs
+}
+
+// traceFields are the ids of the span of the context, they link records to
+// the trace of the update or the request.
+func traceFields(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return nil
+	}
+
+	return []slog.Attr{
+		slog.String(KeyTraceID, spanCtx.TraceID().String()),
+		slog.String(KeySpanID, spanCtx.SpanID().String()),
+	}
+}
+
+// contextHandler adds the fields and the trace ids of the context to every
+// record, unless the record has an attribute with the same key.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs := slices.Concat(fields(ctx), traceFields(ctx)); len(attrs) > 0 {
+		own := make(map[string]bool, record.NumAttrs())
+		record.Attrs(func(attr slog.Attr) bool {
+			own[attr.Key] = true
+
+			return true
+		})
+
+		record = record.Clone()
+
+		for _, attr := range attrs {
+			if !own[attr.Key] {
+				record.AddAttrs(attr)
+			}
+		}
+	}
+
+	return h.Handler.Handle(ctx, record) //nolint:wrapcheck // the handler is only decorated
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger, err := New(&buf, "json", "warn")
+	require.NoError(t, err)
+
+	ctx := With(t.Context(), slog.Int64(KeyChatID, 1), slog.String(KeyCommand, "next"))
+	ctx = With(ctx, slog.Int64(KeyChatID, 2), slog.String(KeyRequestID, "abc"))
+
+	logger.InfoContext(ctx, "skipped below the level")
+	logger.With("component", "test").WarnContext(ctx, "message", KeyCommand, "prev")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+	require.Equal(t, "message", record["msg"])
+	require.Equal(t, "test", record["component"])
+	require.InDelta(t, 2, record[KeyChatID], 0, "inner fields replace outer ones")
+	require.Equal(t, "prev", record[KeyCommand], "attributes of the record win")
+	require.Equal(t, "abc", record[KeyRequestID])
+
+	_, err = New(&buf, "xml", "")
+	require.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, err = New(&buf, "text", "verbose")
+	require.Error(t, err)
+}
+
+func TestNew_TraceIDs(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger, err := New(&buf, "json", "")
+	require.NoError(t, err)
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	})
+
+	logger.InfoContext(trace.ContextWithSpanContext(t.Context(), spanCtx), "traced")
+	logger.InfoContext(t.Context(), "not traced")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var traced, untraced map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &traced))
+	require.NoError(t, json.Unmarshal(lines[1], &untraced))
+
+	require.Equal(t, spanCtx.TraceID().String(), traced[KeyTraceID])
+	require.Equal(t, spanCtx.SpanID().String(), traced[KeySpanID])
+	require.NotContains(t, untraced, KeyTraceID)
+}
@@ -0,0 +1,313 @@
+// Package openapi loads an OpenAPI 3 document and validates HTTP requests and
+// responses against it. Only the parts of the specification used by the v1 API
+// are supported: path, query and header parameters, JSON bodies and the schema
+// keywords handled by Schema.Validate.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	schemaRefPrefix    = "#/components/schemas/"
+	parameterRefPrefix = "#/components/parameters/"
+	responseRefPrefix  = "#/components/responses/"
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported openapi version")
+	ErrUnknownRef         = errors.New("unknown reference")
+)
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Servers    []Server             `json:"servers"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Components struct {
+	Schemas    map[string]*Schema    `json:"schemas"`
+	Parameters map[string]*Parameter `json:"parameters"`
+	Responses  map[string]*Response  `json:"responses"`
+}
+
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters"`
+	Get        *Operation   `json:"get"`
+	Put        *Operation   `json:"put"`
+	Post       *Operation   `json:"post"`
+	Delete     *Operation   `json:"delete"`
+	Patch      *Operation   `json:"patch"`
+}
+
+// Operations returns the operations of the path by HTTP method.
+func (p *PathItem) Operations() map[string]*Operation {
+	operations := make(map[string]*Operation, 5)
+
+	for method, op := range map[string]*Operation{
+		http.MethodGet:    p.Get,
+		http.MethodPut:    p.Put,
+		http.MethodPost:   p.Post,
+		http.MethodDelete: p.Delete,
+		http.MethodPatch:  p.Patch,
+	} {
+		if op != nil {
+			operations[method] = op
+		}
+	}
+
+	return operations
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"` // "path", "query" or "header"
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref     string                `json:"$ref"`
+	Content map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Load parses the document and resolves references to components.
+func Load(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi document: %w", err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedVersion, doc.OpenAPI)
+	}
+
+	resolver := &resolver{components: doc.Components, seen: make(map[*Schema]bool)}
+
+	for _, schema := range doc.Components.Schemas {
+		if err := resolver.resolve(schema); err != nil {
+			return nil, err
+		}
+	}
+
+	for path, item := range doc.Paths {
+		if err := resolver.resolveParameters(item.Parameters); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for method, op := range item.Operations() {
+			if err := resolver.resolveParameters(op.Parameters); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+
+			// Параметры пути действуют для всех операций, если операция их не переопределила
+			op.Parameters = mergeParameters(item.Parameters, op.Parameters)
+
+			if err := resolver.resolveOperation(op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+		}
+	}
+
+	return &doc, nil
+}
+
+// BasePath is the path of the first server, "" when the document has none.
+func (d *Document) BasePath() string {
+	if len(d.Servers) == 0 {
+		return ""
+	}
+
+	return strings.TrimSuffix(d.Servers[0].URL, "/")
+}
+
+// Operation finds the operation of a route. The path is a full router path
+// with parameters in Gin syntax, e.g. "/api/chats/:id".
+func (d *Document) Operation(method, routePath string) *Operation {
+	path, ok := strings.CutPrefix(routePath, d.BasePath())
+	if !ok {
+		return nil
+	}
+
+	item := d.Paths[templatePath(path)]
+	if item == nil {
+		return nil
+	}
+
+	return item.Operations()[method]
+}
+
+// Response finds the response documented for the status code: the exact
+// code, then the range like "4XX", then "default".
+func (o *Operation) Response(status int) *Response {
+	code := strconv.Itoa(status)
+
+	for _, key := range []string{code, code[:1] + "XX", "default"} {
+		if response, ok := o.Responses[key]; ok {
+			return response
+		}
+	}
+
+	return nil
+}
+
+// templatePath converts ":name" and "*name" segments to "{name}".
+func templatePath(path string) string {
+	segments := strings.Split(path, "/")
+
+	for ind, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[ind] = "{" + name + "}"
+		} else if name, ok := strings.CutPrefix(segment, "*"); ok {
+			segments[ind] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func mergeParameters(common, own []*Parameter) []*Parameter {
+	merged := append([]*Parameter(nil), own...)
+
+	for _, param := range common {
+		overridden := false
+
+		for _, ownParam := range own {
+			if ownParam.Name == param.Name && ownParam.In == param.In {
+				overridden = true
+
+				break
+			}
+		}
+
+		if !overridden {
+			merged = append(merged, param)
+		}
+	}
+
+	return merged
+}
+
+type resolver struct {
+	components Components
+	seen       map[*Schema]bool
+}
+
+// resolveParameters replaces references in place, so that merging can compare
+// parameters by name.
+func (r *resolver) resolveParameters(params []*Parameter) error {
+	for ind, param := range params {
+		if param.Ref == "" {
+			continue
+		}
+
+		name, ok := strings.CutPrefix(param.Ref, parameterRefPrefix)
+		if !ok || r.components.Parameters[name] == nil {
+			return fmt.Errorf("%w: %q", ErrUnknownRef, param.Ref)
+		}
+
+		params[ind] = r.components.Parameters[name]
+	}
+
+	return nil
+}
+
+func (r *resolver) resolveOperation(op *Operation) error {
+	for _, param := range op.Parameters {
+		if err := r.resolve(param.Schema); err != nil {
+			return fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+	}
+
+	if op.RequestBody != nil {
+		for _, media := range op.RequestBody.Content {
+			if err := r.resolve(media.Schema); err != nil {
+				return fmt.Errorf("request body: %w", err)
+			}
+		}
+	}
+
+	for status, response := range op.Responses {
+		if response.Ref != "" {
+			name, ok := strings.CutPrefix(response.Ref, responseRefPrefix)
+			if !ok || r.components.Responses[name] == nil {
+				return fmt.Errorf("response %s: %w: %q", status, ErrUnknownRef, response.Ref)
+			}
+
+			response = r.components.Responses[name]
+			op.Responses[status] = response
+		}
+
+		for _, media := range response.Content {
+			if err := r.resolve(media.Schema); err != nil {
+				return fmt.Errorf("response %s: %w", status, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *resolver) resolve(schema *Schema) error {
+	if schema == nil || r.seen[schema] {
+		return nil
+	}
+
+	r.seen[schema] = true
+
+	if schema.Ref != "" {
+		name, ok := strings.CutPrefix(schema.Ref, schemaRefPrefix)
+		if !ok || r.components.Schemas[name] == nil {
+			return fmt.Errorf("%w: %q", ErrUnknownRef, schema.Ref)
+		}
+
+		schema.resolved = r.components.Schemas[name]
+
+		return r.resolve(schema.resolved)
+	}
+
+	if schema.Pattern != "" {
+		pattern, err := regexp.Compile(schema.Pattern)
+		if err != nil {
+			return fmt.Errorf("compile pattern %q: %w", schema.Pattern, err)
+		}
+
+		schema.pattern = pattern
+	}
+
+	for _, property := range schema.Properties {
+		if err := r.resolve(property); err != nil {
+			return err
+		}
+	}
+
+	return r.resolve(schema.Items)
+}
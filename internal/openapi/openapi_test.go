@@ -0,0 +1,175 @@
+package openapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `{
+  "openapi": "3.0.3",
+  "servers": [{"url": "/api"}],
+  "paths": {
+    "/items/{id}": {
+      "parameters": [{"$ref": "#/components/parameters/ID"}],
+      "get": {
+        "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1}}],
+        "responses": {
+          "200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+          "4XX": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "put": {
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}
+        },
+        "responses": {"204": {}}
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "ID": {"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}
+    },
+    "responses": {
+      "Error": {"content": {"application/json": {"schema": {"type": "object", "required": ["error"]}}}}
+    },
+    "schemas": {
+      "Item": {
+        "type": "object",
+        "required": ["name", "tags"],
+        "properties": {
+          "name": {"type": "string", "minLength": 1},
+          "time": {"type": "string", "pattern": "^[0-9]{2}:[0-9]{2}$"},
+          "kind": {"type": "string", "enum": ["a", "b"]},
+          "tags": {"type": "array", "nullable": true, "items": {"type": "string"}}
+        }
+      }
+    }
+  }
+}`
+
+func loadTestDocument(t *testing.T) *Document {
+	t.Helper()
+
+	doc, err := Load([]byte(testDocument))
+	require.NoError(t, err)
+
+	return doc
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Unknown reference", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Load([]byte(`{
+			"openapi": "3.0.3",
+			"paths": {"/": {"get": {"responses": {"200": {"content": {
+				"application/json": {"schema": {"$ref": "#/components/schemas/Missing"}}
+			}}}}}}
+		}`))
+		require.ErrorIs(t, err, ErrUnknownRef)
+	})
+
+	t.Run("Unsupported version", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Load([]byte(`{"swagger": "2.0"}`))
+		require.ErrorIs(t, err, ErrUnsupportedVersion)
+	})
+
+	t.Run("Operation by router path", func(t *testing.T) {
+		t.Parallel()
+
+		doc := loadTestDocument(t)
+
+		require.NotNil(t, doc.Operation(http.MethodGet, "/api/items/:id"))
+		require.Nil(t, doc.Operation(http.MethodDelete, "/api/items/:id"))
+		require.Nil(t, doc.Operation(http.MethodGet, "/items/:id"))
+
+		op := doc.Operation(http.MethodGet, "/api/items/:id")
+		require.Len(t, op.Parameters, 2)
+		require.NotNil(t, op.Response(http.StatusNotFound))
+		require.Nil(t, op.Response(http.StatusInternalServerError))
+	})
+}
+
+func TestOperation_ValidateRequest(t *testing.T) {
+	t.Parallel()
+
+	doc := loadTestDocument(t)
+	get := doc.Operation(http.MethodGet, "/api/items/:id")
+	put := doc.Operation(http.MethodPut, "/api/items/:id")
+
+	pathID := func(id string) func(string) string {
+		return func(name string) string {
+			if name == "id" {
+				return id
+			}
+
+			return ""
+		}
+	}
+
+	tests := []struct {
+		name    string
+		op      *Operation
+		target  string
+		id      string
+		body    string
+		wantErr string
+	}{
+		{name: "Valid query", op: get, target: "/api/items/1?limit=5", id: "1"},
+		{name: "Path parameter type", op: get, target: "/api/items/x", id: "x", wantErr: `path parameter "id": must be a number`},
+		{name: "Query minimum", op: get, target: "/api/items/1?limit=0", id: "1", wantErr: "must be at least 1"},
+		{name: "Valid body", op: put, target: "/api/items/1", id: "1", body: `{"name": "a", "tags": null, "kind": "b"}`},
+		{name: "Missing body", op: put, target: "/api/items/1", id: "1", wantErr: ErrRequestBodyRequired.Error()},
+		{name: "Not JSON", op: put, target: "/api/items/1", id: "1", body: `{`, wantErr: ErrInvalidJSON.Error()},
+		{name: "Required property", op: put, target: "/api/items/1", id: "1", body: `{"name": "a"}`, wantErr: "tags: is required"},
+		{name: "Enum", op: put, target: "/api/items/1", id: "1", body: `{"name": "a", "tags": [], "kind": "c"}`, wantErr: "kind: must be one of"},
+		{name: "Pattern", op: put, target: "/api/items/1", id: "1", body: `{"name": "a", "tags": [], "time": "9:00"}`, wantErr: "time: must match"},
+		{name: "Item type", op: put, target: "/api/items/1", id: "1", body: `{"name": "a", "tags": ["x", 1]}`, wantErr: "tags[1]: must be a string"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, test.target, strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+			err := test.op.ValidateRequest(req, pathID(test.id))
+			if test.wantErr == "" {
+				require.NoError(t, err)
+
+				body, readErr := io.ReadAll(req.Body)
+				require.NoError(t, readErr)
+				require.Equal(t, test.body, string(body), "body must stay readable")
+
+				return
+			}
+
+			require.ErrorContains(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestOperation_ValidateResponse(t *testing.T) {
+	t.Parallel()
+
+	op := loadTestDocument(t).Operation(http.MethodGet, "/api/items/:id")
+
+	require.NoError(t, op.ValidateResponse(http.StatusOK, "application/json", []byte(`{"name": "a", "tags": ["x"]}`)))
+	require.NoError(t, op.ValidateResponse(http.StatusNotFound, "application/json", []byte(`{"error": "not found"}`)))
+
+	require.ErrorContains(t, op.ValidateResponse(http.StatusOK, "application/json", []byte(`{"tags": []}`)), "name: is required")
+	require.ErrorIs(t, op.ValidateResponse(http.StatusInternalServerError, "application/json", []byte(`{}`)), ErrUndocumentedStatus)
+	require.ErrorIs(t, op.ValidateResponse(http.StatusOK, "text/plain", []byte(`ok`)), ErrUndocumentedMediaType)
+}
@@ -0,0 +1,252 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema is the subset of the OpenAPI schema object used by the API.
+type Schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Format     string             `json:"format"`
+	Nullable   bool               `json:"nullable"`
+	Enum       []any              `json:"enum"`
+	Properties map[string]*Schema `json:"properties"`
+	Required   []string           `json:"required"`
+	Items      *Schema            `json:"items"`
+	Minimum    *float64           `json:"minimum"`
+	Maximum    *float64           `json:"maximum"`
+	MinLength  *int               `json:"minLength"`
+	MaxLength  *int               `json:"maxLength"`
+	MinItems   *int               `json:"minItems"`
+	Pattern    string             `json:"pattern"`
+
+	resolved *Schema
+	pattern  *regexp.Regexp
+}
+
+// ValidationError describes the first mismatch between a value and a schema.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks a value decoded from JSON with json.Decoder.UseNumber.
+func (s *Schema) Validate(value any) error {
+	return s.validate("", value)
+}
+
+func (s *Schema) validate(path string, value any) error {
+	if s == nil {
+		return nil
+	}
+
+	if s.resolved != nil {
+		return s.resolved.validate(path, value)
+	}
+
+	fail := func(format string, args ...any) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+
+		return fail("must not be null")
+	}
+
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		return fail("must be one of %v", s.Enum)
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fail("must be an object")
+		}
+
+		return s.validateObject(path, object)
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fail("must be an array")
+		}
+
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			return fail("must have at least %d items", *s.MinItems)
+		}
+
+		for ind, item := range items {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, ind), item); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+
+		return s.validateString(path, str)
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fail("must be a number")
+		}
+
+		return s.validateNumber(path, number)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("must be a boolean")
+		}
+
+		return nil
+	default:
+		return fail("unsupported schema type %q", s.Type)
+	}
+}
+
+func (s *Schema) validateObject(path string, object map[string]any) error {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			return &ValidationError{Path: joinPath(path, name), Message: "is required"}
+		}
+	}
+
+	for name, property := range s.Properties {
+		value, ok := object[name]
+		if !ok {
+			continue
+		}
+
+		if err := property.validate(joinPath(path, name), value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateString(path, str string) error {
+	fail := func(format string, args ...any) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	length := utf8.RuneCountInString(str)
+
+	switch {
+	case s.MinLength != nil && length < *s.MinLength:
+		return fail("must be at least %d characters long", *s.MinLength)
+	case s.MaxLength != nil && length > *s.MaxLength:
+		return fail("must be at most %d characters long", *s.MaxLength)
+	case s.pattern != nil && !s.pattern.MatchString(str):
+		return fail("must match %q", s.Pattern)
+	}
+
+	switch s.Format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			return fail("must be an RFC 3339 date-time")
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, str); err != nil {
+			return fail("must be an RFC 3339 full-date")
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateNumber(path string, number json.Number) error {
+	fail := func(format string, args ...any) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if s.Type == "integer" {
+		if _, err := strconv.ParseInt(number.String(), 10, 64); err != nil {
+			return fail("must be an integer")
+		}
+	}
+
+	value, err := number.Float64()
+	if err != nil {
+		return fail("must be a number")
+	}
+
+	switch {
+	case s.Minimum != nil && value < *s.Minimum:
+		return fail("must be at least %v", *s.Minimum)
+	case s.Maximum != nil && value > *s.Maximum:
+		return fail("must be at most %v", *s.Maximum)
+	}
+
+	return nil
+}
+
+func (s *Schema) inEnum(value any) bool {
+	if number, ok := value.(json.Number); ok {
+		parsed, err := number.Float64()
+		if err != nil {
+			return false
+		}
+
+		value = parsed
+	}
+
+	// Значения enum из документа декодированы без UseNumber, поэтому числа в них float64
+	return slices.Contains(s.Enum, value)
+}
+
+// parse converts a parameter value to the type of the schema, so that it can be validated.
+func (s *Schema) parse(raw string) (any, error) {
+	if s.resolved != nil {
+		return s.resolved.parse(raw)
+	}
+
+	switch s.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, &ValidationError{Message: "must be a number"}
+		}
+
+		return json.Number(raw), nil
+	case "boolean":
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, &ValidationError{Message: "must be a boolean"}
+		}
+
+		return parsed, nil
+	default:
+		return raw, nil
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
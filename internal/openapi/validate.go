@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const jsonMediaType = "application/json"
+
+var (
+	ErrRequestBodyRequired   = errors.New("request body is required")
+	ErrInvalidJSON           = errors.New("body is not valid JSON")
+	ErrUnsupportedMediaType  = errors.New("unsupported media type")
+	ErrUndocumentedStatus    = errors.New("undocumented response status")
+	ErrUndocumentedMediaType = errors.New("undocumented response media type")
+)
+
+// ValidateRequest checks parameters and the body of the request. pathParam
+// returns the value of a path parameter by name. The body is read and
+// replaced, so that handlers can read it again.
+func (o *Operation) ValidateRequest(req *http.Request, pathParam func(name string) string) error {
+	query := req.URL.Query()
+
+	for _, param := range o.Parameters {
+		var (
+			raw     string
+			present bool
+		)
+
+		switch param.In {
+		case "path":
+			raw = pathParam(param.Name)
+			present = raw != ""
+		case "query":
+			present = query.Has(param.Name)
+			raw = query.Get(param.Name)
+		case "header":
+			raw = req.Header.Get(param.Name)
+			present = raw != ""
+		default:
+			continue
+		}
+
+		if err := param.validate(raw, present); err != nil {
+			return fmt.Errorf("%s parameter %q: %w", param.In, param.Name, err)
+		}
+	}
+
+	if o.RequestBody == nil || req.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return fmt.Errorf("read request body: %w", err)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if o.RequestBody.Required {
+			return ErrRequestBodyRequired
+		}
+
+		return nil
+	}
+
+	media := mediaType(req.Header.Get("Content-Type"))
+
+	content, ok := o.RequestBody.Content[media]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedMediaType, media)
+	}
+
+	if err := validateJSON(media, content.Schema, body); err != nil {
+		return fmt.Errorf("request body: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateResponse checks the status, the media type and the JSON body of a response.
+func (o *Operation) ValidateResponse(status int, contentType string, body []byte) error {
+	response := o.Response(status)
+	if response == nil {
+		return fmt.Errorf("%w: %d", ErrUndocumentedStatus, status)
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	media := mediaType(contentType)
+
+	content, ok := response.Content[media]
+	if !ok {
+		return fmt.Errorf("%w: %q for status %d", ErrUndocumentedMediaType, media, status)
+	}
+
+	if err := validateJSON(media, content.Schema, body); err != nil {
+		return fmt.Errorf("response %d: %w", status, err)
+	}
+
+	return nil
+}
+
+func (p *Parameter) validate(raw string, present bool) error {
+	if !present {
+		if p.Required {
+			return &ValidationError{Message: "is required"}
+		}
+
+		return nil
+	}
+
+	if p.Schema == nil {
+		return nil
+	}
+
+	value, err := p.Schema.parse(raw)
+	if err != nil {
+		return err
+	}
+
+	return p.Schema.Validate(value)
+}
+
+// validateJSON checks JSON bodies only, other media types are opaque.
+func validateJSON(media string, schema *Schema, body []byte) error {
+	if media != jsonMediaType {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return ErrInvalidJSON
+	}
+
+	return schema.Validate(value)
+}
+
+func mediaType(contentType string) string {
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+
+	return media
+}
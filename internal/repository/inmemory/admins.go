@@ -0,0 +1,76 @@
+package inmemory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+func (r *RepoInMem) GetAdmins(ctx context.Context) ([]repository.Admin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]repository.Admin, 0, len(r.admins))
+	for _, admin := range r.admins {
+		result = append(result, admin)
+	}
+
+	slices.SortFunc(result, func(a, b repository.Admin) int {
+		return strings.Compare(a.Login, b.Login)
+	})
+
+	return result, nil
+}
+
+func (r *RepoInMem) GetAdmin(ctx context.Context, login string) (*repository.Admin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	admin, ok := r.admins[login]
+	if !ok {
+		return nil, repository.ErrAdminNotFound
+	}
+
+	return &admin, nil
+}
+
+func (r *RepoInMem) CreateAdmin(ctx context.Context, admin repository.Admin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.admins[admin.Login]; ok {
+		return repository.ErrAdminExists
+	}
+
+	r.admins[admin.Login] = admin
+
+	return nil
+}
+
+func (r *RepoInMem) UpdateAdmin(ctx context.Context, admin repository.Admin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.admins[admin.Login]; !ok {
+		return repository.ErrAdminNotFound
+	}
+
+	r.admins[admin.Login] = admin
+
+	return nil
+}
+
+func (r *RepoInMem) DeleteAdmin(ctx context.Context, login string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.admins[login]; !ok {
+		return repository.ErrAdminNotFound
+	}
+
+	delete(r.admins, login)
+
+	return nil
+}
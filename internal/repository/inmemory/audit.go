@@ -0,0 +1,72 @@
+package inmemory
+
+import (
+	"context"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+func (r *RepoInMem) AddAuditEntry(ctx context.Context, entry repository.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.auditSeq++
+	entry.ID = r.auditSeq
+	entry.Before = copyChat(entry.Before)
+	entry.After = copyChat(entry.After)
+
+	r.audit = append(r.audit, entry)
+
+	return nil
+}
+
+func (r *RepoInMem) GetAuditEntries(
+	ctx context.Context,
+	filter repository.AuditFilter,
+) ([]repository.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]repository.AuditEntry, 0)
+
+	for ind := len(r.audit) - 1; ind >= 0; ind-- {
+		entry := r.audit[ind]
+
+		if filter.BeforeID > 0 && entry.ID >= filter.BeforeID {
+			continue
+		}
+
+		if !filter.Match(entry) {
+			continue
+		}
+
+		entry.Before = copyChat(entry.Before)
+		entry.After = copyChat(entry.After)
+		entries = append(entries, entry)
+
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *RepoInMem) DeleteAuditEntriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.audit[:0]
+
+	for _, entry := range r.audit {
+		if !entry.OccurredAt.Before(before) {
+			kept = append(kept, entry)
+		}
+	}
+
+	deleted := int64(len(r.audit) - len(kept))
+	r.audit = kept
+
+	return deleted, nil
+}
@@ -0,0 +1,50 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+func (r *RepoInMem) AddDuty(ctx context.Context, duty repository.Duty) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.duties[duty.ChatID] = append(r.duties[duty.ChatID], duty)
+
+	return nil
+}
+
+// GetDuties returns the duty history of the chat, oldest first.
+func (r *RepoInMem) GetDuties(ctx context.Context, chatID int64) ([]repository.Duty, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.duties[chatID]), nil
+}
+
+// GetDutiesSince returns the duties of all chats, deleted chats included,
+// that occurred at since or later, oldest first.
+func (r *RepoInMem) GetDutiesSince(ctx context.Context, since time.Time) ([]repository.Duty, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]repository.Duty, 0)
+
+	for _, duties := range r.duties {
+		for _, duty := range duties {
+			if !duty.OccurredAt.Before(since) {
+				result = append(result, duty)
+			}
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b repository.Duty) int {
+		return cmp.Compare(a.OccurredAt.UnixNano(), b.OccurredAt.UnixNano())
+	})
+
+	return result, nil
+}
@@ -3,17 +3,32 @@ package inmemory
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/6ermvH/trash-bot/internal/repository"
 )
 
 type RepoInMem struct {
-	chats map[int64]*repository.Chat
-	mu    sync.Mutex
+	chats         map[int64]*repository.Chat
+	admins        map[string]repository.Admin
+	refreshTokens map[string]repository.RefreshToken
+	revokedTokens map[string]time.Time
+	audit         []repository.AuditEntry
+	auditSeq      int64
+	stats         map[statKey]int64
+	duties        map[int64][]repository.Duty
+	mu            sync.Mutex
 }
 
 func New() *RepoInMem {
-	return &RepoInMem{chats: make(map[int64]*repository.Chat)}
+	return &RepoInMem{
+		chats:         make(map[int64]*repository.Chat),
+		admins:        make(map[string]repository.Admin),
+		refreshTokens: make(map[string]repository.RefreshToken),
+		revokedTokens: make(map[string]time.Time),
+		stats:         make(map[statKey]int64),
+		duties:        make(map[int64][]repository.Duty),
+	}
 }
 
 func (r *RepoInMem) GetChats(ctx context.Context) ([]repository.Chat, error) {
@@ -28,6 +43,18 @@ func (r *RepoInMem) GetChats(ctx context.Context) ([]repository.Chat, error) {
 	return result, nil
 }
 
+func (r *RepoInMem) FindChats(ctx context.Context, query repository.ChatQuery) ([]repository.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chats := make([]repository.Chat, 0, len(r.chats))
+	for _, chat := range r.chats {
+		chats = append(chats, *chat)
+	}
+
+	return query.Apply(chats), nil
+}
+
 func (r *RepoInMem) GetChat(ctx context.Context, chatID int64) (*repository.Chat, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -155,3 +182,54 @@ func (r *RepoInMem) GetSubscribedChats(ctx context.Context) ([]repository.Chat,
 
 	return result, nil
 }
+
+func (r *RepoInMem) SaveChat(ctx context.Context, chat repository.Chat) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.chats[chat.ID] = copyChat(&chat)
+
+	return nil
+}
+
+func (r *RepoInMem) DeleteChat(ctx context.Context, chatID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.chats, chatID)
+
+	return nil
+}
+
+// ImportChats saves chats and deletes the chats with deleteIDs at once.
+func (r *RepoInMem) ImportChats(ctx context.Context, chats []repository.Chat, deleteIDs []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, chat := range chats {
+		r.chats[chat.ID] = copyChat(&chat)
+	}
+
+	for _, chatID := range deleteIDs {
+		delete(r.chats, chatID)
+	}
+
+	return nil
+}
+
+// copyChat returns a deep copy, so that callers cannot change stored data.
+func copyChat(chat *repository.Chat) *repository.Chat {
+	if chat == nil {
+		return nil
+	}
+
+	cp := *chat
+	cp.Users = append([]string(nil), chat.Users...)
+
+	if chat.NotifyTime != nil {
+		notifyTime := *chat.NotifyTime
+		cp.NotifyTime = &notifyTime
+	}
+
+	return &cp
+}
@@ -329,6 +329,95 @@ func TestGetSubscribedChats(t *testing.T) {
 	})
 }
 
+func TestFindChats(t *testing.T) {
+	t.Parallel()
+
+	notifyTime := "09:00"
+	repo := newTestRepo(t, []repository.Chat{
+		{ID: 1, Users: []string{"German", "Anthon"}},
+		{ID: 2, Users: []string{}},
+		{ID: 3, Users: []string{"Вова"}, NotifyTime: &notifyTime},
+	})
+	ctx := t.Context()
+
+	subscribed, empty := false, false
+
+	chats, err := repo.FindChats(ctx, repository.ChatQuery{Subscribed: &subscribed, Empty: &empty})
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	require.Equal(t, int64(1), chats[0].ID)
+
+	chats, err = repo.FindChats(ctx, repository.ChatQuery{Member: "вов"})
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	require.Equal(t, int64(3), chats[0].ID)
+
+	chats, err = repo.FindChats(ctx, repository.ChatQuery{
+		Sort:  repository.ChatSortMembers,
+		Desc:  true,
+		After: &repository.ChatCursor{ID: 1, Members: 2},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{3, 2}, []int64{chats[0].ID, chats[1].ID})
+}
+
+func TestSaveDeleteChat(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Save overwrites whole chat", func(t *testing.T) {
+		t.Parallel()
+
+		chats := []repository.Chat{
+			{
+				ID:      1,
+				Users:   []string{"German"},
+				Current: 0,
+			},
+		}
+		repo := newTestRepo(t, chats)
+		ctx := t.Context()
+
+		notifyTime := "09:00"
+		saved := repository.Chat{
+			ID:         1,
+			Users:      []string{"Anthon", "Vitaly"},
+			Current:    1,
+			NotifyTime: &notifyTime,
+		}
+		require.NoError(t, repo.SaveChat(ctx, saved))
+
+		username, err := repo.GetCurrent(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, "Vitaly", username)
+
+		// Изменение исходных данных не должно влиять на сохранённый чат
+		saved.Users[1] = "German"
+		*saved.NotifyTime = "10:00"
+
+		require.Equal(t, "Vitaly", repo.chats[1].Users[1])
+		require.Equal(t, "09:00", *repo.chats[1].NotifyTime)
+	})
+
+	t.Run("Delete chat", func(t *testing.T) {
+		t.Parallel()
+
+		chats := []repository.Chat{
+			{
+				ID:      1,
+				Users:   []string{"German"},
+				Current: 0,
+			},
+		}
+		repo := newTestRepo(t, chats)
+		ctx := t.Context()
+
+		require.NoError(t, repo.DeleteChat(ctx, 1))
+
+		_, err := repo.GetChat(ctx, 1)
+		require.ErrorIs(t, err, repository.ErrChatIsNotInitialize)
+	})
+}
+
 func newTestRepo(t *testing.T, chats []repository.Chat) *RepoInMem {
 	t.Helper()
 
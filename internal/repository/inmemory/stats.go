@@ -0,0 +1,53 @@
+package inmemory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+type statKey struct {
+	day    string
+	metric string
+	chatID int64
+	key    string
+}
+
+func (r *RepoInMem) AddStatCount(ctx context.Context, count repository.StatCount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats[statKey{day: count.Day, metric: count.Metric, chatID: count.ChatID, key: count.Key}] += count.Count
+
+	return nil
+}
+
+// GetStatCounts returns counters of days from since on, oldest first.
+func (r *RepoInMem) GetStatCounts(ctx context.Context, since string) ([]repository.StatCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make([]repository.StatCount, 0)
+
+	for key, value := range r.stats {
+		if key.day < since {
+			continue
+		}
+
+		counts = append(counts, repository.StatCount{
+			Day:    key.day,
+			Metric: key.metric,
+			ChatID: key.chatID,
+			Key:    key.key,
+			Count:  value,
+		})
+	}
+
+	slices.SortFunc(counts, func(a, b repository.StatCount) int {
+		return strings.Compare(a.Day, b.Day)
+	})
+
+	return counts, nil
+}
@@ -0,0 +1,111 @@
+package inmemory
+
+import (
+	"context"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+func (r *RepoInMem) CreateRefreshToken(ctx context.Context, token repository.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refreshTokens[token.Hash] = token
+
+	return nil
+}
+
+func (r *RepoInMem) GetRefreshToken(ctx context.Context, hash string) (*repository.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.refreshTokens[hash]
+	if !ok {
+		return nil, repository.ErrTokenNotFound
+	}
+
+	return &token, nil
+}
+
+func (r *RepoInMem) MarkRefreshTokenUsed(ctx context.Context, hash string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.refreshTokens[hash]
+	if !ok {
+		return repository.ErrTokenNotFound
+	}
+
+	if token.UsedAt != nil {
+		return repository.ErrTokenAlreadyUsed
+	}
+
+	token.UsedAt = &usedAt
+	r.refreshTokens[hash] = token
+
+	return nil
+}
+
+func (r *RepoInMem) RevokeRefreshFamily(ctx context.Context, family string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.refreshTokens {
+		if token.Family == family {
+			delete(r.refreshTokens, hash)
+		}
+	}
+
+	return nil
+}
+
+func (r *RepoInMem) RevokeAdminRefreshTokens(ctx context.Context, login string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.refreshTokens {
+		if token.Login == login {
+			delete(r.refreshTokens, hash)
+		}
+	}
+
+	return nil
+}
+
+func (r *RepoInMem) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revokedTokens[jti] = expiresAt
+
+	return nil
+}
+
+func (r *RepoInMem) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.revokedTokens[jti]
+
+	return ok, nil
+}
+
+func (r *RepoInMem) DeleteExpiredTokens(ctx context.Context, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.refreshTokens {
+		if !token.ExpiresAt.After(now) {
+			delete(r.refreshTokens, hash)
+		}
+	}
+
+	for jti, expiresAt := range r.revokedTokens {
+		if !expiresAt.After(now) {
+			delete(r.revokedTokens, jti)
+		}
+	}
+
+	return nil
+}
@@ -1,10 +1,20 @@
 package repository
 
-import "errors"
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
 
 var (
 	ErrChatIsEmpty         = errors.New("chat don`t have someone user in list")
 	ErrChatIsNotInitialize = errors.New("chat don`t initialize manager")
+	ErrAdminNotFound       = errors.New("admin not found")
+	ErrAdminExists         = errors.New("admin already exists")
+	ErrTokenNotFound       = errors.New("token not found")
+	ErrTokenAlreadyUsed    = errors.New("token already used")
 )
 
 type Chat struct {
@@ -13,3 +23,229 @@ type Chat struct {
 	Users      []string `json:"activeUsers"`
 	NotifyTime *string  `json:"notifyTime,omitempty"` // время уведомления в формате "HH:MM", nil если не подписан
 }
+
+// ChatSort is the order of chats selected by ChatQuery, chats with equal keys are ordered by id.
+type ChatSort string
+
+const (
+	ChatSortID         ChatSort = "id"
+	ChatSortMembers    ChatSort = "members"    // по количеству участников
+	ChatSortNotifyTime ChatSort = "notifyTime" // по времени напоминания, чаты без подписки первыми
+)
+
+func (s ChatSort) Valid() bool {
+	return s == ChatSortID || s == ChatSortMembers || s == ChatSortNotifyTime
+}
+
+// ChatQuery selects a page of chats. Zero fields do not filter.
+type ChatQuery struct {
+	Subscribed *bool  // with or without the daily reminder
+	Member     string // case-insensitive part of a member name
+	Empty      *bool  // with or without members
+	Sort       ChatSort
+	Desc       bool
+	After      *ChatCursor // only chats after this position in the sort order, for pagination
+	Limit      int
+}
+
+// ChatCursor is the position of a chat in the sort order: the sort key and the id.
+type ChatCursor struct {
+	ID         int64  `json:"id"`
+	Members    int    `json:"members,omitempty"`
+	NotifyTime string `json:"notifyTime,omitempty"`
+}
+
+// CursorOf returns the position of the chat.
+func CursorOf(chat Chat) ChatCursor {
+	cursor := ChatCursor{ID: chat.ID, Members: len(chat.Users)}
+	if chat.NotifyTime != nil {
+		cursor.NotifyTime = *chat.NotifyTime
+	}
+
+	return cursor
+}
+
+// Match reports whether the chat passes the filters and is after the cursor.
+func (q ChatQuery) Match(chat Chat) bool {
+	switch {
+	case q.Subscribed != nil && *q.Subscribed != (chat.NotifyTime != nil),
+		q.Empty != nil && *q.Empty != (len(chat.Users) == 0),
+		q.Member != "" && !hasMember(chat, q.Member),
+		q.After != nil && q.Compare(CursorOf(chat), *q.After) <= 0:
+		return false
+	default:
+		return true
+	}
+}
+
+// Compare compares positions in the sort order of the query.
+func (q ChatQuery) Compare(a, b ChatCursor) int {
+	var result int
+
+	switch q.Sort {
+	case ChatSortMembers:
+		result = cmp.Compare(a.Members, b.Members)
+	case ChatSortNotifyTime:
+		result = cmp.Compare(a.NotifyTime, b.NotifyTime)
+	}
+
+	if result == 0 {
+		result = cmp.Compare(a.ID, b.ID)
+	}
+
+	if q.Desc {
+		return -result
+	}
+
+	return result
+}
+
+// Apply selects the page from all chats, it is used by storages without a query engine.
+func (q ChatQuery) Apply(chats []Chat) []Chat {
+	result := make([]Chat, 0, len(chats))
+
+	for _, chat := range chats {
+		if q.Match(chat) {
+			result = append(result, chat)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b Chat) int {
+		return q.Compare(CursorOf(a), CursorOf(b))
+	})
+
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+
+	return result
+}
+
+func hasMember(chat Chat, part string) bool {
+	part = strings.ToLower(part)
+
+	return slices.ContainsFunc(chat.Users, func(user string) bool {
+		return strings.Contains(strings.ToLower(user), part)
+	})
+}
+
+// Role is access level of an admin panel account.
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // только просмотр
+	RoleOperator Role = "operator" // управление очередями
+	RoleOwner    Role = "owner"    // управление админами и данными
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleOwner:    3,
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+
+	return ok
+}
+
+// Allows reports whether the role grants at least the required access level.
+func (r Role) Allows(required Role) bool {
+	rank, ok := roleRanks[r]
+
+	return ok && rank >= roleRanks[required]
+}
+
+type Admin struct {
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"-"`
+	Role         Role      `json:"role"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// RefreshToken is a server-side record of an issued refresh token. Tokens
+// obtained by rotating one another share the same Family.
+type RefreshToken struct {
+	Hash   string
+	Family string
+	Login  string
+	// TelegramID and TelegramUsername are set for tokens of Telegram users.
+	TelegramID       int64
+	TelegramUsername string
+	ExpiresAt        time.Time
+	UsedAt           *time.Time
+}
+
+// AuditEntry is a record of one change made by a bot user, a panel admin or the system.
+type AuditEntry struct {
+	ID         int64     `json:"id"`
+	OccurredAt time.Time `json:"occurredAt"`
+	ActorType  string    `json:"actorType"` // "telegram", "admin" or "system"
+	ActorID    string    `json:"actorId"`
+	ActorName  string    `json:"actorName"`
+	Source     string    `json:"source"` // "bot", "panel" or "system"
+	ChatID     int64     `json:"chatId"`
+	Action     string    `json:"action"`
+	Before     *Chat     `json:"before,omitempty"` // nil if the chat did not exist
+	After      *Chat     `json:"after,omitempty"`  // nil if the chat was deleted
+	Details    string    `json:"details,omitempty"`
+}
+
+// AuditFilter selects audit entries, newest first. Zero fields do not filter.
+type AuditFilter struct {
+	ChatID   *int64
+	ActorID  string
+	Action   string
+	Source   string
+	From     time.Time
+	To       time.Time
+	BeforeID int64 // only entries older than this one, for pagination
+	Limit    int
+}
+
+// Match reports whether the entry passes the filter, ignoring BeforeID and Limit.
+func (f AuditFilter) Match(entry AuditEntry) bool {
+	switch {
+	case f.ChatID != nil && entry.ChatID != *f.ChatID,
+		f.ActorID != "" && entry.ActorID != f.ActorID,
+		f.Action != "" && entry.Action != f.Action,
+		f.Source != "" && entry.Source != f.Source,
+		!f.From.IsZero() && entry.OccurredAt.Before(f.From),
+		!f.To.IsZero() && !entry.OccurredAt.Before(f.To):
+		return false
+	default:
+		return true
+	}
+}
+
+// StatCount is the value of a statistics counter on one day. Counts of the
+// same day, metric, chat and key are added up.
+type StatCount struct {
+	Day    string // "2006-01-02" in the local time of the server
+	Metric string
+	ChatID int64  // 0 for counters not bound to a chat
+	Key    string // member for duties, command for command usage, empty otherwise
+	Count  int64
+}
+
+// Duty is an entry of the duty history of a chat. Moving the rotation forward
+// records a done duty of the member whose turn ended, moving it back records
+// a skip of the member whose duty was taken back.
+type Duty struct {
+	ChatID     int64
+	Member     string
+	OccurredAt time.Time
+	Skipped    bool
+}
+
+// Change is a chat change recorded for other processes using the same
+// database. Changes are ordered by ID.
+type Change struct {
+	ID         int64
+	Origin     string // the process that made the change
+	OccurredAt time.Time
+	ChatID     int64
+	Type       string // trashmanager.EventType
+	Payload    []byte // the event encoded as JSON
+}
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+func (r *RepoSQLite) GetAdmins(ctx context.Context) (_ []repository.Admin, err error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT login, password_hash, role, created_at FROM admins ORDER BY login",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query admins: %w", err)
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close rows: %w", closeErr)
+		}
+	}()
+
+	admins := make([]repository.Admin, 0)
+
+	for rows.Next() {
+		var (
+			admin     repository.Admin
+			createdAt int64
+		)
+
+		if err := rows.Scan(&admin.Login, &admin.PasswordHash, &admin.Role, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan admin: %w", err)
+		}
+
+		admin.CreatedAt = time.Unix(createdAt, 0).UTC()
+		admins = append(admins, admin)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate admins: %w", err)
+	}
+
+	return admins, nil
+}
+
+func (r *RepoSQLite) GetAdmin(ctx context.Context, login string) (*repository.Admin, error) {
+	var (
+		admin     repository.Admin
+		createdAt int64
+	)
+
+	err := r.db.QueryRowContext(ctx,
+		"SELECT login, password_hash, role, created_at FROM admins WHERE login = ?", login,
+	).Scan(&admin.Login, &admin.PasswordHash, &admin.Role, &createdAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrAdminNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("query admin: %w", err)
+	}
+
+	admin.CreatedAt = time.Unix(createdAt, 0).UTC()
+
+	return &admin, nil
+}
+
+func (r *RepoSQLite) CreateAdmin(ctx context.Context, admin repository.Admin) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		`
+		INSERT INTO admins (login, password_hash, role, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(login) DO NOTHING
+	`,
+		admin.Login,
+		admin.PasswordHash,
+		admin.Role,
+		admin.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert admin: %w", err)
+	}
+
+	return expectAffected(res, repository.ErrAdminExists)
+}
+
+func (r *RepoSQLite) UpdateAdmin(ctx context.Context, admin repository.Admin) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE admins SET password_hash = ?, role = ? WHERE login = ?",
+		admin.PasswordHash,
+		admin.Role,
+		admin.Login,
+	)
+	if err != nil {
+		return fmt.Errorf("update admin: %w", err)
+	}
+
+	return expectAffected(res, repository.ErrAdminNotFound)
+}
+
+func (r *RepoSQLite) DeleteAdmin(ctx context.Context, login string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM admins WHERE login = ?", login)
+	if err != nil {
+		return fmt.Errorf("delete admin: %w", err)
+	}
+
+	return expectAffected(res, repository.ErrAdminNotFound)
+}
+
+// expectAffected returns errNone when the statement did not change any row.
+func expectAffected(res sql.Result, errNone error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if affected == 0 {
+		return errNone
+	}
+
+	return nil
+}
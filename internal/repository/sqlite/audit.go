@@ -0,0 +1,203 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+func (r *RepoSQLite) AddAuditEntry(ctx context.Context, entry repository.AuditEntry) error {
+	before, err := marshalSnapshot(entry.Before)
+	if err != nil {
+		return err
+	}
+
+	after, err := marshalSnapshot(entry.After)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.ExecContext(
+		ctx,
+		`
+		INSERT INTO audit_log (
+			occurred_at, actor_type, actor_id, actor_name, source, chat_id, action, before, after, details
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		entry.OccurredAt.UnixNano(),
+		entry.ActorType,
+		entry.ActorID,
+		entry.ActorName,
+		entry.Source,
+		entry.ChatID,
+		entry.Action,
+		before,
+		after,
+		entry.Details,
+	); err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RepoSQLite) GetAuditEntries(
+	ctx context.Context,
+	filter repository.AuditFilter,
+) (_ []repository.AuditEntry, err error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.ChatID != nil {
+		conditions = append(conditions, "chat_id = ?")
+		args = append(args, *filter.ChatID)
+	}
+
+	if filter.ActorID != "" {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+
+	if filter.Source != "" {
+		conditions = append(conditions, "source = ?")
+		args = append(args, filter.Source)
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "occurred_at >= ?")
+		args = append(args, filter.From.UnixNano())
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "occurred_at < ?")
+		args = append(args, filter.To.UnixNano())
+	}
+
+	if filter.BeforeID > 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, filter.BeforeID)
+	}
+
+	query := `SELECT id, occurred_at, actor_type, actor_id, actor_name, source, chat_id, action, before, after, details
+		FROM audit_log`
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY id DESC"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query audit entries: %w", err)
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close rows: %w", closeErr)
+		}
+	}()
+
+	entries := make([]repository.AuditEntry, 0)
+
+	for rows.Next() {
+		var (
+			entry         repository.AuditEntry
+			occurredAt    int64
+			before, after sql.NullString
+		)
+
+		if err := rows.Scan(
+			&entry.ID,
+			&occurredAt,
+			&entry.ActorType,
+			&entry.ActorID,
+			&entry.ActorName,
+			&entry.Source,
+			&entry.ChatID,
+			&entry.Action,
+			&before,
+			&after,
+			&entry.Details,
+		); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+
+		entry.OccurredAt = time.Unix(0, occurredAt).UTC()
+
+		if entry.Before, err = unmarshalSnapshot(before); err != nil {
+			return nil, err
+		}
+
+		if entry.After, err = unmarshalSnapshot(after); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *RepoSQLite) DeleteAuditEntriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM audit_log WHERE occurred_at < ?", before.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("delete audit entries: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get affected rows: %w", err)
+	}
+
+	return deleted, nil
+}
+
+func marshalSnapshot(chat *repository.Chat) (*string, error) {
+	if chat == nil {
+		return nil, nil //nolint:nilnil // NULL column
+	}
+
+	data, err := json.Marshal(chat)
+	if err != nil {
+		return nil, fmt.Errorf("marshal chat snapshot: %w", err)
+	}
+
+	snapshot := string(data)
+
+	return &snapshot, nil
+}
+
+func unmarshalSnapshot(data sql.NullString) (*repository.Chat, error) {
+	if !data.Valid {
+		return nil, nil //nolint:nilnil // NULL column
+	}
+
+	var chat repository.Chat
+	if err := json.Unmarshal([]byte(data.String), &chat); err != nil {
+		return nil, fmt.Errorf("decode chat snapshot: %w", err)
+	}
+
+	return &chat, nil
+}
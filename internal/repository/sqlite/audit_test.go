@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := newTestRepo(t)
+	ctx := t.Context()
+
+	base := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	notifyTime := "09:00"
+
+	entries := []repository.AuditEntry{
+		{
+			OccurredAt: base,
+			ActorType:  "telegram",
+			ActorID:    "42",
+			ActorName:  "german",
+			Source:     "bot",
+			ChatID:     1,
+			Action:     "members.changed",
+			After:      &repository.Chat{ID: 1, Users: []string{"German"}},
+		},
+		{
+			OccurredAt: base.Add(time.Hour),
+			ActorType:  "admin",
+			ActorID:    "root",
+			Source:     "panel",
+			ChatID:     1,
+			Action:     "subscription.enabled",
+			Before:     &repository.Chat{ID: 1, Users: []string{"German"}},
+			After:      &repository.Chat{ID: 1, Users: []string{"German"}, NotifyTime: &notifyTime},
+		},
+		{
+			OccurredAt: base.Add(2 * time.Hour),
+			ActorType:  "admin",
+			ActorID:    "root",
+			Source:     "panel",
+			Action:     "auth.login_failed",
+			Details:    "ip 127.0.0.1: invalid credentials",
+		},
+	}
+
+	for _, entry := range entries {
+		require.NoError(t, repo.AddAuditEntry(ctx, entry))
+	}
+
+	all, err := repo.GetAuditEntries(ctx, repository.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	require.Equal(t, "auth.login_failed", all[0].Action)
+	require.Equal(t, base.Add(2*time.Hour), all[0].OccurredAt)
+	require.Nil(t, all[0].Before)
+	require.Equal(t, "09:00", *all[1].After.NotifyTime)
+	require.Equal(t, []string{"German"}, all[2].After.Users)
+
+	chatID := int64(1)
+
+	testCases := []struct {
+		name     string
+		filter   repository.AuditFilter
+		expected []string
+	}{
+		{
+			name:     "By chat",
+			filter:   repository.AuditFilter{ChatID: &chatID},
+			expected: []string{"subscription.enabled", "members.changed"},
+		},
+		{
+			name:     "By actor and source",
+			filter:   repository.AuditFilter{ActorID: "root", Source: "panel"},
+			expected: []string{"auth.login_failed", "subscription.enabled"},
+		},
+		{
+			name:     "By action",
+			filter:   repository.AuditFilter{Action: "members.changed"},
+			expected: []string{"members.changed"},
+		},
+		{
+			name:     "By time",
+			filter:   repository.AuditFilter{From: base.Add(time.Hour), To: base.Add(2 * time.Hour)},
+			expected: []string{"subscription.enabled"},
+		},
+		{
+			name:     "Page",
+			filter:   repository.AuditFilter{BeforeID: all[0].ID, Limit: 1},
+			expected: []string{"subscription.enabled"},
+		},
+	}
+
+	for _, tc := range testCases {
+		found, err := repo.GetAuditEntries(ctx, tc.filter)
+		require.NoError(t, err, tc.name)
+
+		actions := make([]string, 0, len(found))
+		for _, entry := range found {
+			actions = append(actions, entry.Action)
+		}
+
+		require.Equal(t, tc.expected, actions, tc.name)
+	}
+
+	deleted, err := repo.DeleteAuditEntriesBefore(ctx, base.Add(90*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+}
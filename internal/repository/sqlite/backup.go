@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var ErrBackupCorrupted = errors.New("backup file failed integrity check")
+
+// Backup writes a consistent copy of the database to path using VACUUM INTO.
+// It is safe to call while the database is in use. The file must not exist.
+func (r *RepoSQLite) Backup(ctx context.Context, path string) error {
+	if _, err := r.db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("vacuum into %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// Snapshot writes a consistent copy of the database to w.
+func (r *RepoSQLite) Snapshot(ctx context.Context, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "trash-bot-snapshot-")
+	if err != nil {
+		return fmt.Errorf("create snapshot dir: %w", err)
+	}
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	path := filepath.Join(dir, "snapshot.db")
+	if err := r.Backup(ctx, path); err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open snapshot: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if _, err := io.Copy(w, file); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Restore replaces the database file at dbPath with the backup at srcPath.
+// It must be called before the database is opened with New.
+func Restore(ctx context.Context, srcPath, dbPath string) error {
+	if err := checkIntegrity(ctx, srcPath); err != nil {
+		return err
+	}
+
+	tmpPath := dbPath + ".restore"
+	if err := copyFile(srcPath, tmpPath); err != nil {
+		return err
+	}
+
+	// Пока файл не заменён, журнал WAL нужен старой базе: удаляем его только после
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		return fmt.Errorf("replace db file: %w", err)
+	}
+
+	// Старые журналы относятся к заменённой базе и не должны примениться к новой
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove %s file: %w", suffix, err)
+		}
+	}
+
+	return nil
+}
+
+func checkIntegrity(ctx context.Context, path string) (err error) {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("stat backup file: %w", err)
+	}
+
+	dbConn, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("open backup file: %w", err)
+	}
+
+	defer func() {
+		if closeErr := dbConn.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close backup file: %w", closeErr)
+		}
+	}()
+
+	var result string
+	if err := dbConn.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("%w: %w", ErrBackupCorrupted, err)
+	}
+
+	if result != "ok" {
+		return fmt.Errorf("%w: %s", ErrBackupCorrupted, result)
+	}
+
+	return nil
+}
+
+func copyFile(srcPath, dstPath string) (err error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("open %q: %w", srcPath, err)
+	}
+
+	defer func() {
+		_ = src.Close()
+	}()
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return fmt.Errorf("create %q: %w", dstPath, err)
+	}
+
+	defer func() {
+		if closeErr := dst.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close %q: %w", dstPath, closeErr)
+		}
+	}()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("copy %q: %w", srcPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepo(t *testing.T) (*RepoSQLite, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "trash.db")
+
+	repo, err := New(path)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = repo.Close()
+	})
+
+	return repo, path
+}
+
+func TestBackupRestore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Restore brings back backed up state", func(t *testing.T) {
+		t.Parallel()
+
+		repo, _ := newTestRepo(t)
+		ctx := t.Context()
+
+		require.NoError(t, repo.SetEstablish(ctx, 1, []string{"German", "Anthon"}))
+		require.NoError(t, repo.SetNext(ctx, 1))
+
+		backupPath := filepath.Join(t.TempDir(), "backup.db")
+		require.NoError(t, repo.Backup(ctx, backupPath))
+
+		// Изменения после бэкапа не должны попасть в восстановленную базу
+		require.NoError(t, repo.SetEstablish(ctx, 2, []string{"Vitaly"}))
+
+		restoredPath := filepath.Join(t.TempDir(), "restored.db")
+		require.NoError(t, Restore(ctx, backupPath, restoredPath))
+
+		restored, err := New(restoredPath)
+		require.NoError(t, err)
+
+		defer func() {
+			_ = restored.Close()
+		}()
+
+		chats, err := restored.GetChats(ctx)
+		require.NoError(t, err)
+		require.Len(t, chats, 1)
+
+		username, err := restored.GetCurrent(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, "Anthon", username)
+	})
+
+	t.Run("Failed replace keeps the WAL", func(t *testing.T) {
+		t.Parallel()
+
+		repo, _ := newTestRepo(t)
+		ctx := t.Context()
+
+		backupPath := filepath.Join(t.TempDir(), "backup.db")
+		require.NoError(t, repo.Backup(ctx, backupPath))
+
+		// Каталог на месте файла базы не даёт её заменить
+		dbPath := filepath.Join(t.TempDir(), "trash.db")
+		require.NoError(t, os.MkdirAll(filepath.Join(dbPath, "busy"), 0o700))
+		require.NoError(t, os.WriteFile(dbPath+"-wal", []byte("wal"), 0o600))
+
+		require.Error(t, Restore(ctx, backupPath, dbPath))
+		require.FileExists(t, dbPath+"-wal")
+	})
+
+	t.Run("Snapshot writes database", func(t *testing.T) {
+		t.Parallel()
+
+		repo, _ := newTestRepo(t)
+		ctx := t.Context()
+
+		require.NoError(t, repo.SetEstablish(ctx, 1, []string{"German"}))
+
+		var buf bytes.Buffer
+		require.NoError(t, repo.Snapshot(ctx, &buf))
+
+		require.True(t, bytes.HasPrefix(buf.Bytes(), []byte("SQLite format 3\x00")))
+	})
+
+	t.Run("Restore rejects corrupted file", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+
+		backupPath := filepath.Join(t.TempDir(), "broken.db")
+		require.NoError(t, os.WriteFile(backupPath, []byte("definitely not sqlite"), 0o600))
+
+		dbPath := filepath.Join(t.TempDir(), "trash.db")
+		err := Restore(ctx, backupPath, dbPath)
+		require.ErrorIs(t, err, ErrBackupCorrupted)
+		require.NoFileExists(t, dbPath)
+	})
+}
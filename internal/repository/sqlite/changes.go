@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+func (r *RepoSQLite) AddChange(ctx context.Context, change repository.Change) error {
+	if _, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO changes (origin, occurred_at, chat_id, type, payload) VALUES (?, ?, ?, ?, ?)",
+		change.Origin,
+		change.OccurredAt.UnixNano(),
+		change.ChatID,
+		change.Type,
+		string(change.Payload),
+	); err != nil {
+		return fmt.Errorf("insert change: %w", err)
+	}
+
+	return nil
+}
+
+// GetChangesAfter returns at most limit changes with ids greater than afterID, oldest first.
+func (r *RepoSQLite) GetChangesAfter(ctx context.Context, afterID int64, limit int) (_ []repository.Change, err error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id, origin, occurred_at, chat_id, type, payload FROM changes WHERE id > ? ORDER BY id LIMIT ?",
+		afterID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query changes: %w", err)
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close rows: %w", closeErr)
+		}
+	}()
+
+	changes := make([]repository.Change, 0)
+
+	for rows.Next() {
+		var (
+			change     repository.Change
+			occurredAt int64
+			payload    string
+		)
+
+		if err := rows.Scan(
+			&change.ID, &change.Origin, &occurredAt, &change.ChatID, &change.Type, &payload,
+		); err != nil {
+			return nil, fmt.Errorf("scan change: %w", err)
+		}
+
+		change.OccurredAt = time.Unix(0, occurredAt).UTC()
+		change.Payload = []byte(payload)
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// LastChangeID returns the id of the newest change, 0 when there are none.
+func (r *RepoSQLite) LastChangeID(ctx context.Context) (int64, error) {
+	var id int64
+	if err := r.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM changes").Scan(&id); err != nil {
+		return 0, fmt.Errorf("select last change id: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *RepoSQLite) DeleteChangesBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM changes WHERE occurred_at < ?", before.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("delete changes: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get affected rows: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChanges(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := newTestRepo(t)
+	ctx := t.Context()
+
+	last, err := repo.LastChangeID(ctx)
+	require.NoError(t, err)
+	require.Zero(t, last)
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for ind := range 3 {
+		require.NoError(t, repo.AddChange(ctx, repository.Change{
+			Origin:     "bot",
+			OccurredAt: start.Add(time.Duration(ind) * time.Minute),
+			ChatID:     int64(ind),
+			Type:       "rotation.advanced",
+			Payload:    []byte(`{"ChatID":1}`),
+		}))
+	}
+
+	last, err = repo.LastChangeID(ctx)
+	require.NoError(t, err)
+
+	changes, err := repo.GetChangesAfter(ctx, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, repository.Change{
+		ID:         changes[0].ID,
+		Origin:     "bot",
+		OccurredAt: start,
+		ChatID:     0,
+		Type:       "rotation.advanced",
+		Payload:    []byte(`{"ChatID":1}`),
+	}, changes[0])
+
+	changes, err = repo.GetChangesAfter(ctx, changes[1].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, last, changes[0].ID)
+
+	deleted, err := repo.DeleteChangesBefore(ctx, start.Add(time.Minute))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, deleted)
+
+	// Номера удалённых изменений не переиспользуются
+	require.NoError(t, repo.AddChange(ctx, repository.Change{Origin: "panel", OccurredAt: start, Payload: []byte("{}")}))
+
+	changes, err = repo.GetChangesAfter(ctx, last, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Greater(t, changes[0].ID, last)
+}
+
+// TestSharedFile checks that processes sharing the file, here two
+// connections, do not lose each other's rotation moves.
+func TestSharedFile(t *testing.T) {
+	t.Parallel()
+
+	bot, path := newTestRepo(t)
+
+	panel, err := New(path)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = panel.Close()
+	})
+
+	ctx := t.Context()
+
+	var mode string
+	require.NoError(t, panel.db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode))
+	require.Equal(t, "wal", mode)
+
+	require.NoError(t, bot.SetEstablish(ctx, 1, []string{"a", "b", "c"}))
+
+	const moves = 20
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 2*moves)
+
+	for _, repo := range []*RepoSQLite{bot, panel} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range moves {
+				errs <- repo.SetNext(ctx, 1)
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	chat, err := panel.GetChat(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 2*moves%3, chat.Current)
+
+	require.NoError(t, bot.SetPrev(ctx, 1))
+
+	chat, err = panel.GetChat(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, (2*moves-1)%3, chat.Current)
+
+	require.NoError(t, bot.SetEstablish(ctx, 2, []string{}))
+	require.ErrorIs(t, bot.SetNext(ctx, 2), repository.ErrChatIsEmpty)
+	require.ErrorIs(t, bot.SetPrev(ctx, 3), repository.ErrChatIsNotInitialize)
+}
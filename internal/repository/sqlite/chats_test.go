@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindChats checks that SQLite selects the same chats as the in-memory
+// implementation of the query.
+func TestFindChats(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := newTestRepo(t)
+	ctx := t.Context()
+
+	morning, evening := "09:00", "21:00"
+
+	chats := []repository.Chat{
+		{ID: -100, Users: []string{"Герман", "Антон"}, NotifyTime: &morning},
+		{ID: 1, Users: []string{}},
+		{ID: 2, Users: []string{"German", "Vitaly", "Anthon"}},
+		{ID: 3, Users: []string{"vitaly"}, NotifyTime: &evening},
+		{ID: 4, Users: []string{"Oleg", "Ivan"}, NotifyTime: &morning},
+		{ID: 5, Users: []string{}, NotifyTime: &evening},
+	}
+
+	for _, chat := range chats {
+		require.NoError(t, repo.SaveChat(ctx, chat))
+	}
+
+	yes, no := true, false
+
+	queries := map[string]repository.ChatQuery{
+		"All":                   {},
+		"Subscribed":            {Subscribed: &yes},
+		"Not subscribed":        {Subscribed: &no},
+		"Empty":                 {Empty: &yes},
+		"Not empty":             {Empty: &no},
+		"Member ignores case":   {Member: "VITAL"},
+		"Member in cyrillic":    {Member: "гер"},
+		"By members desc":       {Sort: repository.ChatSortMembers, Desc: true},
+		"By notify time":        {Sort: repository.ChatSortNotifyTime},
+		"By notify time desc":   {Sort: repository.ChatSortNotifyTime, Desc: true, Empty: &no},
+		"Limit":                 {Sort: repository.ChatSortMembers, Limit: 2},
+		"After cursor":          {After: &repository.ChatCursor{ID: 2}},
+		"After cursor with key": {Sort: repository.ChatSortMembers, After: &repository.ChatCursor{ID: 3, Members: 1}},
+	}
+
+	for name, query := range queries {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			found, err := repo.FindChats(t.Context(), query)
+			require.NoError(t, err)
+			require.Equal(t, query.Apply(chats), found)
+		})
+	}
+
+	t.Run("Pages cover every chat once", func(t *testing.T) {
+		t.Parallel()
+
+		query := repository.ChatQuery{Sort: repository.ChatSortNotifyTime, Desc: true, Limit: 2}
+
+		var seen []repository.Chat
+
+		for {
+			page, err := repo.FindChats(t.Context(), query)
+			require.NoError(t, err)
+
+			seen = append(seen, page...)
+
+			if len(page) < query.Limit {
+				break
+			}
+
+			cursor := repository.CursorOf(page[len(page)-1])
+			query.After = &cursor
+		}
+
+		query.After, query.Limit = nil, 0
+		require.Equal(t, query.Apply(chats), seen)
+	})
+}
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := newTestRepo(t)
+	require.NoError(t, repo.Ping(t.Context()))
+
+	require.NoError(t, repo.Close())
+	require.Error(t, repo.Ping(t.Context()))
+}
+
+func TestImportChats(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := newTestRepo(t)
+	ctx := t.Context()
+
+	require.NoError(t, repo.SaveChat(ctx, repository.Chat{ID: 1, Users: []string{"German"}}))
+	require.NoError(t, repo.SaveChat(ctx, repository.Chat{ID: 2, Users: []string{"Anthon"}}))
+
+	// Запись третьего чата падает посреди импорта
+	_, err := repo.db.ExecContext(ctx, `
+	CREATE TRIGGER fail_import BEFORE INSERT ON chats WHEN NEW.id = 3
+	BEGIN SELECT RAISE(ABORT, 'disk is full'); END`)
+	require.NoError(t, err)
+
+	imported := []repository.Chat{
+		{ID: 1, Users: []string{"Vitaly"}},
+		{ID: 3, Users: []string{"Oleg"}},
+	}
+
+	err = repo.ImportChats(ctx, imported, []int64{2})
+	require.ErrorContains(t, err, "disk is full")
+
+	chats, err := repo.GetChats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []repository.Chat{
+		{ID: 1, Users: []string{"German"}},
+		{ID: 2, Users: []string{"Anthon"}},
+	}, chats, "nothing is written")
+
+	_, err = repo.db.ExecContext(ctx, "DROP TRIGGER fail_import")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.ImportChats(ctx, imported, []int64{2}))
+
+	chats, err = repo.GetChats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []repository.Chat{
+		{ID: 1, Users: []string{"Vitaly"}},
+		{ID: 3, Users: []string{"Oleg"}},
+	}, chats)
+}
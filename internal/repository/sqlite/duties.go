@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+func (r *RepoSQLite) AddDuty(ctx context.Context, duty repository.Duty) error {
+	if _, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO duties (chat_id, member, occurred_at, skipped) VALUES (?, ?, ?, ?)",
+		duty.ChatID,
+		duty.Member,
+		duty.OccurredAt.UnixNano(),
+		duty.Skipped,
+	); err != nil {
+		return fmt.Errorf("insert duty: %w", err)
+	}
+
+	return nil
+}
+
+// GetDuties returns the duty history of the chat, oldest first.
+func (r *RepoSQLite) GetDuties(ctx context.Context, chatID int64) ([]repository.Duty, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT chat_id, member, occurred_at, skipped FROM duties WHERE chat_id = ? ORDER BY id",
+		chatID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query duties: %w", err)
+	}
+
+	return scanDuties(rows)
+}
+
+// GetDutiesSince returns the duties of all chats, deleted chats included,
+// that occurred at since or later, oldest first.
+func (r *RepoSQLite) GetDutiesSince(ctx context.Context, since time.Time) ([]repository.Duty, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT chat_id, member, occurred_at, skipped FROM duties WHERE occurred_at >= ? ORDER BY id",
+		since.UnixNano(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query duties: %w", err)
+	}
+
+	return scanDuties(rows)
+}
+
+func scanDuties(rows *sql.Rows) (_ []repository.Duty, err error) {
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close rows: %w", closeErr)
+		}
+	}()
+
+	duties := make([]repository.Duty, 0)
+
+	for rows.Next() {
+		var (
+			duty       repository.Duty
+			occurredAt int64
+		)
+
+		if err := rows.Scan(&duty.ChatID, &duty.Member, &occurredAt, &duty.Skipped); err != nil {
+			return nil, fmt.Errorf("scan duty: %w", err)
+		}
+
+		duty.OccurredAt = time.Unix(0, occurredAt).UTC()
+		duties = append(duties, duty)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate duties: %w", err)
+	}
+
+	return duties, nil
+}
@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuties(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := newTestRepo(t)
+	ctx := t.Context()
+
+	base := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	duties := []repository.Duty{
+		{ChatID: 1, Member: "German", OccurredAt: base},
+		{ChatID: 2, Member: "Vitaly", OccurredAt: base},
+		{ChatID: 1, Member: "Anthon", OccurredAt: base.Add(time.Hour)},
+		{ChatID: 1, Member: "Anthon", OccurredAt: base.Add(2 * time.Hour), Skipped: true},
+	}
+
+	for _, duty := range duties {
+		require.NoError(t, repo.AddDuty(ctx, duty))
+	}
+
+	stored, err := repo.GetDuties(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []repository.Duty{duties[0], duties[2], duties[3]}, stored)
+
+	stored, err = repo.GetDuties(ctx, 3)
+	require.NoError(t, err)
+	require.Empty(t, stored)
+
+	stored, err = repo.GetDutiesSince(ctx, base.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, []repository.Duty{duties[2], duties[3]}, stored)
+}
@@ -3,20 +3,58 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/6ermvH/trash-bot/internal/repository"
-	_ "modernc.org/sqlite"
+	"modernc.org/sqlite"
 )
 
+// pragmas are set on every connection. WAL lets a process read while another
+// one writes, so the bot and the panel can share the file, and a writer waits
+// up to the busy timeout for the lock instead of failing with SQLITE_BUSY.
+var pragmas = url.Values{"_pragma": {
+	"busy_timeout(" + strconv.Itoa(int(busyTimeout.Milliseconds())) + ")",
+	"journal_mode(WAL)",
+	"synchronous(NORMAL)",
+}}
+
+const busyTimeout = 5 * time.Second
+
+// lowerFunc is strings.ToLower for SQL: the built-in lower() folds only ASCII
+// letters, and member names are mostly not in English.
+const lowerFunc = "go_lower"
+
+var registerFunctions = sync.OnceValue(func() error {
+	// Функции доступны соединениям, открытым после регистрации
+	return sqlite.RegisterDeterministicScalarFunction(lowerFunc, 1,
+		func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			text, ok := args[0].(string)
+			if !ok {
+				return args[0], nil
+			}
+
+			return strings.ToLower(text), nil
+		})
+})
+
 type RepoSQLite struct {
-	db *sql.DB
+	db *tracedDB
 }
 
 func New(dbPath string) (*RepoSQLite, error) {
-	dbConn, err := sql.Open("sqlite", dbPath)
+	if err := registerFunctions(); err != nil {
+		return nil, fmt.Errorf("register sqlite functions: %w", err)
+	}
+
+	dbConn, err := sql.Open("sqlite", dbPath+"?"+pragmas.Encode())
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite db: %w", err)
 	}
@@ -26,7 +64,7 @@ func New(dbPath string) (*RepoSQLite, error) {
 		return nil, fmt.Errorf("ping sqlite db: %w", err)
 	}
 
-	repo := &RepoSQLite{db: dbConn}
+	repo := &RepoSQLite{db: &tracedDB{DB: dbConn}}
 	if err := repo.migrate(ctx); err != nil {
 		return nil, fmt.Errorf("migrate sqlite db: %w", err)
 	}
@@ -42,10 +80,99 @@ func (r *RepoSQLite) Close() error {
 	return nil
 }
 
+// Ping checks that the database file can be used, it is the readiness check.
+func (r *RepoSQLite) Ping(ctx context.Context) error {
+	var one int
+	if err := r.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("ping sqlite db: %w", err)
+	}
+
+	return nil
+}
+
 func (r *RepoSQLite) GetChats(ctx context.Context) ([]repository.Chat, error) {
 	return r.queryChats(ctx, "SELECT id, current, users, notify_time FROM chats")
 }
 
+// FindChats selects chats with the same semantics as repository.ChatQuery.Apply.
+func (r *RepoSQLite) FindChats(ctx context.Context, query repository.ChatQuery) ([]repository.Chat, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if query.Subscribed != nil {
+		if *query.Subscribed {
+			conditions = append(conditions, "notify_time IS NOT NULL")
+		} else {
+			conditions = append(conditions, "notify_time IS NULL")
+		}
+	}
+
+	if query.Empty != nil {
+		if *query.Empty {
+			conditions = append(conditions, "json_array_length(users) = 0")
+		} else {
+			conditions = append(conditions, "json_array_length(users) > 0")
+		}
+	}
+
+	if query.Member != "" {
+		conditions = append(conditions,
+			"EXISTS (SELECT 1 FROM json_each(chats.users) WHERE instr("+lowerFunc+"(json_each.value), ?) > 0)")
+		args = append(args, strings.ToLower(query.Member))
+	}
+
+	key, keyArg := sortKey(query)
+
+	direction, compare := "ASC", ">"
+	if query.Desc {
+		direction, compare = "DESC", "<"
+	}
+
+	if query.After != nil {
+		if key == "" {
+			conditions = append(conditions, "id "+compare+" ?")
+			args = append(args, query.After.ID)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", key, compare))
+			args = append(args, keyArg(*query.After), keyArg(*query.After), query.After.ID)
+		}
+	}
+
+	statement := "SELECT id, current, users, notify_time FROM chats"
+
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if key != "" {
+		statement += " ORDER BY " + key + " " + direction + ", id " + direction
+	} else {
+		statement += " ORDER BY id " + direction
+	}
+
+	if query.Limit > 0 {
+		statement += " LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	return r.queryChats(ctx, statement, args...)
+}
+
+// sortKey returns the SQL expression of the sort key of the query and its value
+// in the cursor, the key is empty when chats are sorted by id only.
+func sortKey(query repository.ChatQuery) (string, func(repository.ChatCursor) any) {
+	switch query.Sort {
+	case repository.ChatSortMembers:
+		return "json_array_length(users)", func(cursor repository.ChatCursor) any { return cursor.Members }
+	case repository.ChatSortNotifyTime:
+		return "COALESCE(notify_time, '')", func(cursor repository.ChatCursor) any { return cursor.NotifyTime }
+	default:
+		return "", nil
+	}
+}
+
 func (r *RepoSQLite) GetChat(ctx context.Context, chatID int64) (*repository.Chat, error) {
 	var (
 		chat       repository.Chat
@@ -90,51 +217,40 @@ func (r *RepoSQLite) GetCurrent(ctx context.Context, chatID int64) (string, erro
 }
 
 func (r *RepoSQLite) SetNext(ctx context.Context, chatID int64) error {
-	chat, err := r.GetChat(ctx, chatID)
-	if err != nil {
-		return err
-	}
-
-	if len(chat.Users) == 0 {
-		return repository.ErrChatIsEmpty
-	}
+	return r.moveCurrent(ctx, chatID, "(current + 1) % json_array_length(users)")
+}
 
-	newCurrent := (chat.Current + 1) % len(chat.Users)
+func (r *RepoSQLite) SetPrev(ctx context.Context, chatID int64) error {
+	return r.moveCurrent(ctx, chatID, "(current + json_array_length(users) - 1) % json_array_length(users)")
+}
 
-	if _, err := r.db.ExecContext(
+// moveCurrent sets current to the expression in one statement, so that moves
+// made at once by the bot and the panel processes are not lost.
+func (r *RepoSQLite) moveCurrent(ctx context.Context, chatID int64, current string) error {
+	res, err := r.db.ExecContext(
 		ctx,
-		"UPDATE chats SET current = ? WHERE id = ?",
-		newCurrent,
+		"UPDATE chats SET current = "+current+" WHERE id = ? AND json_array_length(users) > 0",
 		chatID,
-	); err != nil {
+	)
+	if err != nil {
 		return fmt.Errorf("update current: %w", err)
 	}
 
-	return nil
-}
-
-func (r *RepoSQLite) SetPrev(ctx context.Context, chatID int64) error {
-	chat, err := r.GetChat(ctx, chatID)
+	updated, err := res.RowsAffected()
 	if err != nil {
-		return err
+		return fmt.Errorf("get affected rows: %w", err)
 	}
 
-	if len(chat.Users) == 0 {
-		return repository.ErrChatIsEmpty
+	if updated > 0 {
+		return nil
 	}
 
-	newCurrent := (len(chat.Users) + chat.Current - 1) % len(chat.Users)
-
-	if _, err := r.db.ExecContext(
-		ctx,
-		"UPDATE chats SET current = ? WHERE id = ?",
-		newCurrent,
-		chatID,
-	); err != nil {
-		return fmt.Errorf("update current: %w", err)
+	// Чат не найден или пуст, ошибку вернёт GetChat
+	if _, err := r.GetChat(ctx, chatID); err != nil {
+		return err
 	}
 
-	return nil
+	return repository.ErrChatIsEmpty
 }
 
 func (r *RepoSQLite) SetEstablish(ctx context.Context, chatID int64, users []string) error {
@@ -184,6 +300,87 @@ func (r *RepoSQLite) Unsubscribe(ctx context.Context, chatID int64) error {
 	return nil
 }
 
+func (r *RepoSQLite) SaveChat(ctx context.Context, chat repository.Chat) error {
+	return saveChat(ctx, r.db, chat)
+}
+
+func (r *RepoSQLite) DeleteChat(ctx context.Context, chatID int64) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM chats WHERE id = ?", chatID); err != nil {
+		return fmt.Errorf("delete chat: %w", err)
+	}
+
+	return nil
+}
+
+// ImportChats saves chats and deletes the chats with deleteIDs in one
+// transaction, on error the stored chats stay as they were.
+func (r *RepoSQLite) ImportChats(ctx context.Context, chats []repository.Chat, deleteIDs []int64) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin import: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, chat := range chats {
+		if err := saveChat(ctx, tx, chat); err != nil {
+			return fmt.Errorf("chat %d: %w", chat.ID, err)
+		}
+	}
+
+	for _, chatID := range deleteIDs {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM chats WHERE id = ?", chatID); err != nil {
+			return fmt.Errorf("delete chat %d: %w", chatID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit import: %w", err)
+	}
+
+	return nil
+}
+
+// execer runs statements on the database or in a transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func saveChat(ctx context.Context, db execer, chat repository.Chat) error {
+	users := chat.Users
+	if users == nil {
+		users = []string{}
+	}
+
+	usersJSON, err := json.Marshal(users)
+	if err != nil {
+		return fmt.Errorf("marshal users: %w", err)
+	}
+
+	if _, err := db.ExecContext(
+		ctx,
+		`
+		INSERT INTO chats (id, current, users, notify_time) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			current = excluded.current,
+			users = excluded.users,
+			notify_time = excluded.notify_time
+	`,
+		chat.ID,
+		chat.Current,
+		string(usersJSON),
+		chat.NotifyTime,
+	); err != nil {
+		return fmt.Errorf("save chat: %w", err)
+	}
+
+	return nil
+}
+
 func (r *RepoSQLite) GetSubscribedChats(ctx context.Context) ([]repository.Chat, error) {
 	return r.queryChats(
 		ctx,
@@ -194,8 +391,9 @@ func (r *RepoSQLite) GetSubscribedChats(ctx context.Context) ([]repository.Chat,
 func (r *RepoSQLite) queryChats(
 	ctx context.Context,
 	query string,
+	args ...any,
 ) (_ []repository.Chat, err error) {
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query chats: %w", err)
 	}
@@ -255,5 +453,102 @@ func (r *RepoSQLite) migrate(ctx context.Context) error {
 	// Игнорируем ошибку, если колонка уже существует
 	_, _ = r.db.ExecContext(ctx, addColumn)
 
+	createAdmins := `
+	CREATE TABLE IF NOT EXISTS admins (
+		login TEXT PRIMARY KEY,
+		password_hash TEXT NOT NULL,
+		role TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);`
+
+	if _, err := r.db.ExecContext(ctx, createAdmins); err != nil {
+		return fmt.Errorf("exec create admins table migration: %w", err)
+	}
+
+	createTokens := `
+	CREATE TABLE IF NOT EXISTS refresh_tokens (
+		hash TEXT PRIMARY KEY,
+		family TEXT NOT NULL,
+		login TEXT NOT NULL,
+		telegram_id INTEGER NOT NULL DEFAULT 0,
+		telegram_username TEXT NOT NULL DEFAULT '',
+		expires_at INTEGER NOT NULL,
+		used_at INTEGER DEFAULT NULL
+	);
+	CREATE INDEX IF NOT EXISTS refresh_tokens_family ON refresh_tokens (family);
+	CREATE TABLE IF NOT EXISTS revoked_tokens (
+		jti TEXT PRIMARY KEY,
+		expires_at INTEGER NOT NULL
+	);`
+
+	if _, err := r.db.ExecContext(ctx, createTokens); err != nil {
+		return fmt.Errorf("exec create tokens tables migration: %w", err)
+	}
+
+	createAudit := `
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		occurred_at INTEGER NOT NULL,
+		actor_type TEXT NOT NULL,
+		actor_id TEXT NOT NULL,
+		actor_name TEXT NOT NULL,
+		source TEXT NOT NULL,
+		chat_id INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		before TEXT DEFAULT NULL,
+		after TEXT DEFAULT NULL,
+		details TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS audit_log_chat_id ON audit_log (chat_id);
+	CREATE INDEX IF NOT EXISTS audit_log_occurred_at ON audit_log (occurred_at);`
+
+	if _, err := r.db.ExecContext(ctx, createAudit); err != nil {
+		return fmt.Errorf("exec create audit table migration: %w", err)
+	}
+
+	createStats := `
+	CREATE TABLE IF NOT EXISTS stat_counts (
+		day TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		chat_id INTEGER NOT NULL DEFAULT 0,
+		key TEXT NOT NULL DEFAULT '',
+		count INTEGER NOT NULL,
+		PRIMARY KEY (day, metric, chat_id, key)
+	);`
+
+	if _, err := r.db.ExecContext(ctx, createStats); err != nil {
+		return fmt.Errorf("exec create stats table migration: %w", err)
+	}
+
+	createDuties := `
+	CREATE TABLE IF NOT EXISTS duties (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat_id INTEGER NOT NULL,
+		member TEXT NOT NULL,
+		occurred_at INTEGER NOT NULL,
+		skipped INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS duties_chat_id ON duties (chat_id);
+	CREATE INDEX IF NOT EXISTS duties_occurred_at ON duties (occurred_at);`
+
+	if _, err := r.db.ExecContext(ctx, createDuties); err != nil {
+		return fmt.Errorf("exec create duties table migration: %w", err)
+	}
+
+	createChanges := `
+	CREATE TABLE IF NOT EXISTS changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		origin TEXT NOT NULL,
+		occurred_at INTEGER NOT NULL,
+		chat_id INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS changes_occurred_at ON changes (occurred_at);`
+
+	if _, err := r.db.ExecContext(ctx, createChanges); err != nil {
+		return fmt.Errorf("exec create changes table migration: %w", err)
+	}
+
 	return nil
 }
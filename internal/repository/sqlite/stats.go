@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+func (r *RepoSQLite) AddStatCount(ctx context.Context, count repository.StatCount) error {
+	if _, err := r.db.ExecContext(
+		ctx,
+		`
+		INSERT INTO stat_counts (day, metric, chat_id, key, count) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (day, metric, chat_id, key) DO UPDATE SET count = count + excluded.count
+	`,
+		count.Day,
+		count.Metric,
+		count.ChatID,
+		count.Key,
+		count.Count,
+	); err != nil {
+		return fmt.Errorf("add stat count: %w", err)
+	}
+
+	return nil
+}
+
+// GetStatCounts returns counters of days from since on, oldest first.
+func (r *RepoSQLite) GetStatCounts(ctx context.Context, since string) (_ []repository.StatCount, err error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT day, metric, chat_id, key, count FROM stat_counts WHERE day >= ? ORDER BY day",
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query stat counts: %w", err)
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close rows: %w", closeErr)
+		}
+	}()
+
+	counts := make([]repository.StatCount, 0)
+
+	for rows.Next() {
+		var count repository.StatCount
+
+		if err := rows.Scan(&count.Day, &count.Metric, &count.ChatID, &count.Key, &count.Count); err != nil {
+			return nil, fmt.Errorf("scan stat count: %w", err)
+		}
+
+		counts = append(counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate stat counts: %w", err)
+	}
+
+	return counts, nil
+}
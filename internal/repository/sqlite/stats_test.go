@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatCounts(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := newTestRepo(t)
+	ctx := t.Context()
+
+	counts := []repository.StatCount{
+		{Day: "2025-01-09", Metric: "duty", ChatID: 1, Key: "German", Count: 1},
+		{Day: "2025-01-10", Metric: "duty", ChatID: 1, Key: "German", Count: 1},
+		{Day: "2025-01-10", Metric: "duty", ChatID: 1, Key: "German", Count: 1},
+		{Day: "2025-01-10", Metric: "duty", ChatID: 1, Key: "Anthon", Count: 1},
+		{Day: "2025-01-10", Metric: "duty", ChatID: 1, Key: "Anthon", Count: -1},
+		{Day: "2025-01-10", Metric: "reminder.sent", ChatID: 2, Count: 1},
+	}
+
+	for _, count := range counts {
+		require.NoError(t, repo.AddStatCount(ctx, count))
+	}
+
+	stored, err := repo.GetStatCounts(ctx, "2025-01-10")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []repository.StatCount{
+		{Day: "2025-01-10", Metric: "duty", ChatID: 1, Key: "German", Count: 2},
+		{Day: "2025-01-10", Metric: "duty", ChatID: 1, Key: "Anthon", Count: 0},
+		{Day: "2025-01-10", Metric: "reminder.sent", ChatID: 2, Count: 1},
+	}, stored)
+
+	stored, err = repo.GetStatCounts(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, stored, 4)
+	require.Equal(t, "2025-01-09", stored[0].Day, "oldest first")
+}
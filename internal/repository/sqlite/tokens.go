@@ -0,0 +1,144 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+func (r *RepoSQLite) CreateRefreshToken(ctx context.Context, token repository.RefreshToken) error {
+	if _, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO refresh_tokens (hash, family, login, telegram_id, telegram_username, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		token.Hash,
+		token.Family,
+		token.Login,
+		token.TelegramID,
+		token.TelegramUsername,
+		token.ExpiresAt.Unix(),
+	); err != nil {
+		return fmt.Errorf("insert refresh token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RepoSQLite) GetRefreshToken(ctx context.Context, hash string) (*repository.RefreshToken, error) {
+	var (
+		token     repository.RefreshToken
+		expiresAt int64
+		usedAt    sql.NullInt64
+	)
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT hash, family, login, telegram_id, telegram_username, expires_at, used_at
+		FROM refresh_tokens WHERE hash = ?`, hash,
+	).Scan(
+		&token.Hash,
+		&token.Family,
+		&token.Login,
+		&token.TelegramID,
+		&token.TelegramUsername,
+		&expiresAt,
+		&usedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrTokenNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("query refresh token: %w", err)
+	}
+
+	token.ExpiresAt = time.Unix(expiresAt, 0).UTC()
+
+	if usedAt.Valid {
+		used := time.Unix(usedAt.Int64, 0).UTC()
+		token.UsedAt = &used
+	}
+
+	return &token, nil
+}
+
+func (r *RepoSQLite) MarkRefreshTokenUsed(ctx context.Context, hash string, usedAt time.Time) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE refresh_tokens SET used_at = ? WHERE hash = ? AND used_at IS NULL",
+		usedAt.Unix(),
+		hash,
+	)
+	if err != nil {
+		return fmt.Errorf("mark refresh token used: %w", err)
+	}
+
+	if err := expectAffected(res, repository.ErrTokenAlreadyUsed); err != nil {
+		if _, getErr := r.GetRefreshToken(ctx, hash); getErr != nil {
+			return getErr
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *RepoSQLite) RevokeRefreshFamily(ctx context.Context, family string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE family = ?", family); err != nil {
+		return fmt.Errorf("delete refresh token family: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RepoSQLite) RevokeAdminRefreshTokens(ctx context.Context, login string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE login = ?", login); err != nil {
+		return fmt.Errorf("delete refresh tokens of admin: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RepoSQLite) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if _, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO revoked_tokens (jti, expires_at) VALUES (?, ?) ON CONFLICT(jti) DO NOTHING",
+		jti,
+		expiresAt.Unix(),
+	); err != nil {
+		return fmt.Errorf("insert revoked token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RepoSQLite) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var exists bool
+
+	if err := r.db.QueryRowContext(
+		ctx,
+		"SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = ?)",
+		jti,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("query revoked token: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (r *RepoSQLite) DeleteExpiredTokens(ctx context.Context, now time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires_at <= ?", now.Unix()); err != nil {
+		return fmt.Errorf("delete expired refresh tokens: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= ?", now.Unix()); err != nil {
+		return fmt.Errorf("delete expired revoked tokens: %w", err)
+	}
+
+	return nil
+}
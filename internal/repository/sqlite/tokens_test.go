@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokens(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := newTestRepo(t)
+	ctx := t.Context()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(t, repo.CreateRefreshToken(ctx, repository.RefreshToken{
+		Hash: "a", Family: "f1", Login: "german", ExpiresAt: now.Add(time.Hour),
+	}))
+	require.NoError(t, repo.CreateRefreshToken(ctx, repository.RefreshToken{
+		Hash: "b", Family: "f2", Login: "german", ExpiresAt: now.Add(-time.Hour),
+	}))
+
+	token, err := repo.GetRefreshToken(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "f1", token.Family)
+	require.Equal(t, now.Add(time.Hour), token.ExpiresAt)
+	require.Nil(t, token.UsedAt)
+
+	require.NoError(t, repo.MarkRefreshTokenUsed(ctx, "a", now))
+	require.ErrorIs(t, repo.MarkRefreshTokenUsed(ctx, "a", now), repository.ErrTokenAlreadyUsed)
+	require.ErrorIs(t, repo.MarkRefreshTokenUsed(ctx, "missing", now), repository.ErrTokenNotFound)
+
+	token, err = repo.GetRefreshToken(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, now, *token.UsedAt)
+
+	require.NoError(t, repo.DeleteExpiredTokens(ctx, now))
+
+	_, err = repo.GetRefreshToken(ctx, "b")
+	require.ErrorIs(t, err, repository.ErrTokenNotFound)
+
+	require.NoError(t, repo.RevokeRefreshFamily(ctx, "f1"))
+
+	_, err = repo.GetRefreshToken(ctx, "a")
+	require.ErrorIs(t, err, repository.ErrTokenNotFound)
+}
+
+func TestRevokedAccessTokens(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := newTestRepo(t)
+	ctx := t.Context()
+	now := time.Now().UTC()
+
+	revoked, err := repo.IsAccessTokenRevoked(ctx, "jti")
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, repo.RevokeAccessToken(ctx, "jti", now.Add(time.Minute)))
+	require.NoError(t, repo.RevokeAccessToken(ctx, "jti", now.Add(time.Minute)))
+
+	revoked, err = repo.IsAccessTokenRevoked(ctx, "jti")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	require.NoError(t, repo.DeleteExpiredTokens(ctx, now.Add(time.Hour)))
+
+	revoked, err = repo.IsAccessTokenRevoked(ctx, "jti")
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
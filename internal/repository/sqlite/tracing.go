@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/6ermvH/trash-bot/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedDB starts a client span for every query, queries are the leaves of the
+// trace of an update or a request.
+type tracedDB struct {
+	*sql.DB
+}
+
+func (db *tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+
+	res, err := db.DB.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+
+	return res, err //nolint:wrapcheck // callers wrap the error
+}
+
+func (db *tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+
+	return rows, err //nolint:wrapcheck // callers wrap the error
+}
+
+func (db *tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+
+	return row
+}
+
+// BeginTx starts a transaction whose statements are traced like the queries
+// of the database.
+func (db *tracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*tracedTx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers wrap the error
+	}
+
+	return &tracedTx{Tx: tx}, nil
+}
+
+type tracedTx struct {
+	*sql.Tx
+}
+
+func (tx *tracedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+
+	res, err := tx.Tx.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+
+	return res, err //nolint:wrapcheck // callers wrap the error
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := queryOperation(query)
+
+	return tracing.Start(ctx, "sqlite "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameSQLite,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+// queryOperation is the first keyword of the query, e.g. SELECT.
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+
+	return strings.ToUpper(fields[0])
+}
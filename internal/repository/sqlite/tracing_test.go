@@ -0,0 +1,48 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/tracing"
+	"github.com/6ermvH/trash-bot/internal/tracing/tracingtest"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+//nolint:paralleltest // sets the global tracer provider
+func TestQuerySpans(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	recorder := tracingtest.Record(t)
+
+	ctx, root := tracing.Start(t.Context(), "update")
+
+	require.NoError(t, repo.SaveChat(ctx, repository.Chat{ID: 1, Users: []string{"German"}}))
+
+	_, err := repo.GetChat(ctx, 1)
+	require.NoError(t, err)
+
+	_, err = repo.db.ExecContext(ctx, "DELETE FROM missing")
+	require.Error(t, err)
+
+	root.End()
+
+	require.Equal(t, []string{"sqlite INSERT", "sqlite SELECT", "sqlite DELETE", "update"}, tracingtest.Names(recorder))
+
+	spans := recorder.Ended()
+	for _, span := range spans[:3] {
+		require.Equal(t, spans[3].SpanContext().SpanID(), span.Parent().SpanID())
+		require.Contains(t, span.Attributes(), semconv.DBSystemNameSQLite)
+	}
+
+	require.Contains(t, spans[1].Attributes(), semconv.DBOperationName("SELECT"))
+	require.Equal(t, codes.Error, spans[2].Status().Code)
+}
+
+func TestQueryOperation(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "SELECT", queryOperation("\n\t\tselect id FROM chats"))
+	require.Equal(t, "QUERY", queryOperation("  "))
+}
@@ -0,0 +1,280 @@
+package adminmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidLogin       = errors.New("login must not be empty")
+	ErrWeakPassword       = errors.New("password must be between 8 and 72 bytes")
+	ErrUnknownRole        = errors.New("unknown role")
+	ErrLastOwner          = errors.New("at least one owner must remain")
+)
+
+type Repository interface {
+	GetAdmins(ctx context.Context) ([]repository.Admin, error)
+	GetAdmin(ctx context.Context, login string) (*repository.Admin, error)
+	CreateAdmin(ctx context.Context, admin repository.Admin) error
+	UpdateAdmin(ctx context.Context, admin repository.Admin) error
+	DeleteAdmin(ctx context.Context, login string) error
+	// RevokeAdminRefreshTokens ends the sessions of the admin, so that a stolen
+	// refresh token does not survive a password or role change.
+	RevokeAdminRefreshTokens(ctx context.Context, login string) error
+}
+
+type Service struct {
+	repo Repository
+	// dummyHash is compared against when the login does not exist, so that
+	// the response time does not reveal which logins are registered.
+	dummyHash []byte
+}
+
+func New(repo Repository) *Service {
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+	return &Service{
+		repo:      repo,
+		dummyHash: dummyHash,
+	}
+}
+
+func (s *Service) Authenticate(ctx context.Context, login, password string) (repository.Admin, error) {
+	admin, err := s.repo.GetAdmin(ctx, login)
+
+	switch {
+	case err == nil:
+		break
+	case errors.Is(err, repository.ErrAdminNotFound):
+		_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+
+		return repository.Admin{}, ErrInvalidCredentials
+	default:
+		return repository.Admin{}, fmt.Errorf("get admin from repo: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(password)); err != nil {
+		return repository.Admin{}, ErrInvalidCredentials
+	}
+
+	return *admin, nil
+}
+
+func (s *Service) Admin(ctx context.Context, login string) (repository.Admin, error) {
+	admin, err := s.repo.GetAdmin(ctx, login)
+	if err != nil {
+		return repository.Admin{}, fmt.Errorf("get admin from repo: %w", err)
+	}
+
+	return *admin, nil
+}
+
+func (s *Service) Admins(ctx context.Context) ([]repository.Admin, error) {
+	admins, err := s.repo.GetAdmins(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get admins from repo: %w", err)
+	}
+
+	return admins, nil
+}
+
+func (s *Service) Create(
+	ctx context.Context,
+	login, password string,
+	role repository.Role,
+) (repository.Admin, error) {
+	login = strings.TrimSpace(login)
+	if login == "" {
+		return repository.Admin{}, ErrInvalidLogin
+	}
+
+	if !role.Valid() {
+		return repository.Admin{}, fmt.Errorf("%w: %q", ErrUnknownRole, role)
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return repository.Admin{}, err
+	}
+
+	return s.create(ctx, login, hash, role)
+}
+
+func (s *Service) create(ctx context.Context, login, hash string, role repository.Role) (repository.Admin, error) {
+	admin := repository.Admin{
+		Login:        login,
+		PasswordHash: hash,
+		Role:         role,
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+
+	if err := s.repo.CreateAdmin(ctx, admin); err != nil {
+		return repository.Admin{}, fmt.Errorf("create admin in repo: %w", err)
+	}
+
+	return admin, nil
+}
+
+func (s *Service) SetPassword(ctx context.Context, login, password string) error {
+	admin, err := s.repo.GetAdmin(ctx, login)
+	if err != nil {
+		return fmt.Errorf("get admin from repo: %w", err)
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	admin.PasswordHash = hash
+
+	if err := s.repo.UpdateAdmin(ctx, *admin); err != nil {
+		return fmt.Errorf("update admin in repo: %w", err)
+	}
+
+	return s.revokeSessions(ctx, login)
+}
+
+func (s *Service) SetRole(ctx context.Context, login string, role repository.Role) error {
+	if !role.Valid() {
+		return fmt.Errorf("%w: %q", ErrUnknownRole, role)
+	}
+
+	admin, err := s.repo.GetAdmin(ctx, login)
+	if err != nil {
+		return fmt.Errorf("get admin from repo: %w", err)
+	}
+
+	if admin.Role == repository.RoleOwner && role != repository.RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, login); err != nil {
+			return err
+		}
+	}
+
+	if admin.Role == role {
+		return nil
+	}
+
+	admin.Role = role
+
+	if err := s.repo.UpdateAdmin(ctx, *admin); err != nil {
+		return fmt.Errorf("update admin in repo: %w", err)
+	}
+
+	return s.revokeSessions(ctx, login)
+}
+
+func (s *Service) Delete(ctx context.Context, login string) error {
+	admin, err := s.repo.GetAdmin(ctx, login)
+	if err != nil {
+		return fmt.Errorf("get admin from repo: %w", err)
+	}
+
+	if admin.Role == repository.RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, login); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.DeleteAdmin(ctx, login); err != nil {
+		return fmt.Errorf("delete admin in repo: %w", err)
+	}
+
+	return s.revokeSessions(ctx, login)
+}
+
+// revokeSessions makes the admin log in again. Access tokens already issued
+// stay valid until they expire, they are short-lived.
+func (s *Service) revokeSessions(ctx context.Context, login string) error {
+	if err := s.repo.RevokeAdminRefreshTokens(ctx, login); err != nil {
+		return fmt.Errorf("revoke refresh tokens in repo: %w", err)
+	}
+
+	return nil
+}
+
+// Bootstrap creates an owner with the given credentials when there are no
+// admins yet. It reports whether the owner was created. The credentials come
+// from the deployment config, which may predate the password length check, so
+// a short password is accepted, check it with CheckPassword to warn about it.
+func (s *Service) Bootstrap(ctx context.Context, login, password string) (bool, error) {
+	login = strings.TrimSpace(login)
+	if login == "" || password == "" {
+		return false, nil
+	}
+
+	admins, err := s.repo.GetAdmins(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get admins from repo: %w", err)
+	}
+
+	if len(admins) > 0 {
+		return false, nil
+	}
+
+	hash, err := generateHash(password)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := s.create(ctx, login, hash, repository.RoleOwner); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *Service) ensureAnotherOwner(ctx context.Context, login string) error {
+	admins, err := s.repo.GetAdmins(ctx)
+	if err != nil {
+		return fmt.Errorf("get admins from repo: %w", err)
+	}
+
+	for _, admin := range admins {
+		if admin.Role == repository.RoleOwner && admin.Login != login {
+			return nil
+		}
+	}
+
+	return ErrLastOwner
+}
+
+// CheckPassword returns ErrWeakPassword when the password does not meet the
+// requirements for new passwords.
+func CheckPassword(password string) error {
+	if len(password) < minPasswordLength {
+		return ErrWeakPassword
+	}
+
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	if err := CheckPassword(password); err != nil {
+		return "", err
+	}
+
+	return generateHash(password)
+}
+
+func generateHash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", ErrWeakPassword
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("hash password: %w", err)
+	}
+
+	return string(hash), nil
+}
@@ -0,0 +1,211 @@
+package adminmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	service := New(inmemory.New())
+	ctx := t.Context()
+
+	_, err := service.Create(ctx, "german", "correct-horse", repository.RoleOperator)
+	require.NoError(t, err)
+
+	t.Run("Valid credentials", func(t *testing.T) {
+		t.Parallel()
+
+		admin, err := service.Authenticate(ctx, "german", "correct-horse")
+		require.NoError(t, err)
+		require.Equal(t, "german", admin.Login)
+		require.Equal(t, repository.RoleOperator, admin.Role)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := service.Authenticate(ctx, "german", "wrong-horse")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("Unknown login", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := service.Authenticate(ctx, "anthon", "correct-horse")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+}
+
+func TestService_Create(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Password is hashed", func(t *testing.T) {
+		t.Parallel()
+
+		repo := inmemory.New()
+		service := New(repo)
+
+		_, err := service.Create(t.Context(), "german", "correct-horse", repository.RoleViewer)
+		require.NoError(t, err)
+
+		stored, err := repo.GetAdmin(t.Context(), "german")
+		require.NoError(t, err)
+		require.NotEqual(t, "correct-horse", stored.PasswordHash)
+		require.NotEmpty(t, stored.PasswordHash)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+		ctx := t.Context()
+
+		_, err := service.Create(ctx, " ", "correct-horse", repository.RoleViewer)
+		require.ErrorIs(t, err, ErrInvalidLogin)
+
+		_, err = service.Create(ctx, "german", "short", repository.RoleViewer)
+		require.ErrorIs(t, err, ErrWeakPassword)
+
+		_, err = service.Create(ctx, "german", "correct-horse", "root")
+		require.ErrorIs(t, err, ErrUnknownRole)
+	})
+
+	t.Run("Duplicate login", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+		ctx := t.Context()
+
+		_, err := service.Create(ctx, "german", "correct-horse", repository.RoleViewer)
+		require.NoError(t, err)
+
+		_, err = service.Create(ctx, "german", "correct-horse", repository.RoleOwner)
+		require.ErrorIs(t, err, repository.ErrAdminExists)
+	})
+}
+
+func TestService_LastOwner(t *testing.T) {
+	t.Parallel()
+
+	service := New(inmemory.New())
+	ctx := t.Context()
+
+	_, err := service.Create(ctx, "german", "correct-horse", repository.RoleOwner)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, service.SetRole(ctx, "german", repository.RoleViewer), ErrLastOwner)
+	require.ErrorIs(t, service.Delete(ctx, "german"), ErrLastOwner)
+
+	_, err = service.Create(ctx, "anthon", "correct-horse", repository.RoleOwner)
+	require.NoError(t, err)
+
+	require.NoError(t, service.SetRole(ctx, "german", repository.RoleViewer))
+	require.ErrorIs(t, service.Delete(ctx, "anthon"), ErrLastOwner)
+	require.NoError(t, service.Delete(ctx, "german"))
+}
+
+func TestService_SetPassword(t *testing.T) {
+	t.Parallel()
+
+	service := New(inmemory.New())
+	ctx := t.Context()
+
+	_, err := service.Create(ctx, "german", "correct-horse", repository.RoleViewer)
+	require.NoError(t, err)
+
+	require.NoError(t, service.SetPassword(ctx, "german", "battery-staple"))
+
+	_, err = service.Authenticate(ctx, "german", "correct-horse")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = service.Authenticate(ctx, "german", "battery-staple")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, service.SetPassword(ctx, "anthon", "battery-staple"), repository.ErrAdminNotFound)
+}
+
+func TestService_RevokesSessions(t *testing.T) {
+	t.Parallel()
+
+	repo := inmemory.New()
+	service := New(repo)
+	ctx := t.Context()
+
+	_, err := service.Create(ctx, "german", "correct-horse", repository.RoleOperator)
+	require.NoError(t, err)
+
+	session := func(hash string) {
+		require.NoError(t, repo.CreateRefreshToken(ctx, repository.RefreshToken{
+			Hash: hash, Family: hash, Login: "german", ExpiresAt: time.Now().Add(time.Hour),
+		}))
+	}
+
+	// Украденный refresh-токен не должен пережить смену пароля или роли
+	for name, change := range map[string]func() error{
+		"password": func() error { return service.SetPassword(ctx, "german", "battery-staple") },
+		"role":     func() error { return service.SetRole(ctx, "german", repository.RoleViewer) },
+		"delete":   func() error { return service.Delete(ctx, "german") },
+	} {
+		session(name)
+		require.NoError(t, change(), name)
+
+		_, err := repo.GetRefreshToken(ctx, name)
+		require.ErrorIs(t, err, repository.ErrTokenNotFound, name)
+
+		_, _ = service.Create(ctx, "german", "correct-horse", repository.RoleOperator)
+	}
+}
+
+func TestService_Bootstrap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Creates owner on first start", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+		ctx := t.Context()
+
+		created, err := service.Bootstrap(ctx, "admin", "correct-horse")
+		require.NoError(t, err)
+		require.True(t, created)
+
+		admin, err := service.Authenticate(ctx, "admin", "correct-horse")
+		require.NoError(t, err)
+		require.Equal(t, repository.RoleOwner, admin.Role)
+
+		created, err = service.Bootstrap(ctx, "other", "correct-horse")
+		require.NoError(t, err)
+		require.False(t, created)
+	})
+
+	t.Run("Short password from the config is accepted", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+		ctx := t.Context()
+
+		created, err := service.Bootstrap(ctx, "admin", "admin")
+		require.NoError(t, err)
+		require.True(t, created)
+		require.ErrorIs(t, CheckPassword("admin"), ErrWeakPassword)
+
+		_, err = service.Authenticate(ctx, "admin", "admin")
+		require.NoError(t, err)
+	})
+
+	t.Run("Skipped without credentials", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+
+		created, err := service.Bootstrap(t.Context(), "", "")
+		require.NoError(t, err)
+		require.False(t, created)
+	})
+}
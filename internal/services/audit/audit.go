@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+const (
+	pruneInterval = time.Hour
+
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+type Repository interface {
+	AddAuditEntry(ctx context.Context, entry repository.AuditEntry) error
+	GetAuditEntries(ctx context.Context, filter repository.AuditFilter) ([]repository.AuditEntry, error)
+	DeleteAuditEntriesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Service stores the audit trail and removes entries older than the retention period.
+type Service struct {
+	repo      Repository
+	retention time.Duration
+	now       func() time.Time
+}
+
+// New creates the service, retention 0 keeps entries forever.
+func New(repo Repository, retention time.Duration) *Service {
+	return &Service{
+		repo:      repo,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// Record stores the entry. The change is already made at this point, so
+// a failure is only logged.
+func (s *Service) Record(ctx context.Context, entry repository.AuditEntry) {
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = s.now().UTC()
+	}
+
+	// Запись не должна теряться, если запрос отменили сразу после изменения
+	if err := s.repo.AddAuditEntry(context.WithoutCancel(ctx), entry); err != nil {
+		slog.ErrorContext(ctx, "record audit entry",
+			"action", entry.Action,
+			logging.KeyChatID, entry.ChatID,
+			"actor_type", entry.ActorType,
+			"actor_id", entry.ActorID,
+			logging.Err(err))
+	}
+}
+
+// Entries returns entries matching the filter, newest first.
+func (s *Service) Entries(ctx context.Context, filter repository.AuditFilter) ([]repository.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultLimit
+	}
+
+	filter.Limit = min(filter.Limit, MaxLimit)
+
+	entries, err := s.repo.GetAuditEntries(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get audit entries from repo: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Start removes expired entries periodically until ctx is done.
+func (s *Service) Start(ctx context.Context) {
+	if s.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Prune(ctx); err != nil {
+			slog.ErrorContext(ctx, "prune audit log", logging.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune removes entries older than the retention period.
+func (s *Service) Prune(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+
+	deleted, err := s.repo.DeleteAuditEntriesBefore(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		return 0, fmt.Errorf("delete expired audit entries: %w", err)
+	}
+
+	return deleted, nil
+}
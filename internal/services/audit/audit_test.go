@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	service := New(inmemory.New(), 7*24*time.Hour)
+	service.now = func() time.Time { return now }
+
+	ctx := t.Context()
+
+	service.Record(ctx, repository.AuditEntry{ChatID: 1, Action: "old", OccurredAt: now.AddDate(0, 0, -8)})
+
+	for range 3 {
+		service.Record(ctx, repository.AuditEntry{ChatID: 2, Action: "rotation.advanced"})
+	}
+
+	entries, err := service.Entries(ctx, repository.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	require.Equal(t, now, entries[0].OccurredAt, "missing time is set on record")
+	require.Greater(t, entries[0].ID, entries[1].ID, "newest first")
+
+	chatID := int64(2)
+
+	entries, err = service.Entries(ctx, repository.AuditFilter{ChatID: &chatID, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	entries, err = service.Entries(ctx, repository.AuditFilter{ChatID: &chatID, BeforeID: entries[1].ID})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	deleted, err := service.Prune(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	entries, err = service.Entries(ctx, repository.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+}
+
+func TestService_PruneDisabled(t *testing.T) {
+	t.Parallel()
+
+	service := New(inmemory.New(), 0)
+	service.Record(t.Context(), repository.AuditEntry{OccurredAt: time.Unix(0, 0)})
+
+	deleted, err := service.Prune(t.Context())
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+}
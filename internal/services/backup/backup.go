@@ -0,0 +1,155 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+)
+
+const (
+	filePrefix = "trash-"
+	fileSuffix = ".db"
+	timeLayout = "20060102-150405"
+
+	defaultInterval = 24 * time.Hour
+)
+
+type Backuper interface {
+	Backup(ctx context.Context, path string) error
+}
+
+type Job struct {
+	backuper Backuper
+	now      func() time.Time
+
+	mu       sync.Mutex
+	schedule schedule
+	// rescheduled wakes Start to reset the ticker to the new interval.
+	rescheduled chan struct{}
+}
+
+type schedule struct {
+	dir      string
+	keep     int
+	interval time.Duration
+}
+
+func New(backuper Backuper, dir string, keep int, interval time.Duration) *Job {
+	j := &Job{
+		backuper:    backuper,
+		now:         time.Now,
+		rescheduled: make(chan struct{}, 1),
+	}
+	j.Reschedule(dir, keep, interval)
+
+	return j
+}
+
+// Reschedule changes the directory, the number of kept copies and the
+// interval, a running job waits the new interval from now.
+func (j *Job) Reschedule(dir string, keep int, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	j.mu.Lock()
+	j.schedule = schedule{dir: dir, keep: keep, interval: interval}
+	j.mu.Unlock()
+
+	select {
+	case j.rescheduled <- struct{}{}:
+	default:
+	}
+}
+
+func (j *Job) current() schedule {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.schedule
+}
+
+func (j *Job) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.current().interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.rescheduled:
+			ticker.Reset(j.current().interval)
+		case <-ticker.C:
+			if _, err := j.Run(ctx); err != nil {
+				slog.ErrorContext(ctx, "scheduled backup", logging.Err(err))
+			}
+		}
+	}
+}
+
+// Run creates a new backup in the job directory and removes the oldest ones
+// so that at most keep copies remain. It returns the path of the new backup.
+func (j *Job) Run(ctx context.Context) (string, error) {
+	sched := j.current()
+
+	if err := os.MkdirAll(sched.dir, 0o750); err != nil {
+		return "", fmt.Errorf("create backup dir: %w", err)
+	}
+
+	path := filepath.Join(sched.dir, filePrefix+j.now().UTC().Format(timeLayout)+fileSuffix)
+
+	if err := j.backuper.Backup(ctx, path); err != nil {
+		return "", fmt.Errorf("create backup: %w", err)
+	}
+
+	if err := rotate(sched.dir, sched.keep); err != nil {
+		return path, fmt.Errorf("rotate backups: %w", err)
+	}
+
+	return path, nil
+}
+
+func rotate(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read backup dir: %w", err)
+	}
+
+	backups := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+
+		backups = append(backups, name)
+	}
+
+	if len(backups) <= keep {
+		return nil
+	}
+
+	// Имена содержат время создания, поэтому лексикографический порядок совпадает с хронологическим
+	slices.Sort(backups)
+
+	for _, name := range backups[:len(backups)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("remove old backup %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errDiskFull = errors.New("disk full")
+
+type fileBackuper struct {
+	err error
+}
+
+func (f *fileBackuper) Backup(ctx context.Context, path string) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	return os.WriteFile(path, []byte("backup"), 0o600)
+}
+
+func newTestJob(t *testing.T, backuper Backuper, keep int) *Job {
+	t.Helper()
+
+	job := New(backuper, t.TempDir(), keep, time.Hour)
+
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	job.now = func() time.Time {
+		current = current.Add(time.Minute)
+
+		return current
+	}
+
+	return job
+}
+
+func TestJob_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Creates backup file", func(t *testing.T) {
+		t.Parallel()
+
+		job := newTestJob(t, &fileBackuper{}, 3)
+
+		path, err := job.Run(t.Context())
+		require.NoError(t, err)
+
+		require.FileExists(t, path)
+		require.Equal(t, "trash-20250101-000100.db", filepath.Base(path))
+	})
+
+	t.Run("Keeps only newest copies", func(t *testing.T) {
+		t.Parallel()
+
+		job := newTestJob(t, &fileBackuper{}, 2)
+
+		paths := make([]string, 0, 4)
+
+		for range 4 {
+			path, err := job.Run(t.Context())
+			require.NoError(t, err)
+
+			paths = append(paths, path)
+		}
+
+		require.NoFileExists(t, paths[0])
+		require.NoFileExists(t, paths[1])
+		require.FileExists(t, paths[2])
+		require.FileExists(t, paths[3])
+	})
+
+	t.Run("Ignores foreign files", func(t *testing.T) {
+		t.Parallel()
+
+		job := newTestJob(t, &fileBackuper{}, 1)
+
+		foreign := filepath.Join(job.current().dir, "notes.txt")
+		require.NoError(t, os.WriteFile(foreign, []byte("keep me"), 0o600))
+
+		for range 3 {
+			_, err := job.Run(t.Context())
+			require.NoError(t, err)
+		}
+
+		require.FileExists(t, foreign)
+	})
+
+	t.Run("Backup error is wrapped", func(t *testing.T) {
+		t.Parallel()
+
+		job := newTestJob(t, &fileBackuper{err: errDiskFull}, 1)
+
+		_, err := job.Run(t.Context())
+		require.ErrorIs(t, err, errDiskFull)
+	})
+}
+
+func TestJob_Reschedule(t *testing.T) {
+	t.Parallel()
+
+	job := newTestJob(t, &fileBackuper{}, 0)
+
+	dir := t.TempDir()
+	job.Reschedule(dir, 1, 0)
+	require.Equal(t, schedule{dir: dir, keep: 1, interval: defaultInterval}, job.current())
+
+	for range 2 {
+		_, err := job.Run(t.Context())
+		require.NoError(t, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "backups go to the new dir and the new keep applies")
+}
@@ -0,0 +1,219 @@
+// Package feed keeps recent chat changes for live panel updates. Every change
+// gets an increasing id, so that a reconnecting client can resume after the
+// last change it has seen as long as it is still in the buffer.
+package feed
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+)
+
+const (
+	defaultBufferSize = 256
+	// subscriberQueue is how many events a subscriber may lag behind before
+	// it is disconnected to resume from the buffer.
+	subscriberQueue = 64
+)
+
+// Event is a chat change sent to the panel.
+type Event struct {
+	ID         uint64    `json:"id"`
+	Type       string    `json:"type"`
+	ChatID     int64     `json:"chatId"`
+	OccurredAt time.Time `json:"occurredAt"`
+	// Chat is the state after the change, nil if the chat was deleted.
+	Chat *repository.Chat `json:"chat,omitempty"`
+}
+
+type ChatGetter interface {
+	Chat(ctx context.Context, chatID int64) (*repository.Chat, error)
+}
+
+// Subscription receives events published after it was created.
+type Subscription struct {
+	// Missed are the buffered events after the requested id.
+	Missed []Event
+	// Reset is set when some events after the requested id are no longer
+	// buffered, the client has to reload the whole state.
+	Reset bool
+	// LastID is the id of the last published event when subscribing.
+	LastID uint64
+	// Events is closed when the broker is closed or the subscriber lags behind.
+	Events <-chan Event
+
+	cancel func()
+}
+
+// Close stops delivery of events to the subscription.
+func (s *Subscription) Close() {
+	s.cancel()
+}
+
+type Broker struct {
+	mu          sync.Mutex
+	seq         uint64
+	buffer      []Event // кольцевой буфер, buffer[start] самое старое событие
+	start       int
+	size        int
+	subscribers map[chan Event]struct{}
+	closed      bool
+}
+
+// New creates a broker keeping the last size events, 0 means the default size.
+func New(size int) *Broker {
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+
+	return &Broker{
+		// Номера продолжают время запуска, чтобы номер из прошлого запуска
+		// никогда не совпал с новым событием
+		seq:         uint64(time.Now().UnixMicro()),
+		buffer:      make([]Event, 0, size),
+		size:        size,
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Handler adapts the broker to trashmanager events, chats are loaded from
+// chats to send their state after the change.
+func (b *Broker) Handler(chats ChatGetter) trashmanager.EventHandler {
+	return func(ctx context.Context, event trashmanager.Event) {
+		meta := event.Meta()
+
+		var chat *repository.Chat
+
+		if event.Type() != trashmanager.EventChatDeleted {
+			loaded, err := chats.Chat(ctx, meta.ChatID)
+			if err != nil {
+				slog.ErrorContext(ctx, "load chat for live update", logging.KeyChatID, meta.ChatID, logging.Err(err))
+
+				return
+			}
+
+			chat = loaded
+		}
+
+		b.Publish(Event{
+			Type:       string(event.Type()),
+			ChatID:     meta.ChatID,
+			OccurredAt: meta.OccurredAt,
+			Chat:       chat,
+		})
+	}
+}
+
+// Publish assigns the next id to the event, buffers it and sends it to subscribers.
+func (b *Broker) Publish(event Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return event
+	}
+
+	b.seq++
+	event.ID = b.seq
+
+	if len(b.buffer) < b.size {
+		b.buffer = append(b.buffer, event)
+	} else {
+		b.buffer[b.start] = event
+		b.start = (b.start + 1) % b.size
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Отстающий подписчик переподключится и продолжит из буфера
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return event
+}
+
+// Subscribe starts a subscription. With resume set, buffered events after
+// lastID are returned in Missed.
+func (b *Broker) Subscribe(lastID uint64, resume bool) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberQueue)
+
+	sub := &Subscription{
+		LastID: b.seq,
+		Events: ch,
+	}
+
+	if resume {
+		sub.Missed, sub.Reset = b.since(lastID)
+	}
+
+	if b.closed {
+		close(ch)
+	} else {
+		b.subscribers[ch] = struct{}{}
+	}
+
+	sub.cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return sub
+}
+
+// Close disconnects all subscribers, later events are dropped.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// since returns buffered events after lastID. It reports a reset when events
+// right after lastID were evicted, lastID is from before a restart or unknown.
+func (b *Broker) since(lastID uint64) ([]Event, bool) {
+	if lastID > b.seq {
+		return nil, true
+	}
+
+	if lastID == b.seq {
+		return nil, false
+	}
+
+	oldest := b.seq - uint64(len(b.buffer)) + 1
+	if lastID+1 < oldest {
+		return nil, true
+	}
+
+	missed := make([]Event, 0, b.seq-lastID)
+
+	for ind := range len(b.buffer) {
+		event := b.buffer[(b.start+ind)%len(b.buffer)]
+		if event.ID > lastID {
+			missed = append(missed, event)
+		}
+	}
+
+	return missed, false
+}
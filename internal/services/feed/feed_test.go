@@ -0,0 +1,105 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	broker := New(2)
+
+	first := broker.Publish(Event{Type: "a"})
+	second := broker.Publish(Event{Type: "b"})
+	require.Equal(t, first.ID+1, second.ID)
+
+	sub := broker.Subscribe(first.ID, true)
+	defer sub.Close()
+
+	require.False(t, sub.Reset)
+	require.Equal(t, []Event{second}, sub.Missed)
+	require.Equal(t, second.ID, sub.LastID)
+
+	third := broker.Publish(Event{Type: "c"})
+	require.Equal(t, third, <-sub.Events)
+
+	fourth := broker.Publish(Event{Type: "d"})
+
+	// Второе событие вытеснено из буфера
+	stale := broker.Subscribe(first.ID, true)
+	require.True(t, stale.Reset)
+	require.Empty(t, stale.Missed)
+	stale.Close()
+
+	current := broker.Subscribe(third.ID, true)
+	require.False(t, current.Reset)
+	require.Equal(t, []Event{fourth}, current.Missed)
+	current.Close()
+
+	fresh := broker.Subscribe(0, false)
+	require.False(t, fresh.Reset)
+	require.Equal(t, fourth.ID, fresh.LastID)
+	fresh.Close()
+
+	// Номер больше последнего выданного, например после перевода часов
+	future := broker.Subscribe(fourth.ID+1, true)
+	require.True(t, future.Reset)
+	future.Close()
+
+	broker.Close()
+
+	require.Equal(t, fourth, <-sub.Events)
+
+	_, ok := <-sub.Events
+	require.False(t, ok, "close disconnects subscribers")
+
+	sub.Close()
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	broker := New(0)
+
+	sub := broker.Subscribe(0, false)
+	defer sub.Close()
+
+	for range subscriberQueue + 1 {
+		broker.Publish(Event{})
+	}
+
+	received := 0
+	for range sub.Events {
+		received++
+	}
+
+	require.Equal(t, subscriberQueue, received, "lagging subscriber is disconnected to resume")
+}
+
+func TestBroker_Handler(t *testing.T) {
+	t.Parallel()
+
+	repo := inmemory.New()
+	require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 1, Users: []string{"German", "Anthon"}}))
+
+	service := trashmanager.New(repo)
+	broker := New(0)
+	service.OnEvent(broker.Handler(service))
+
+	sub := broker.Subscribe(0, false)
+	defer sub.Close()
+
+	_, err := service.Next(t.Context(), 1)
+	require.NoError(t, err)
+
+	event := <-sub.Events
+	require.Equal(t, string(trashmanager.EventRotationAdvanced), event.Type)
+	require.Equal(t, int64(1), event.ChatID)
+	require.NotNil(t, event.Chat)
+	require.Equal(t, 1, event.Chat.Current)
+}
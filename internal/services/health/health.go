@@ -0,0 +1,145 @@
+// Package health reports whether the process is alive and whether its
+// dependencies work, for Docker and load balancers.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	checkTimeout = 2 * time.Second
+)
+
+var ErrNoHeartbeat = errors.New("no heartbeat yet")
+
+// Check returns an error when the dependency does not work.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks.
+type Checker struct {
+	mu     sync.Mutex
+	checks []namedCheck
+}
+
+func New() *Checker {
+	return &Checker{}
+}
+
+// Add adds a readiness check, name is shown in the report.
+func (c *Checker) Add(name string, check Check) *Checker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+
+	return c
+}
+
+// Report is the result of the checks, Checks maps names to "ok" or the error.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Ready runs every check, each with its own timeout.
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.Unlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]string, len(checks))}
+
+	for _, named := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := named.check(checkCtx)
+
+		cancel()
+
+		if err != nil {
+			report.Status = StatusFail
+			report.Checks[named.name] = err.Error()
+
+			continue
+		}
+
+		report.Checks[named.name] = StatusOK
+	}
+
+	return report
+}
+
+// Healthz answers while the process is up.
+func (c *Checker) Healthz(w http.ResponseWriter, _ *http.Request) {
+	writeReport(w, http.StatusOK, Report{Status: StatusOK})
+}
+
+// Readyz answers 503 when any check fails.
+func (c *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := c.Ready(r.Context())
+
+	code := http.StatusOK
+	if report.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeReport(w, code, report)
+}
+
+func writeReport(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("write health report", logging.Err(err))
+	}
+}
+
+// Heartbeat remembers when a periodic job last did its work.
+type Heartbeat struct {
+	last atomic.Int64
+	now  func() time.Time
+}
+
+func NewHeartbeat() *Heartbeat {
+	return &Heartbeat{now: time.Now}
+}
+
+// Beat records that the job worked now.
+func (h *Heartbeat) Beat() {
+	h.last.Store(h.now().UnixNano())
+}
+
+// Check fails when the job did not work within maxAge, or did not work at all.
+func (h *Heartbeat) Check(maxAge time.Duration) Check {
+	return func(context.Context) error {
+		last := h.last.Load()
+		if last == 0 {
+			return ErrNoHeartbeat
+		}
+
+		if age := h.now().Sub(time.Unix(0, last)); age > maxAge {
+			return fmt.Errorf("last heartbeat %s ago", age.Truncate(time.Second))
+		}
+
+		return nil
+	}
+}
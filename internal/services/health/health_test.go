@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeat_Check(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	heartbeat := NewHeartbeat()
+	heartbeat.now = func() time.Time { return now }
+
+	check := heartbeat.Check(2 * time.Minute)
+	require.ErrorIs(t, check(t.Context()), ErrNoHeartbeat)
+
+	heartbeat.Beat()
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, check(t.Context()))
+
+	now = now.Add(time.Minute)
+	require.EqualError(t, check(t.Context()), "last heartbeat 3m0s ago")
+}
+
+func TestChecker_Readyz(t *testing.T) {
+	t.Parallel()
+
+	failing := errors.New("database is locked")
+	healthy := true
+
+	checker := New().
+		Add("telegram", func(context.Context) error { return nil }).
+		Add("database", func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			require.True(t, ok, "checks run with a timeout")
+
+			if healthy {
+				return nil
+			}
+
+			return failing
+		})
+
+	readyz := func() (int, Report) {
+		rec := httptest.NewRecorder()
+		checker.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var report Report
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+
+		return rec.Code, report
+	}
+
+	code, report := readyz()
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, Report{Status: StatusOK, Checks: map[string]string{"telegram": "ok", "database": "ok"}}, report)
+
+	healthy = false
+
+	code, report = readyz()
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, StatusFail, report.Status)
+	require.Equal(t, "database is locked", report.Checks["database"])
+	require.Equal(t, StatusOK, report.Checks["telegram"])
+
+	rec := httptest.NewRecorder()
+	checker.Healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rec.Code, "liveness does not depend on the checks")
+}
@@ -0,0 +1,194 @@
+// Package journal shares chat changes between processes using the same
+// database, e.g. the bot and the panel deployed separately. Every process
+// writes its changes to the database and polls the changes of the others,
+// the id of the last change seen is the cursor of the poll.
+package journal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultRetention    = time.Hour
+
+	pruneInterval = 10 * time.Minute
+	// pageSize is how many changes are read at once while catching up.
+	pageSize = 100
+)
+
+type Repository interface {
+	AddChange(ctx context.Context, change repository.Change) error
+	GetChangesAfter(ctx context.Context, afterID int64, limit int) ([]repository.Change, error)
+	LastChangeID(ctx context.Context) (int64, error)
+	DeleteChangesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Journal struct {
+	repo      Repository
+	origin    string
+	interval  time.Duration
+	retention time.Duration
+	now       func() time.Time
+
+	// mu serializes polls, handlers may be added while the journal is polled.
+	mu       sync.Mutex
+	handlers []trashmanager.EventHandler
+	cursor   int64
+	synced   bool // the cursor is set by the first poll
+}
+
+// New creates the journal of this process. Changes are polled every interval
+// and kept for retention, 0 means the defaults of a second and an hour.
+func New(repo Repository, interval, retention time.Duration) *Journal {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+
+	return &Journal{
+		repo:      repo,
+		origin:    rand.Text(),
+		interval:  interval,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// OnChange adds a handler of the changes made by other processes, handlers
+// are called one by one in the order of the changes.
+func (j *Journal) OnChange(handler trashmanager.EventHandler) *Journal {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.handlers = append(j.handlers, handler)
+
+	return j
+}
+
+// Handler records the changes of this process for the others.
+func (j *Journal) Handler() trashmanager.EventHandler {
+	return func(ctx context.Context, event trashmanager.Event) {
+		meta := event.Meta()
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			slog.ErrorContext(ctx, "encode change", logging.KeyChatID, meta.ChatID, logging.Err(err))
+
+			return
+		}
+
+		change := repository.Change{
+			Origin:     j.origin,
+			OccurredAt: meta.OccurredAt.UTC(),
+			ChatID:     meta.ChatID,
+			Type:       string(event.Type()),
+			Payload:    payload,
+		}
+
+		// Изменение уже сделано, другие процессы должны о нём узнать
+		if err := j.repo.AddChange(context.WithoutCancel(ctx), change); err != nil {
+			slog.ErrorContext(ctx, "record change", logging.KeyChatID, meta.ChatID, logging.Err(err))
+		}
+	}
+}
+
+// Poll delivers the changes of other processes made since the previous poll.
+// The first poll only remembers the newest change, earlier ones are not delivered.
+func (j *Journal) Poll(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.synced {
+		last, err := j.repo.LastChangeID(ctx)
+		if err != nil {
+			return fmt.Errorf("get last change from repo: %w", err)
+		}
+
+		j.cursor, j.synced = last, true
+
+		return nil
+	}
+
+	for {
+		changes, err := j.repo.GetChangesAfter(ctx, j.cursor, pageSize)
+		if err != nil {
+			return fmt.Errorf("get changes from repo: %w", err)
+		}
+
+		for _, change := range changes {
+			j.cursor = change.ID
+
+			if change.Origin != j.origin {
+				j.deliver(ctx, change)
+			}
+		}
+
+		if len(changes) < pageSize {
+			return nil
+		}
+	}
+}
+
+func (j *Journal) deliver(ctx context.Context, change repository.Change) {
+	event, err := trashmanager.UnmarshalEvent(trashmanager.EventType(change.Type), change.Payload)
+	if err != nil {
+		// Например, изменение записал процесс более новой версии
+		slog.WarnContext(ctx, "skip change", "change_id", change.ID, logging.KeyChatID, change.ChatID, logging.Err(err))
+
+		return
+	}
+
+	for _, handler := range j.handlers {
+		handler(ctx, event)
+	}
+}
+
+// Start polls changes and removes expired ones periodically until ctx is done.
+func (j *Journal) Start(ctx context.Context) {
+	polls := time.NewTicker(j.interval)
+	defer polls.Stop()
+
+	prunes := time.NewTicker(pruneInterval)
+	defer prunes.Stop()
+
+	for {
+		if err := j.Poll(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "poll changes", logging.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-prunes.C:
+			if _, err := j.Prune(ctx); err != nil {
+				slog.ErrorContext(ctx, "prune changes", logging.Err(err))
+			}
+		case <-polls.C:
+		}
+	}
+}
+
+// Prune removes changes older than the retention period. A process stopped
+// for longer does not need them, it loads the current state on start.
+func (j *Journal) Prune(ctx context.Context) (int64, error) {
+	deleted, err := j.repo.DeleteChangesBefore(ctx, j.now().Add(-j.retention))
+	if err != nil {
+		return 0, fmt.Errorf("delete expired changes: %w", err)
+	}
+
+	return deleted, nil
+}
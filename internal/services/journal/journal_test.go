@@ -0,0 +1,139 @@
+package journal
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository/sqlite"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/stretchr/testify/require"
+)
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []trashmanager.Event
+}
+
+func (r *eventRecorder) handle(_ context.Context, event trashmanager.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+// newProcess opens the database like a separate process does and records
+// changes of its service to the journal.
+func newProcess(t *testing.T, path string) (*trashmanager.Service, *Journal) {
+	t.Helper()
+
+	repo, err := sqlite.New(path)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = repo.Close()
+	})
+
+	changes := New(repo, 0, 0)
+	trashm := trashmanager.New(repo)
+	trashm.OnEvent(changes.Handler())
+
+	return trashm, changes
+}
+
+func TestJournal(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	path := filepath.Join(t.TempDir(), "trash.db")
+
+	bot, botChanges := newProcess(t, path)
+	panel, panelChanges := newProcess(t, path)
+
+	require.NoError(t, bot.SetEstablish(ctx, 1, []string{"German", "Anthon"}))
+
+	botEvents, panelEvents := &eventRecorder{}, &eventRecorder{}
+	botChanges.OnChange(botEvents.handle)
+	panelChanges.OnChange(panelEvents.handle)
+
+	// Первый опрос запоминает позицию, прошлые изменения не доставляются
+	require.NoError(t, botChanges.Poll(ctx))
+	require.NoError(t, panelChanges.Poll(ctx))
+
+	_, err := bot.Next(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, panel.Subscribe(trashmanager.WithAnnounce(ctx), 1, "09:00"))
+
+	require.NoError(t, botChanges.Poll(ctx))
+	require.NoError(t, panelChanges.Poll(ctx))
+
+	require.Len(t, panelEvents.events, 1)
+	advanced, ok := panelEvents.events[0].(trashmanager.RotationAdvanced)
+	require.True(t, ok)
+	require.Equal(t, "German", advanced.Previous)
+	require.Equal(t, "Anthon", advanced.Current)
+	require.EqualValues(t, 1, advanced.ChatID)
+
+	require.Len(t, botEvents.events, 1)
+	subscribed, ok := botEvents.events[0].(trashmanager.Subscribed)
+	require.True(t, ok)
+	require.Equal(t, "09:00", subscribed.NotifyTime)
+	require.True(t, subscribed.Announce)
+
+	// Доставленные изменения не повторяются
+	require.NoError(t, panelChanges.Poll(ctx))
+	require.Len(t, panelEvents.events, 1)
+}
+
+func TestJournal_CatchUp(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	path := filepath.Join(t.TempDir(), "trash.db")
+
+	bot, _ := newProcess(t, path)
+	_, panelChanges := newProcess(t, path)
+
+	events := &eventRecorder{}
+	panelChanges.OnChange(events.handle)
+	require.NoError(t, panelChanges.Poll(ctx))
+
+	for ind := range pageSize + 1 {
+		require.NoError(t, bot.SetEstablish(ctx, int64(ind), []string{"German"}))
+	}
+
+	require.NoError(t, panelChanges.Poll(ctx))
+	// Новый чат создаётся и получает участников
+	require.Len(t, events.events, 2*(pageSize+1))
+}
+
+func TestJournal_Prune(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	repo, err := sqlite.New(filepath.Join(t.TempDir(), "trash.db"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = repo.Close()
+	})
+
+	changes := New(repo, 0, time.Hour)
+	trashm := trashmanager.New(repo)
+	trashm.OnEvent(changes.Handler())
+
+	require.NoError(t, trashm.SetEstablish(ctx, 1, []string{"German"}))
+
+	deleted, err := changes.Prune(ctx)
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+
+	changes.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	deleted, err = changes.Prune(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, deleted)
+}
@@ -0,0 +1,207 @@
+// Package metrics collects Prometheus metrics of the bot, the scheduler, the
+// storage and the panel.
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace      = "trashbot"
+	collectTimeout = 5 * time.Second
+)
+
+// ChatCounter is the source of the chat gauges, they are read on every scrape.
+type ChatCounter interface {
+	GetChats(ctx context.Context) ([]repository.Chat, error)
+	GetSubscribedChats(ctx context.Context) ([]repository.Chat, error)
+}
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	commands       *prometheus.CounterVec
+	callbacks      *prometheus.CounterVec
+	schedulerTicks prometheus.Counter
+	reminders      *prometheus.CounterVec
+	repoDuration   *prometheus.HistogramVec
+	httpRequests   *prometheus.CounterVec
+	httpDuration   *prometheus.HistogramVec
+}
+
+func New(chats ChatCounter) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "telegram_commands_total",
+			Help:      "Telegram commands handled by the bot.",
+		}, []string{"command"}),
+		callbacks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "telegram_callbacks_total",
+			Help:      "Inline keyboard buttons pressed.",
+		}, []string{"action"}),
+		schedulerTicks: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "scheduler_ticks_total",
+			Help:      "Checks of the reminder scheduler.",
+		}),
+		reminders: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reminders_total",
+			Help:      "Daily reminders by result: sent or failed.",
+		}, []string{"result"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_operation_duration_seconds",
+			Help:      "Latency of storage operations.",
+			Buckets:   []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1},
+		}, []string{"repository", "method"}),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests of the panel.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests of the panel.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+
+	// Результаты без событий всё равно видны на графиках с нулём
+	m.reminders.WithLabelValues("sent")
+	m.reminders.WithLabelValues("failed")
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.commands,
+		m.callbacks,
+		m.schedulerTicks,
+		m.reminders,
+		m.repoDuration,
+		m.httpRequests,
+		m.httpDuration,
+		newChatsCollector(chats),
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// CommandUsed counts a bot command, it makes Metrics a telegram.CommandRecorder.
+func (m *Metrics) CommandUsed(_ context.Context, _ int64, command string) {
+	m.commands.WithLabelValues(command).Inc()
+}
+
+// CallbackPressed counts a pressed inline keyboard button.
+func (m *Metrics) CallbackPressed(_ context.Context, _ int64, action string) {
+	m.callbacks.WithLabelValues(action).Inc()
+}
+
+// SchedulerTick counts a check of the reminder scheduler.
+func (m *Metrics) SchedulerTick() {
+	m.schedulerTicks.Inc()
+}
+
+// ReminderSent counts a daily reminder delivered to a chat.
+func (m *Metrics) ReminderSent(_ context.Context, _ int64) {
+	m.reminders.WithLabelValues("sent").Inc()
+}
+
+// ReminderFailed counts a daily reminder that could not be sent.
+func (m *Metrics) ReminderFailed(_ context.Context, _ int64) {
+	m.reminders.WithLabelValues("failed").Inc()
+}
+
+// observeRepository records the latency of a storage operation started at start.
+func (m *Metrics) observeRepository(repo, method string, start time.Time) {
+	m.repoDuration.WithLabelValues(repo, method).Observe(time.Since(start).Seconds())
+}
+
+// GinMiddleware counts panel requests by route pattern, so chat ids do not
+// create new series. Requests without a route are counted as "unmatched".
+func (m *Metrics) GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		method := ctx.Request.Method
+		m.httpRequests.WithLabelValues(method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+		m.httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// chatsCollector reads the chat gauges from the storage on scrape instead of
+// tracking every change.
+type chatsCollector struct {
+	chats      ChatCounter
+	total      *prometheus.Desc
+	subscribed *prometheus.Desc
+}
+
+func newChatsCollector(chats ChatCounter) *chatsCollector {
+	return &chatsCollector{
+		chats: chats,
+		total: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "chats"),
+			"Chats with an initialized rotation.",
+			nil, nil,
+		),
+		subscribed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "subscribed_chats"),
+			"Chats subscribed to the daily reminder.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *chatsCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.total
+	descs <- c.subscribed
+}
+
+func (c *chatsCollector) Collect(metrics chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	chats, err := c.chats.GetChats(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "collect chats gauge", logging.Err(err))
+		metrics <- prometheus.NewInvalidMetric(c.total, err)
+	} else {
+		metrics <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(len(chats)))
+	}
+
+	subscribed, err := c.chats.GetSubscribedChats(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "collect subscribed chats gauge", logging.Err(err))
+		metrics <- prometheus.NewInvalidMetric(c.subscribed, err)
+	} else {
+		metrics <- prometheus.MustNewConstMetric(c.subscribed, prometheus.GaugeValue, float64(len(subscribed)))
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, metrics *Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	repo := inmemory.New()
+	metrics := New(repo)
+	instrumented := InstrumentRepository(repo, "inmemory", metrics)
+	ctx := t.Context()
+
+	require.NoError(t, instrumented.SaveChat(ctx, repository.Chat{ID: 1, Users: []string{"German"}}))
+	require.NoError(t, instrumented.SaveChat(ctx, repository.Chat{ID: 2, Users: []string{"Anthon"}}))
+	require.NoError(t, instrumented.Subscribe(ctx, 1, "09:00"))
+
+	_, err := instrumented.GetChat(ctx, 3)
+	require.ErrorIs(t, err, repository.ErrChatIsNotInitialize, "errors of the storage are returned as is")
+
+	metrics.CommandUsed(ctx, 1, "next")
+	metrics.CommandUsed(ctx, 1, "next")
+	metrics.CallbackPressed(ctx, 1, "who")
+	metrics.SchedulerTick()
+	metrics.ReminderSent(ctx, 1)
+
+	router := gin.New()
+	router.Use(metrics.GinMiddleware())
+	router.GET("/api/chats/:id", func(ctx *gin.Context) { ctx.Status(http.StatusNotFound) })
+
+	for _, path := range []string{"/api/chats/1", "/api/chats/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t, metrics)
+
+	for _, line := range []string{
+		`trashbot_telegram_commands_total{command="next"} 2`,
+		`trashbot_telegram_callbacks_total{action="who"} 1`,
+		`trashbot_scheduler_ticks_total 1`,
+		`trashbot_reminders_total{result="sent"} 1`,
+		`trashbot_reminders_total{result="failed"} 0`,
+		`trashbot_repository_operation_duration_seconds_count{method="SaveChat",repository="inmemory"} 2`,
+		`trashbot_repository_operation_duration_seconds_count{method="GetChat",repository="inmemory"} 1`,
+		`trashbot_http_requests_total{method="GET",route="/api/chats/:id",status="404"} 2`,
+		`trashbot_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`trashbot_chats 2`,
+		`trashbot_subscribed_chats 1`,
+	} {
+		require.Contains(t, body, line)
+	}
+}
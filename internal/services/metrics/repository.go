@@ -0,0 +1,243 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/adminmanager"
+	"github.com/6ermvH/trash-bot/internal/services/audit"
+	"github.com/6ermvH/trash-bot/internal/services/stats"
+	"github.com/6ermvH/trash-bot/internal/services/tokenmanager"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+)
+
+// Repository is the storage used by all services.
+type Repository interface {
+	trashmanager.Repository
+	adminmanager.Repository
+	tokenmanager.Repository
+	audit.Repository
+	stats.Repository
+}
+
+// InstrumentedRepository records the latency of every operation of the
+// wrapped storage, labeled with its name.
+type InstrumentedRepository struct {
+	repo    Repository
+	name    string
+	metrics *Metrics
+}
+
+// InstrumentRepository wraps the storage, name is "sqlite" or "inmemory".
+func InstrumentRepository(repo Repository, name string, metrics *Metrics) *InstrumentedRepository {
+	return &InstrumentedRepository{
+		repo:    repo,
+		name:    name,
+		metrics: metrics,
+	}
+}
+
+func (r *InstrumentedRepository) GetChats(ctx context.Context) ([]repository.Chat, error) {
+	defer r.metrics.observeRepository(r.name, "GetChats", time.Now())
+
+	return r.repo.GetChats(ctx)
+}
+
+func (r *InstrumentedRepository) FindChats(ctx context.Context, query repository.ChatQuery) ([]repository.Chat, error) {
+	defer r.metrics.observeRepository(r.name, "FindChats", time.Now())
+
+	return r.repo.FindChats(ctx, query)
+}
+
+func (r *InstrumentedRepository) GetChat(ctx context.Context, chatID int64) (*repository.Chat, error) {
+	defer r.metrics.observeRepository(r.name, "GetChat", time.Now())
+
+	return r.repo.GetChat(ctx, chatID)
+}
+
+func (r *InstrumentedRepository) GetSubscribedChats(ctx context.Context) ([]repository.Chat, error) {
+	defer r.metrics.observeRepository(r.name, "GetSubscribedChats", time.Now())
+
+	return r.repo.GetSubscribedChats(ctx)
+}
+
+func (r *InstrumentedRepository) GetCurrent(ctx context.Context, chatID int64) (string, error) {
+	defer r.metrics.observeRepository(r.name, "GetCurrent", time.Now())
+
+	return r.repo.GetCurrent(ctx, chatID)
+}
+
+func (r *InstrumentedRepository) SetNext(ctx context.Context, chatID int64) error {
+	defer r.metrics.observeRepository(r.name, "SetNext", time.Now())
+
+	return r.repo.SetNext(ctx, chatID)
+}
+
+func (r *InstrumentedRepository) SetPrev(ctx context.Context, chatID int64) error {
+	defer r.metrics.observeRepository(r.name, "SetPrev", time.Now())
+
+	return r.repo.SetPrev(ctx, chatID)
+}
+
+func (r *InstrumentedRepository) SetEstablish(ctx context.Context, chatID int64, users []string) error {
+	defer r.metrics.observeRepository(r.name, "SetEstablish", time.Now())
+
+	return r.repo.SetEstablish(ctx, chatID, users)
+}
+
+func (r *InstrumentedRepository) Subscribe(ctx context.Context, chatID int64, notifyTime string) error {
+	defer r.metrics.observeRepository(r.name, "Subscribe", time.Now())
+
+	return r.repo.Subscribe(ctx, chatID, notifyTime)
+}
+
+func (r *InstrumentedRepository) Unsubscribe(ctx context.Context, chatID int64) error {
+	defer r.metrics.observeRepository(r.name, "Unsubscribe", time.Now())
+
+	return r.repo.Unsubscribe(ctx, chatID)
+}
+
+func (r *InstrumentedRepository) SaveChat(ctx context.Context, chat repository.Chat) error {
+	defer r.metrics.observeRepository(r.name, "SaveChat", time.Now())
+
+	return r.repo.SaveChat(ctx, chat)
+}
+
+func (r *InstrumentedRepository) DeleteChat(ctx context.Context, chatID int64) error {
+	defer r.metrics.observeRepository(r.name, "DeleteChat", time.Now())
+
+	return r.repo.DeleteChat(ctx, chatID)
+}
+
+func (r *InstrumentedRepository) ImportChats(ctx context.Context, chats []repository.Chat, deleteIDs []int64) error {
+	defer r.metrics.observeRepository(r.name, "ImportChats", time.Now())
+
+	return r.repo.ImportChats(ctx, chats, deleteIDs)
+}
+
+func (r *InstrumentedRepository) AddDuty(ctx context.Context, duty repository.Duty) error {
+	defer r.metrics.observeRepository(r.name, "AddDuty", time.Now())
+
+	return r.repo.AddDuty(ctx, duty)
+}
+
+func (r *InstrumentedRepository) GetDuties(ctx context.Context, chatID int64) ([]repository.Duty, error) {
+	defer r.metrics.observeRepository(r.name, "GetDuties", time.Now())
+
+	return r.repo.GetDuties(ctx, chatID)
+}
+
+func (r *InstrumentedRepository) GetDutiesSince(ctx context.Context, since time.Time) ([]repository.Duty, error) {
+	defer r.metrics.observeRepository(r.name, "GetDutiesSince", time.Now())
+
+	return r.repo.GetDutiesSince(ctx, since)
+}
+
+func (r *InstrumentedRepository) GetAdmins(ctx context.Context) ([]repository.Admin, error) {
+	defer r.metrics.observeRepository(r.name, "GetAdmins", time.Now())
+
+	return r.repo.GetAdmins(ctx)
+}
+
+func (r *InstrumentedRepository) GetAdmin(ctx context.Context, login string) (*repository.Admin, error) {
+	defer r.metrics.observeRepository(r.name, "GetAdmin", time.Now())
+
+	return r.repo.GetAdmin(ctx, login)
+}
+
+func (r *InstrumentedRepository) CreateAdmin(ctx context.Context, admin repository.Admin) error {
+	defer r.metrics.observeRepository(r.name, "CreateAdmin", time.Now())
+
+	return r.repo.CreateAdmin(ctx, admin)
+}
+
+func (r *InstrumentedRepository) UpdateAdmin(ctx context.Context, admin repository.Admin) error {
+	defer r.metrics.observeRepository(r.name, "UpdateAdmin", time.Now())
+
+	return r.repo.UpdateAdmin(ctx, admin)
+}
+
+func (r *InstrumentedRepository) DeleteAdmin(ctx context.Context, login string) error {
+	defer r.metrics.observeRepository(r.name, "DeleteAdmin", time.Now())
+
+	return r.repo.DeleteAdmin(ctx, login)
+}
+
+func (r *InstrumentedRepository) CreateRefreshToken(ctx context.Context, token repository.RefreshToken) error {
+	defer r.metrics.observeRepository(r.name, "CreateRefreshToken", time.Now())
+
+	return r.repo.CreateRefreshToken(ctx, token)
+}
+
+func (r *InstrumentedRepository) GetRefreshToken(ctx context.Context, hash string) (*repository.RefreshToken, error) {
+	defer r.metrics.observeRepository(r.name, "GetRefreshToken", time.Now())
+
+	return r.repo.GetRefreshToken(ctx, hash)
+}
+
+func (r *InstrumentedRepository) MarkRefreshTokenUsed(ctx context.Context, hash string, usedAt time.Time) error {
+	defer r.metrics.observeRepository(r.name, "MarkRefreshTokenUsed", time.Now())
+
+	return r.repo.MarkRefreshTokenUsed(ctx, hash, usedAt)
+}
+
+func (r *InstrumentedRepository) RevokeRefreshFamily(ctx context.Context, family string) error {
+	defer r.metrics.observeRepository(r.name, "RevokeRefreshFamily", time.Now())
+
+	return r.repo.RevokeRefreshFamily(ctx, family)
+}
+
+func (r *InstrumentedRepository) RevokeAdminRefreshTokens(ctx context.Context, login string) error {
+	defer r.metrics.observeRepository(r.name, "RevokeAdminRefreshTokens", time.Now())
+
+	return r.repo.RevokeAdminRefreshTokens(ctx, login)
+}
+
+func (r *InstrumentedRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	defer r.metrics.observeRepository(r.name, "RevokeAccessToken", time.Now())
+
+	return r.repo.RevokeAccessToken(ctx, jti, expiresAt)
+}
+
+func (r *InstrumentedRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	defer r.metrics.observeRepository(r.name, "IsAccessTokenRevoked", time.Now())
+
+	return r.repo.IsAccessTokenRevoked(ctx, jti)
+}
+
+func (r *InstrumentedRepository) DeleteExpiredTokens(ctx context.Context, now time.Time) error {
+	defer r.metrics.observeRepository(r.name, "DeleteExpiredTokens", time.Now())
+
+	return r.repo.DeleteExpiredTokens(ctx, now)
+}
+
+func (r *InstrumentedRepository) AddAuditEntry(ctx context.Context, entry repository.AuditEntry) error {
+	defer r.metrics.observeRepository(r.name, "AddAuditEntry", time.Now())
+
+	return r.repo.AddAuditEntry(ctx, entry)
+}
+
+func (r *InstrumentedRepository) GetAuditEntries(ctx context.Context, filter repository.AuditFilter) ([]repository.AuditEntry, error) {
+	defer r.metrics.observeRepository(r.name, "GetAuditEntries", time.Now())
+
+	return r.repo.GetAuditEntries(ctx, filter)
+}
+
+func (r *InstrumentedRepository) DeleteAuditEntriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	defer r.metrics.observeRepository(r.name, "DeleteAuditEntriesBefore", time.Now())
+
+	return r.repo.DeleteAuditEntriesBefore(ctx, before)
+}
+
+func (r *InstrumentedRepository) AddStatCount(ctx context.Context, count repository.StatCount) error {
+	defer r.metrics.observeRepository(r.name, "AddStatCount", time.Now())
+
+	return r.repo.AddStatCount(ctx, count)
+}
+
+func (r *InstrumentedRepository) GetStatCounts(ctx context.Context, since string) ([]repository.StatCount, error) {
+	defer r.metrics.observeRepository(r.name, "GetStatCounts", time.Now())
+
+	return r.repo.GetStatCounts(ctx, since)
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import "time"
+
+// LoginGuard limits login attempts per client IP and per login and locks both
+// out after repeated failures.
+type LoginGuard struct {
+	attempts *Limiter
+	lockout  *Lockout
+}
+
+func NewLoginGuard(perMinute float64, burst, maxFailures int, lockout time.Duration) *LoginGuard {
+	return &LoginGuard{
+		attempts: New(perMinute/float64(time.Minute/time.Second), burst),
+		lockout:  NewLockout(maxFailures, lockout),
+	}
+}
+
+// SetLimits changes the limits of attempts and lockouts.
+func (g *LoginGuard) SetLimits(perMinute float64, burst, maxFailures int, lockout time.Duration) {
+	g.attempts.SetLimits(perMinute/float64(time.Minute/time.Second), burst)
+	g.lockout.SetLimits(maxFailures, lockout)
+}
+
+// Allow reports whether a login attempt may be made and otherwise how long to wait.
+func (g *LoginGuard) Allow(ip, login string) (time.Duration, bool) {
+	var wait time.Duration
+
+	for _, key := range guardKeys(ip, login) {
+		if left, locked := g.lockout.Locked(key); locked {
+			wait = max(wait, left)
+		}
+	}
+
+	if wait > 0 {
+		return wait, false
+	}
+
+	allowed := true
+
+	for _, key := range guardKeys(ip, login) {
+		if left, ok := g.attempts.Allow(key); !ok {
+			wait = max(wait, left)
+			allowed = false
+		}
+	}
+
+	return wait, allowed
+}
+
+// Failed records a failed attempt. It reports whether the attempt caused a lockout.
+func (g *LoginGuard) Failed(ip, login string) (time.Duration, bool) {
+	var (
+		lockedFor time.Duration
+		locked    bool
+	)
+
+	for _, key := range guardKeys(ip, login) {
+		if duration, ok := g.lockout.Fail(key); ok {
+			lockedFor, locked = duration, true
+		}
+	}
+
+	return lockedFor, locked
+}
+
+// Succeeded resets the failures of the client IP and the login.
+func (g *LoginGuard) Succeeded(ip, login string) {
+	for _, key := range guardKeys(ip, login) {
+		g.lockout.Reset(key)
+	}
+}
+
+func guardKeys(ip, login string) []string {
+	return []string{"ip:" + ip, "login:" + login}
+}
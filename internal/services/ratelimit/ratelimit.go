@@ -0,0 +1,198 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a set of token buckets identified by key, e.g. client IP.
+type Limiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// New creates a limiter allowing rate events per second with bursts of up to burst events.
+func New(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(max(burst, 1)),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetLimits changes the rate and the burst, buckets keep their tokens up to
+// the new burst.
+func (l *Limiter) SetLimits(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = rate
+	l.burst = float64(max(burst, 1))
+}
+
+// Allow takes a token from the key's bucket. When the bucket is empty it
+// reports false and how long to wait for the next token.
+func (l *Limiter) Allow(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+
+		return 0, true
+	}
+
+	if l.rate <= 0 {
+		return time.Duration(math.MaxInt64), false
+	}
+
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+}
+
+// sweep removes buckets that have refilled completely, they are equal to new ones.
+func (l *Limiter) sweep(now time.Time) {
+	if l.rate <= 0 {
+		return
+	}
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	if now.Sub(l.lastSweep) < refill {
+		return
+	}
+
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Lockout locks a key out for a while after too many failures in a row.
+type Lockout struct {
+	maxFailures int
+	duration    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*failures
+	now     func() time.Time
+}
+
+type failures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+func NewLockout(maxFailures int, duration time.Duration) *Lockout {
+	return &Lockout{
+		maxFailures: maxFailures,
+		duration:    duration,
+		entries:     make(map[string]*failures),
+		now:         time.Now,
+	}
+}
+
+// SetLimits changes the limits, current lockouts keep their end.
+func (l *Lockout) SetLimits(maxFailures int, duration time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.maxFailures = maxFailures
+	l.duration = duration
+}
+
+// Locked reports whether the key is locked out and for how long.
+func (l *Lockout) Locked(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return 0, false
+	}
+
+	left := entry.lockedUntil.Sub(l.now())
+	if left <= 0 {
+		return 0, false
+	}
+
+	return left, true
+}
+
+// Fail records a failure. It reports whether the key got locked out by it.
+func (l *Lockout) Fail(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxFailures <= 0 {
+		return 0, false
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	entry, ok := l.entries[key]
+	if !ok {
+		entry = &failures{}
+		l.entries[key] = entry
+	}
+
+	// Старые неудачи забываются, если после них прошло больше времени блокировки
+	if now.Sub(entry.last) > l.duration {
+		entry.count = 0
+	}
+
+	entry.count++
+	entry.last = now
+
+	if entry.count < l.maxFailures {
+		return 0, false
+	}
+
+	entry.count = 0
+	entry.lockedUntil = now.Add(l.duration)
+
+	return l.duration, true
+}
+
+// Reset forgets the failures of the key, e.g. after a successful attempt.
+func (l *Lockout) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, key)
+}
+
+func (l *Lockout) sweep(now time.Time) {
+	for key, entry := range l.entries {
+		if now.Sub(entry.last) > l.duration && !now.Before(entry.lockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	limiter := New(2, 3)
+	limiter.now = clock.Now
+
+	for range 3 {
+		_, ok := limiter.Allow("a")
+		require.True(t, ok)
+	}
+
+	wait, ok := limiter.Allow("a")
+	require.False(t, ok)
+	require.Equal(t, 500*time.Millisecond, wait)
+
+	_, ok = limiter.Allow("b")
+	require.True(t, ok, "buckets are independent")
+
+	clock.Advance(500 * time.Millisecond)
+
+	_, ok = limiter.Allow("a")
+	require.True(t, ok)
+
+	_, ok = limiter.Allow("a")
+	require.False(t, ok)
+
+	clock.Advance(time.Hour)
+
+	for range 3 {
+		_, ok := limiter.Allow("a")
+		require.True(t, ok, "burst is not exceeded after a long pause")
+	}
+
+	_, ok = limiter.Allow("a")
+	require.False(t, ok)
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	limiter := New(1, 1)
+	limiter.now = clock.Now
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, ok := limiter.Allow(key)
+		require.True(t, ok)
+	}
+
+	clock.Advance(time.Minute)
+
+	_, ok := limiter.Allow("d")
+	require.True(t, ok)
+	require.Len(t, limiter.buckets, 1)
+}
+
+func TestLimiter_SetLimits(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	limiter := New(1, 1)
+	limiter.now = clock.Now
+
+	_, ok := limiter.Allow("a")
+	require.True(t, ok)
+
+	limiter.SetLimits(4, 2)
+
+	wait, ok := limiter.Allow("a")
+	require.False(t, ok)
+	require.Equal(t, 250*time.Millisecond, wait, "the new rate refills the bucket")
+
+	clock.Advance(time.Second)
+
+	for range 2 {
+		_, ok := limiter.Allow("a")
+		require.True(t, ok, "the new burst applies")
+	}
+}
+
+func TestLockout(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	lockout := NewLockout(3, time.Minute)
+	lockout.now = clock.Now
+
+	for range 2 {
+		_, locked := lockout.Fail("a")
+		require.False(t, locked)
+	}
+
+	lockedFor, locked := lockout.Fail("a")
+	require.True(t, locked)
+	require.Equal(t, time.Minute, lockedFor)
+
+	clock.Advance(20 * time.Second)
+
+	left, locked := lockout.Locked("a")
+	require.True(t, locked)
+	require.Equal(t, 40*time.Second, left)
+
+	_, locked = lockout.Locked("b")
+	require.False(t, locked)
+
+	clock.Advance(40 * time.Second)
+
+	_, locked = lockout.Locked("a")
+	require.False(t, locked)
+
+	t.Run("Success resets failures", func(t *testing.T) {
+		t.Parallel()
+
+		lockout := NewLockout(2, time.Minute)
+
+		_, locked := lockout.Fail("a")
+		require.False(t, locked)
+
+		lockout.Reset("a")
+
+		_, locked = lockout.Fail("a")
+		require.False(t, locked)
+	})
+
+	t.Run("Old failures expire", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Unix(0, 0)}
+
+		lockout := NewLockout(2, time.Minute)
+		lockout.now = clock.Now
+
+		_, locked := lockout.Fail("a")
+		require.False(t, locked)
+
+		clock.Advance(2 * time.Minute)
+
+		_, locked = lockout.Fail("a")
+		require.False(t, locked)
+	})
+}
+
+func TestLoginGuard(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Lockout applies to login from any IP", func(t *testing.T) {
+		t.Parallel()
+
+		guard := NewLoginGuard(60, 10, 2, time.Minute)
+
+		for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+			_, ok := guard.Allow(ip, "admin")
+			require.True(t, ok)
+
+			guard.Failed(ip, "admin")
+		}
+
+		wait, ok := guard.Allow("10.0.0.3", "admin")
+		require.False(t, ok)
+		require.Positive(t, wait)
+
+		_, ok = guard.Allow("10.0.0.3", "other")
+		require.True(t, ok)
+	})
+
+	t.Run("Attempts per minute", func(t *testing.T) {
+		t.Parallel()
+
+		guard := NewLoginGuard(1, 2, 0, time.Minute)
+
+		for range 2 {
+			_, ok := guard.Allow("10.0.0.1", "admin")
+			require.True(t, ok)
+		}
+
+		wait, ok := guard.Allow("10.0.0.1", "admin")
+		require.False(t, ok)
+		require.LessOrEqual(t, wait, time.Minute)
+	})
+}
@@ -0,0 +1,180 @@
+// Package reloader applies changes of the config file to the running services
+// when the file changes or on SIGHUP.
+package reloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/config"
+	"github.com/6ermvH/trash-bot/internal/logging"
+)
+
+const pollInterval = 5 * time.Second
+
+// Handler applies the reloadable settings of the config, it must not keep
+// the config.
+type Handler func(ctx context.Context, cfg *config.Config) error
+
+// Status is the effective config and the result of the last reload.
+type Status struct {
+	Config   *config.Config
+	LoadedAt time.Time
+	// RestartRequired are fields changed in the file that take effect after a restart.
+	RestartRequired []string
+	// Err is the error of the last reload, nil when it succeeded.
+	Err error
+}
+
+type Reloader struct {
+	path     string
+	load     func(path string) (*config.Config, error)
+	handlers []Handler
+	interval time.Duration // of polling the file
+	now      func() time.Time
+
+	// reloads serializes reloads of the watcher, SIGHUP and tests.
+	reloads sync.Mutex
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// New creates the reloader of the file the config was loaded from.
+func New(path string, cfg *config.Config) *Reloader {
+	return &Reloader{
+		path:     path,
+		load:     config.NewFromFile,
+		interval: pollInterval,
+		now:      time.Now,
+		status:   Status{Config: cfg, LoadedAt: time.Now()},
+	}
+}
+
+// OnReload adds a handler called with the effective config after every
+// reload, handlers are called in the order they were added.
+func (r *Reloader) OnReload(handler Handler) *Reloader {
+	r.handlers = append(r.handlers, handler)
+
+	return r
+}
+
+func (r *Reloader) Status() Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.status
+}
+
+// Reload reads the file again. An invalid file keeps the current config.
+// Changed reloadable fields are applied by the handlers, changes of other
+// fields are reported as requiring a restart.
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.reloads.Lock()
+	defer r.reloads.Unlock()
+
+	current := r.Status()
+
+	next, err := r.load(r.path)
+	if err != nil {
+		err = fmt.Errorf("load config: %w", err)
+		r.setStatus(Status{
+			Config:          current.Config,
+			LoadedAt:        current.LoadedAt,
+			RestartRequired: current.RestartRequired,
+			Err:             err,
+		})
+
+		return err
+	}
+
+	effective, changes := current.Config.Reload(next)
+
+	var errs []error
+
+	if len(changes.Applied) > 0 {
+		for _, handler := range r.handlers {
+			if err := handler(ctx, effective); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	err = errors.Join(errs...)
+	r.setStatus(Status{
+		Config:          effective,
+		LoadedAt:        r.now(),
+		RestartRequired: changes.Restart,
+		Err:             err,
+	})
+
+	if len(changes.Applied) > 0 {
+		slog.InfoContext(ctx, "config reloaded", "applied", changes.Applied)
+	}
+
+	if len(changes.Restart) > 0 {
+		slog.WarnContext(ctx, "config changes require a restart", "fields", changes.Restart)
+	}
+
+	if err != nil {
+		return fmt.Errorf("apply config: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Reloader) setStatus(status Status) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.status = status
+}
+
+// Start reloads the config on SIGHUP and when the content of the file changes
+// until ctx is done. The file is polled, so it also follows a ConfigMap
+// replaced through a symlink.
+func (r *Reloader) Start(ctx context.Context) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	defer signal.Stop(hangups)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	content, _ := os.ReadFile(r.path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			slog.InfoContext(ctx, "reloading config on SIGHUP")
+			r.reload(ctx)
+		case <-ticker.C:
+			data, err := os.ReadFile(r.path)
+			if err != nil || bytes.Equal(data, content) {
+				continue
+			}
+
+			content = data
+
+			slog.InfoContext(ctx, "reloading changed config", "path", r.path)
+			r.reload(ctx)
+		}
+	}
+}
+
+func (r *Reloader) reload(ctx context.Context) {
+	if err := r.Reload(ctx); err != nil {
+		slog.ErrorContext(ctx, "reload config", logging.Err(err))
+	}
+}
@@ -0,0 +1,168 @@
+package reloader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+const baseConfig = `
+telegram:
+  botkey: "123:token"
+database:
+  type: "memory"
+log:
+  level: "info"
+`
+
+func newTestReloader(t *testing.T) (*Reloader, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, baseConfig)
+
+	cfg, err := config.NewFromFile(path)
+	require.NoError(t, err)
+
+	return New(path, cfg), path
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+}
+
+func TestReloader_Reload(t *testing.T) {
+	t.Parallel()
+
+	reloader, path := newTestReloader(t)
+
+	var applied []string
+
+	reloader.OnReload(func(_ context.Context, cfg *config.Config) error {
+		applied = append(applied, cfg.Log.Level)
+
+		return nil
+	})
+
+	require.NoError(t, reloader.Reload(t.Context()))
+	require.Empty(t, applied, "handlers are not called without changes")
+
+	writeFile(t, path, baseConfig+"metrics:\n  enabled: true\n  addr: \"127.0.0.1:9090\"\n")
+	require.NoError(t, reloader.Reload(t.Context()))
+	require.Empty(t, applied, "handlers are not called for changes requiring a restart")
+
+	status := reloader.Status()
+	require.Equal(t, []string{"metrics.enabled", "metrics.addr"}, status.RestartRequired)
+	require.False(t, status.Config.Metrics.Enabled)
+
+	writeFile(t, path, `
+telegram:
+  botkey: "123:token"
+database:
+  type: "memory"
+log:
+  level: "warn"
+`)
+	require.NoError(t, reloader.Reload(t.Context()))
+	require.Equal(t, []string{"warn"}, applied)
+
+	status = reloader.Status()
+	require.Equal(t, "warn", status.Config.Log.Level)
+	require.Empty(t, status.RestartRequired, "the file matches the running config again")
+	require.NoError(t, status.Err)
+}
+
+func TestReloader_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	reloader, path := newTestReloader(t)
+	before := reloader.Status()
+
+	writeFile(t, path, baseConfig+"server:\n  enabled: true\n")
+
+	err := reloader.Reload(t.Context())
+
+	var validationErr *config.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	status := reloader.Status()
+	require.Same(t, before.Config, status.Config, "the current config is kept")
+	require.Equal(t, before.LoadedAt, status.LoadedAt)
+	require.ErrorIs(t, status.Err, err)
+}
+
+var errApply = errors.New("apply failed")
+
+func TestReloader_HandlerError(t *testing.T) {
+	t.Parallel()
+
+	reloader, path := newTestReloader(t)
+
+	var called atomic.Bool
+
+	reloader.
+		OnReload(func(context.Context, *config.Config) error { return errApply }).
+		OnReload(func(context.Context, *config.Config) error {
+			called.Store(true)
+
+			return nil
+		})
+
+	writeFile(t, path, baseConfig+"  format: \"json\"\n")
+
+	err := reloader.Reload(t.Context())
+	require.ErrorIs(t, err, errApply)
+	require.True(t, called.Load(), "other handlers still apply their settings")
+
+	status := reloader.Status()
+	require.Equal(t, "json", status.Config.Log.Format)
+	require.ErrorIs(t, status.Err, errApply)
+}
+
+func TestReloader_Start(t *testing.T) {
+	t.Parallel()
+
+	reloader, path := newTestReloader(t)
+	reloader.interval = 10 * time.Millisecond
+
+	levels := make(chan string, 1)
+
+	reloader.OnReload(func(_ context.Context, cfg *config.Config) error {
+		levels <- cfg.Log.Level
+
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	go reloader.Start(ctx)
+
+	// Первая проверка файла видит исходное содержимое
+	time.Sleep(50 * time.Millisecond)
+
+	writeFile(t, path, `
+telegram:
+  botkey: "123:token"
+database:
+  type: "memory"
+log:
+  level: "error"
+`)
+
+	select {
+	case level := <-levels:
+		require.Equal(t, "error", level)
+	case <-time.After(5 * time.Second):
+		t.Fatal("changed config was not reloaded")
+	}
+}
@@ -2,11 +2,14 @@ package scheduler
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"time"
 
+	"github.com/6ermvH/trash-bot/internal/logging"
 	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/tracing"
 	"github.com/go-telegram/bot"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Service interface {
@@ -14,9 +17,24 @@ type Service interface {
 	Who(ctx context.Context, chatID int64) (string, error)
 }
 
+// Reporter counts delivered and failed reminders.
+type Reporter interface {
+	ReminderSent(ctx context.Context, chatID int64)
+	ReminderFailed(ctx context.Context, chatID int64)
+}
+
+// Tracker lets a check started before shutdown send its reminders even when
+// the context of the scheduler is cancelled meanwhile.
+type Tracker interface {
+	Track(ctx context.Context) (context.Context, func(), bool)
+}
+
 type Scheduler struct {
-	service Service
-	botAPI  *bot.Bot
+	service   Service
+	botAPI    *bot.Bot
+	reporters []Reporter
+	onTick    []func()
+	tracker   Tracker
 }
 
 func New(service Service, botAPI *bot.Bot) *Scheduler {
@@ -26,38 +44,95 @@ func New(service Service, botAPI *bot.Bot) *Scheduler {
 	}
 }
 
-func (s *Scheduler) Start(ctx context.Context) {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
+// WithReporter adds a reporter of the results of sending reminders, they are
+// reported to every added reporter.
+func (s *Scheduler) WithReporter(reporter Reporter) *Scheduler {
+	s.reporters = append(s.reporters, reporter)
+
+	return s
+}
 
+// OnTick adds a function called on every check of the scheduler, e.g. to
+// count checks or to report that the scheduler is alive.
+func (s *Scheduler) OnTick(fn func()) *Scheduler {
+	s.onTick = append(s.onTick, fn)
+
+	return s
+}
+
+// WithTracker sets the tracker of checks, without it a check is cut off when
+// ctx of Start is cancelled.
+func (s *Scheduler) WithTracker(tracker Tracker) *Scheduler {
+	s.tracker = tracker
+
+	return s
+}
+
+// Start checks reminders every minute until ctx is done. A check in progress
+// is finished before Start returns.
+func (s *Scheduler) Start(ctx context.Context) {
 	// Выравниваем запуск на начало минуты
 	now := time.Now()
 	nextMinute := now.Truncate(time.Minute).Add(time.Minute)
-	time.Sleep(time.Until(nextMinute))
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(time.Until(nextMinute)):
+	}
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
 
 	// Проверяем сразу после выравнивания
-	s.checkAndNotify(ctx)
+	s.check(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			s.checkAndNotify(ctx)
+			s.check(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) check(ctx context.Context) {
+	if s.tracker != nil {
+		work, done, ok := s.tracker.Track(ctx)
+		if !ok {
+			return
 		}
+
+		defer done()
+
+		ctx = work
 	}
+
+	s.checkAndNotify(ctx)
 }
 
 func (s *Scheduler) checkAndNotify(ctx context.Context) {
+	for _, fn := range s.onTick {
+		fn()
+	}
+
 	currentTime := time.Now().Format("15:04")
+	ctx = logging.With(ctx, slog.String("tick", currentTime))
+
+	// Каждая проверка — отдельный трейс с напоминаниями этой минуты
+	ctx, span := tracing.Start(ctx, "scheduler.check", trace.WithNewRoot())
+	defer span.End()
 
 	chats, err := s.service.GetSubscribedChats(ctx)
 	if err != nil {
-		log.Printf("scheduler: get subscribed chats: %v", err)
+		slog.ErrorContext(ctx, "get subscribed chats", logging.Err(err))
 
 		return
 	}
 
+	slog.DebugContext(ctx, "check reminders", "subscribed_chats", len(chats))
+
 	for _, chat := range chats {
 		if chat.NotifyTime == nil {
 			continue
@@ -67,14 +142,15 @@ func (s *Scheduler) checkAndNotify(ctx context.Context) {
 			continue
 		}
 
-		s.sendNotification(ctx, chat.ID)
+		s.sendNotification(logging.With(ctx, slog.Int64(logging.KeyChatID, chat.ID)), chat.ID)
 	}
 }
 
 func (s *Scheduler) sendNotification(ctx context.Context, chatID int64) {
 	username, err := s.service.Who(ctx, chatID)
 	if err != nil {
-		log.Printf("scheduler: get who for chat %d: %v", chatID, err)
+		slog.ErrorContext(ctx, "get who for reminder", logging.Err(err))
+		s.report(ctx, chatID, err)
 
 		return
 	}
@@ -84,6 +160,20 @@ func (s *Scheduler) sendNotification(ctx context.Context, chatID int64) {
 		Text:   "🗑 Напоминание: сегодня мусор выносит " + username,
 	})
 	if err != nil {
-		log.Printf("scheduler: send notification to chat %d: %v", chatID, err)
+		slog.ErrorContext(ctx, "send reminder", logging.Err(err))
+	} else {
+		slog.InfoContext(ctx, "reminder sent")
+	}
+
+	s.report(ctx, chatID, err)
+}
+
+func (s *Scheduler) report(ctx context.Context, chatID int64, err error) {
+	for _, reporter := range s.reporters {
+		if err != nil {
+			reporter.ReminderFailed(ctx, chatID)
+		} else {
+			reporter.ReminderSent(ctx, chatID)
+		}
 	}
 }
@@ -2,10 +2,15 @@ package scheduler
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/go-telegram/bot"
 	"github.com/stretchr/testify/require"
 )
 
@@ -253,3 +258,84 @@ func TestScheduler_ServiceIntegration(t *testing.T) {
 		require.Equal(t, "Unknown", who)
 	})
 }
+
+// telegramStub answers every request of the bot and records sent messages.
+type telegramStub struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (s *telegramStub) Do(req *http.Request) (*http.Response, error) {
+	if ctxErr := req.Context().Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	if strings.HasSuffix(req.URL.Path, "/sendMessage") {
+		s.mu.Lock()
+		s.sent = append(s.sent, req.URL.Path)
+		s.mu.Unlock()
+	}
+
+	body := `{"ok": true, "result": {"message_id": 1, "date": 0, "chat": {"id": 1, "type": "group"}}}`
+
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func (s *telegramStub) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.sent)
+}
+
+// detachTracker lets checks finish after cancellation until it is closed.
+type detachTracker struct {
+	closed bool
+}
+
+func (d *detachTracker) Track(ctx context.Context) (context.Context, func(), bool) {
+	if d.closed {
+		return ctx, func() {}, false
+	}
+
+	return context.WithoutCancel(ctx), func() {}, true
+}
+
+func TestScheduler_Check(t *testing.T) {
+	t.Parallel()
+
+	newScheduler := func(t *testing.T) (*Scheduler, *telegramStub) {
+		t.Helper()
+
+		currentTime := time.Now().Format("15:04")
+		service := &mockService{
+			chats: []repository.Chat{{ID: 1, Users: []string{"German"}, NotifyTime: &currentTime}},
+		}
+
+		stub := &telegramStub{}
+
+		botAPI, err := bot.New("1:test", bot.WithSkipGetMe(), bot.WithHTTPClient(time.Second, stub))
+		require.NoError(t, err)
+
+		return New(service, botAPI), stub
+	}
+
+	// Проверка, начатая до остановки, отправляет напоминания после отмены контекста
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	scheduler, stub := newScheduler(t)
+	scheduler.check(ctx)
+	require.Zero(t, stub.count())
+
+	tracker := &detachTracker{}
+
+	scheduler, stub = newScheduler(t)
+	scheduler.WithTracker(tracker).check(ctx)
+	require.Equal(t, 1, stub.count())
+
+	tracker.closed = true
+
+	scheduler.check(ctx)
+	require.Equal(t, 1, stub.count())
+}
@@ -0,0 +1,361 @@
+// Package stats counts chat activity, reminders and bot commands per day and
+// builds the detailed statistics of the panel from these counters and the duty
+// history of trashmanager.
+package stats
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+)
+
+const (
+	MetricActivity       = "activity" // любые изменения чата
+	MetricReminderSent   = "reminder.sent"
+	MetricReminderFailed = "reminder.failed"
+	MetricCommand        = "command" // ключ: команда бота без слэша
+
+	DefaultDays = 30
+	MaxDays     = 365
+
+	activeWeek  = 7
+	activeMonth = 30
+)
+
+type Repository interface {
+	AddStatCount(ctx context.Context, count repository.StatCount) error
+	GetStatCounts(ctx context.Context, since string) ([]repository.StatCount, error)
+	GetDutiesSince(ctx context.Context, since time.Time) ([]repository.Duty, error)
+}
+
+type ChatLister interface {
+	Chats(ctx context.Context) ([]repository.Chat, error)
+}
+
+type Service struct {
+	repo  Repository
+	chats ChatLister
+	now   func() time.Time
+}
+
+func New(repo Repository, chats ChatLister) *Service {
+	return &Service{
+		repo:  repo,
+		chats: chats,
+		now:   time.Now,
+	}
+}
+
+// Handler counts chat activity from trashmanager events. Duties are not
+// counted here, they are read from the duty history the /stats command uses.
+func (s *Service) Handler() trashmanager.EventHandler {
+	return func(ctx context.Context, event trashmanager.Event) {
+		s.add(ctx, MetricActivity, event.Meta().ChatID, "", 1)
+	}
+}
+
+// ReminderSent counts a daily reminder delivered to the chat.
+func (s *Service) ReminderSent(ctx context.Context, chatID int64) {
+	s.add(ctx, MetricReminderSent, chatID, "", 1)
+}
+
+// ReminderFailed counts a daily reminder that could not be sent.
+func (s *Service) ReminderFailed(ctx context.Context, chatID int64) {
+	s.add(ctx, MetricReminderFailed, chatID, "", 1)
+}
+
+// CommandUsed counts a bot command sent to the chat.
+func (s *Service) CommandUsed(ctx context.Context, chatID int64, command string) {
+	s.add(ctx, MetricCommand, chatID, command, 1)
+}
+
+// add updates the counter of today. Statistics must not break the action
+// they count, so a failure is only logged.
+func (s *Service) add(ctx context.Context, metric string, chatID int64, key string, delta int64) {
+	count := repository.StatCount{
+		Day:    s.now().Format(time.DateOnly),
+		Metric: metric,
+		ChatID: chatID,
+		Key:    key,
+		Count:  delta,
+	}
+
+	if err := s.repo.AddStatCount(context.WithoutCancel(ctx), count); err != nil {
+		slog.ErrorContext(ctx, "add stat count", "metric", metric, logging.KeyChatID, chatID, logging.Err(err))
+	}
+}
+
+// Query selects the period and the chats of the detailed statistics.
+type Query struct {
+	Days int // the period ending today, 0 means DefaultDays
+	// ChatIDs limits the statistics to these chats, nil means all chats.
+	ChatIDs []int64
+}
+
+// Detailed is the statistics of a period. Duties and reminders of chats that
+// no longer exist are counted in the totals only. Duties come from the duty
+// history, so they match the /stats command of the bot.
+type Detailed struct {
+	From            string         `json:"from"`
+	To              string         `json:"to"`
+	DutiesTotal     int64          `json:"dutiesTotal"`
+	Members         []MemberDuties `json:"members"`
+	Chats           []ChatStats    `json:"chats"`
+	ActiveChats7d   int            `json:"activeChats7d"`
+	ActiveChats30d  int            `json:"activeChats30d"`
+	RemindersSent   int64          `json:"remindersSent"`
+	RemindersFailed int64          `json:"remindersFailed"`
+	Commands        []CommandUsage `json:"commands"`
+	Daily           []DailyStats   `json:"daily"`
+}
+
+// MemberDuties is the number of duties done by a member, most first.
+type MemberDuties struct {
+	Name   string `json:"name"`
+	Duties int64  `json:"duties"`
+}
+
+// ChatStats shows how fair the rotation of a chat is. Members are the current
+// members, with zero duties too, followed by former members who did duties.
+type ChatStats struct {
+	ChatID  int64          `json:"chatId"`
+	Duties  int64          `json:"duties"`
+	Members []MemberDuties `json:"members"`
+	// Spread is the difference between the most and the fewest duties of the
+	// current members, 0 is perfectly fair.
+	Spread          int64 `json:"spread"`
+	RemindersSent   int64 `json:"remindersSent"`
+	RemindersFailed int64 `json:"remindersFailed"`
+}
+
+type CommandUsage struct {
+	Command string `json:"command"`
+	Count   int64  `json:"count"`
+}
+
+// DailyStats is one point of the time series, days without activity are zero.
+type DailyStats struct {
+	Day             string `json:"day"`
+	Duties          int64  `json:"duties"`
+	ActiveChats     int    `json:"activeChats"`
+	RemindersSent   int64  `json:"remindersSent"`
+	RemindersFailed int64  `json:"remindersFailed"`
+	Commands        int64  `json:"commands"`
+}
+
+// Detailed aggregates the counters of the period.
+func (s *Service) Detailed(ctx context.Context, query Query) (Detailed, error) {
+	days := query.Days
+	if days <= 0 {
+		days = DefaultDays
+	}
+
+	days = min(days, MaxDays)
+
+	today := s.now()
+	from := firstDay(today, days)
+	week := firstDay(today, activeWeek)
+	month := firstDay(today, activeMonth)
+
+	// Активные за 30 дней считаются и при более коротком периоде
+	counts, err := s.repo.GetStatCounts(ctx, min(from, month))
+	if err != nil {
+		return Detailed{}, fmt.Errorf("get stat counts from repo: %w", err)
+	}
+
+	chats, err := s.chats.Chats(ctx)
+	if err != nil {
+		return Detailed{}, fmt.Errorf("get chats for stats: %w", err)
+	}
+
+	duties, err := s.repo.GetDutiesSince(ctx, startOfDay(from, today.Location()))
+	if err != nil {
+		return Detailed{}, fmt.Errorf("get duties from repo: %w", err)
+	}
+
+	if query.ChatIDs != nil {
+		counts = slices.DeleteFunc(counts, func(count repository.StatCount) bool {
+			return !slices.Contains(query.ChatIDs, count.ChatID)
+		})
+		duties = slices.DeleteFunc(duties, func(duty repository.Duty) bool {
+			return !slices.Contains(query.ChatIDs, duty.ChatID)
+		})
+		chats = slices.DeleteFunc(chats, func(chat repository.Chat) bool {
+			return !slices.Contains(query.ChatIDs, chat.ID)
+		})
+	}
+
+	result := Detailed{
+		From:  from,
+		To:    today.Format(time.DateOnly),
+		Daily: make([]DailyStats, days),
+	}
+
+	daily := make(map[string]*DailyStats, days)
+
+	for ind := range result.Daily {
+		result.Daily[ind].Day = today.AddDate(0, 0, ind-days+1).Format(time.DateOnly)
+		daily[result.Daily[ind].Day] = &result.Daily[ind]
+	}
+
+	var (
+		lastActive = make(map[int64]string)
+		members    = make(map[string]int64)
+		commands   = make(map[string]int64)
+		byChat     = make(map[int64]*ChatStats)
+		dutiesOf   = make(map[int64]map[string]int64)
+	)
+
+	chatStats := func(chatID int64) *ChatStats {
+		if byChat[chatID] == nil {
+			byChat[chatID] = &ChatStats{ChatID: chatID}
+			dutiesOf[chatID] = make(map[string]int64)
+		}
+
+		return byChat[chatID]
+	}
+
+	for _, count := range counts {
+		if count.Metric == MetricActivity && count.Count > 0 {
+			lastActive[count.ChatID] = max(lastActive[count.ChatID], count.Day)
+		}
+
+		point, ok := daily[count.Day]
+		if !ok {
+			continue
+		}
+
+		switch count.Metric {
+		case MetricActivity:
+			if count.Count > 0 {
+				point.ActiveChats++
+			}
+		case MetricReminderSent:
+			result.RemindersSent += count.Count
+			point.RemindersSent += count.Count
+			chatStats(count.ChatID).RemindersSent += count.Count
+		case MetricReminderFailed:
+			result.RemindersFailed += count.Count
+			point.RemindersFailed += count.Count
+			chatStats(count.ChatID).RemindersFailed += count.Count
+		case MetricCommand:
+			point.Commands += count.Count
+			commands[count.Key] += count.Count
+		}
+	}
+
+	for _, duty := range duties {
+		point, ok := daily[duty.OccurredAt.In(today.Location()).Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+
+		// Пропуск отменяет засчитанное дежурство, как в отчёте /stats
+		var delta int64 = 1
+		if duty.Skipped {
+			delta = -1
+		}
+
+		result.DutiesTotal += delta
+		point.Duties += delta
+		members[duty.Member] += delta
+		chatStats(duty.ChatID).Duties += delta
+		dutiesOf[duty.ChatID][duty.Member] += delta
+	}
+
+	for _, day := range lastActive {
+		if day >= week {
+			result.ActiveChats7d++
+		}
+
+		if day >= month {
+			result.ActiveChats30d++
+		}
+	}
+
+	maps.DeleteFunc(members, func(_ string, count int64) bool { return count == 0 })
+	result.Members = sortedDuties(members)
+
+	result.Commands = make([]CommandUsage, 0, len(commands))
+	for command, count := range commands {
+		result.Commands = append(result.Commands, CommandUsage{Command: command, Count: count})
+	}
+
+	slices.SortFunc(result.Commands, func(a, b CommandUsage) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Command, b.Command))
+	})
+
+	slices.SortFunc(chats, func(a, b repository.Chat) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	result.Chats = make([]ChatStats, 0, len(chats))
+	for _, chat := range chats {
+		stats := chatStats(chat.ID)
+		stats.Members, stats.Spread = fairness(chat.Users, dutiesOf[chat.ID])
+		result.Chats = append(result.Chats, *stats)
+	}
+
+	return result, nil
+}
+
+// fairness lists the duties of the current members first, then of former
+// members, and the spread between the current members.
+func fairness(users []string, duties map[string]int64) ([]MemberDuties, int64) {
+	current := make(map[string]int64, len(users))
+	former := make(map[string]int64)
+
+	for _, user := range users {
+		current[user] = duties[user]
+	}
+
+	for name, count := range duties {
+		if _, ok := current[name]; !ok && count != 0 {
+			former[name] = count
+		}
+	}
+
+	result := append(sortedDuties(current), sortedDuties(former)...)
+
+	var spread int64
+
+	if len(current) > 0 {
+		low, high := result[len(current)-1].Duties, result[0].Duties
+		spread = high - low
+	}
+
+	return result, spread
+}
+
+func sortedDuties(duties map[string]int64) []MemberDuties {
+	result := make([]MemberDuties, 0, len(duties))
+	for name, count := range duties {
+		result = append(result, MemberDuties{Name: name, Duties: count})
+	}
+
+	slices.SortFunc(result, func(a, b MemberDuties) int {
+		return cmp.Or(cmp.Compare(b.Duties, a.Duties), cmp.Compare(a.Name, b.Name))
+	})
+
+	return result
+}
+
+// firstDay returns the first day of the period of days ending today.
+func firstDay(today time.Time, days int) string {
+	return today.AddDate(0, 0, 1-days).Format(time.DateOnly)
+}
+
+// startOfDay returns the midnight of the day formatted by firstDay.
+func startOfDay(day string, loc *time.Location) time.Time {
+	start, _ := time.ParseInLocation(time.DateOnly, day, loc)
+
+	return start
+}
@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/6ermvH/trash-bot/internal/services/trashmanager"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Detailed(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	repo := inmemory.New()
+	trashm := trashmanager.New(repo)
+
+	service := New(repo, trashm)
+	trashm.OnEvent(service.Handler())
+
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.Local)
+
+	// Две недели назад в чате 2 была активность, в 7 дней он не попадает
+	service.now = func() time.Time { return now.AddDate(0, 0, -14) }
+	require.NoError(t, trashm.SetEstablish(ctx, 2, []string{"Vitaly"}))
+
+	service.now = func() time.Time { return now.AddDate(0, 0, -1) }
+	require.NoError(t, trashm.SetEstablish(ctx, 1, []string{"German", "Anthon", "Vitaly"}))
+
+	// Дежурства берутся из истории, которую пишет trashmanager
+	yesterday := now.AddDate(0, 0, -1)
+	for ind, member := range []string{"German", "Anthon", "Vitaly", "German", "Anthon"} {
+		duty := repository.Duty{ChatID: 1, Member: member, OccurredAt: yesterday.Add(time.Duration(ind) * time.Minute)}
+		require.NoError(t, repo.AddDuty(ctx, duty))
+	}
+
+	// Очередь вернули к Anthon, его последнее дежурство не засчитано
+	skip := repository.Duty{ChatID: 1, Member: "Anthon", OccurredAt: yesterday.Add(time.Hour), Skipped: true}
+	require.NoError(t, repo.AddDuty(ctx, skip))
+
+	require.NoError(t, repo.AddDuty(ctx, repository.Duty{ChatID: 2, Member: "Vitaly", OccurredAt: now.AddDate(0, 0, -8)}))
+
+	// Сегодня очередь ушла вперёд и вернулась, дежурство не засчитано
+	service.now = func() time.Time { return now }
+
+	_, err := trashm.Next(ctx, 1)
+	require.NoError(t, err)
+	_, err = trashm.Prev(ctx, 1)
+	require.NoError(t, err)
+
+	service.ReminderSent(ctx, 1)
+	service.ReminderFailed(ctx, 2)
+	service.CommandUsed(ctx, 1, "next")
+	service.CommandUsed(ctx, 1, "next")
+	service.CommandUsed(ctx, 2, "who")
+
+	detailed, err := service.Detailed(ctx, Query{Days: 7})
+	require.NoError(t, err)
+
+	require.Equal(t, "2025-01-04", detailed.From)
+	require.Equal(t, "2025-01-10", detailed.To)
+	require.Len(t, detailed.Daily, 7)
+
+	require.Equal(t, int64(4), detailed.DutiesTotal)
+	require.Equal(t, []MemberDuties{{"German", 2}, {"Anthon", 1}, {"Vitaly", 1}}, detailed.Members)
+	require.Equal(t, 1, detailed.ActiveChats7d)
+	require.Equal(t, 2, detailed.ActiveChats30d)
+	require.Equal(t, int64(1), detailed.RemindersSent)
+	require.Equal(t, int64(1), detailed.RemindersFailed)
+	require.Equal(t, []CommandUsage{{"next", 2}, {"who", 1}}, detailed.Commands)
+
+	require.Equal(t, DailyStats{Day: "2025-01-09", Duties: 4, ActiveChats: 1}, detailed.Daily[5])
+	require.Equal(t, DailyStats{
+		Day:             "2025-01-10",
+		Duties:          0,
+		ActiveChats:     1,
+		RemindersSent:   1,
+		RemindersFailed: 1,
+		Commands:        3,
+	}, detailed.Daily[6])
+
+	require.Len(t, detailed.Chats, 2)
+	require.Equal(t, ChatStats{
+		ChatID:        1,
+		Duties:        4,
+		Members:       []MemberDuties{{"German", 2}, {"Anthon", 1}, {"Vitaly", 1}},
+		Spread:        1,
+		RemindersSent: 1,
+	}, detailed.Chats[0])
+
+	scoped, err := service.Detailed(ctx, Query{Days: 7, ChatIDs: []int64{2}})
+	require.NoError(t, err)
+	require.Zero(t, scoped.DutiesTotal)
+	require.Equal(t, 0, scoped.ActiveChats7d)
+	require.Equal(t, 1, scoped.ActiveChats30d)
+	require.Equal(t, []CommandUsage{{"who", 1}}, scoped.Commands)
+	require.Len(t, scoped.Chats, 1)
+	require.Equal(t, []MemberDuties{{"Vitaly", 0}}, scoped.Chats[0].Members)
+}
@@ -0,0 +1,103 @@
+package telegramauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultMaxAge = 24 * time.Hour
+
+var (
+	ErrInvalidHash = errors.New("telegram login data hash mismatch")
+	ErrExpired     = errors.New("telegram login data is too old")
+	ErrMissingData = errors.New("telegram login data is incomplete")
+)
+
+// User is a Telegram account confirmed by the Login Widget.
+type User struct {
+	ID        int64
+	Username  string
+	FirstName string
+	LastName  string
+}
+
+// Verifier checks Login Widget payloads signed for the bot,
+// see https://core.telegram.org/widgets/login#checking-authorization.
+type Verifier struct {
+	secret []byte
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+func NewVerifier(botToken string, maxAge time.Duration) *Verifier {
+	if maxAge <= 0 {
+		maxAge = defaultMaxAge
+	}
+
+	secret := sha256.Sum256([]byte(botToken))
+
+	return &Verifier{
+		secret: secret[:],
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+}
+
+// Verify checks the hash and freshness of the payload fields as sent by the widget.
+func (v *Verifier) Verify(data map[string]string) (User, error) {
+	hash := data["hash"]
+	if hash == "" || data["id"] == "" || data["auth_date"] == "" {
+		return User{}, ErrMissingData
+	}
+
+	keys := make([]string, 0, len(data))
+
+	for key := range data {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+data[key])
+	}
+
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(strings.Join(lines, "\n")))
+
+	expected, err := hex.DecodeString(hash)
+	if err != nil || !hmac.Equal(mac.Sum(nil), expected) {
+		return User{}, ErrInvalidHash
+	}
+
+	authDate, err := strconv.ParseInt(data["auth_date"], 10, 64)
+	if err != nil {
+		return User{}, fmt.Errorf("%w: auth_date: %w", ErrMissingData, err)
+	}
+
+	if v.now().Sub(time.Unix(authDate, 0)) > v.maxAge {
+		return User{}, ErrExpired
+	}
+
+	id, err := strconv.ParseInt(data["id"], 10, 64)
+	if err != nil {
+		return User{}, fmt.Errorf("%w: id: %w", ErrMissingData, err)
+	}
+
+	return User{
+		ID:        id,
+		Username:  data["username"],
+		FirstName: data["first_name"],
+		LastName:  data["last_name"],
+	}, nil
+}
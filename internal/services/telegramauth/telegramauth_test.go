@@ -0,0 +1,93 @@
+package telegramauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testBotToken = "123456:test-token"
+
+func sign(data map[string]string) map[string]string {
+	signed := maps.Clone(data)
+
+	keys := slices.Sorted(maps.Keys(data))
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+data[key])
+	}
+
+	secret := sha256.Sum256([]byte(testBotToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+
+	signed["hash"] = hex.EncodeToString(mac.Sum(nil))
+
+	return signed
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	verifier := NewVerifier(testBotToken, time.Hour)
+	verifier.now = func() time.Time { return now }
+
+	payload := map[string]string{
+		"id":         "42",
+		"first_name": "German",
+		"username":   "german",
+		"auth_date":  strconv.FormatInt(now.Add(-time.Minute).Unix(), 10),
+	}
+
+	t.Run("Valid payload", func(t *testing.T) {
+		t.Parallel()
+
+		user, err := verifier.Verify(sign(payload))
+		require.NoError(t, err)
+		require.Equal(t, User{ID: 42, Username: "german", FirstName: "German"}, user)
+	})
+
+	t.Run("Tampered field", func(t *testing.T) {
+		t.Parallel()
+
+		data := sign(payload)
+		data["id"] = "43"
+
+		_, err := verifier.Verify(data)
+		require.ErrorIs(t, err, ErrInvalidHash)
+	})
+
+	t.Run("Other bot token", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewVerifier("other", time.Hour).Verify(sign(payload))
+		require.ErrorIs(t, err, ErrInvalidHash)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		t.Parallel()
+
+		data := maps.Clone(payload)
+		data["auth_date"] = strconv.FormatInt(now.Add(-2*time.Hour).Unix(), 10)
+
+		_, err := verifier.Verify(sign(data))
+		require.ErrorIs(t, err, ErrExpired)
+	})
+
+	t.Run("Missing hash", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := verifier.Verify(payload)
+		require.ErrorIs(t, err, ErrMissingData)
+	})
+}
@@ -0,0 +1,454 @@
+package tokenmanager
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultAccessTTL  = 15 * time.Minute
+	defaultRefreshTTL = 30 * 24 * time.Hour
+
+	refreshTokenBytes = 32
+	// pruneInterval is how often expired refresh tokens and revoked access
+	// tokens are deleted, every refresh adds a row.
+	pruneInterval = time.Hour
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenRevoked = errors.New("token revoked")
+	ErrNoSigningKey = errors.New("jwt signing key is empty")
+)
+
+type Repository interface {
+	CreateRefreshToken(ctx context.Context, token repository.RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (*repository.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, hash string, usedAt time.Time) error
+	RevokeRefreshFamily(ctx context.Context, family string) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpiredTokens(ctx context.Context, now time.Time) error
+}
+
+// Accounts looks up the current state of an admin when a token is refreshed,
+// so that deleted admins lose access and role changes take effect.
+type Accounts interface {
+	Admin(ctx context.Context, login string) (repository.Admin, error)
+}
+
+// Key is a JWT signing key identified by the kid header.
+type Key struct {
+	ID     string
+	Secret string
+	// Until is the moment after which tokens signed with the key are rejected.
+	// Zero means no limit, it is used only for the current key.
+	Until time.Time
+}
+
+type Config struct {
+	Current    Key
+	Previous   []Key
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	// TelegramRole is the role of Telegram users in their chats, viewer when empty.
+	TelegramRole repository.Role
+}
+
+// Claims are the claims of an access token.
+type Claims struct {
+	Login string          `json:"login"`
+	Role  repository.Role `json:"role"`
+	// TelegramID is set for users logged in with Telegram, their access is
+	// limited to their own chats.
+	TelegramID       int64  `json:"tgId,omitempty"`
+	TelegramUsername string `json:"tgUsername,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Pair is a newly issued access and refresh token.
+type Pair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+type Service struct {
+	repo     Repository
+	accounts Accounts
+	// keys are replaced as a whole by Reconfigure, so that a token is never
+	// signed with a key and a TTL of different configurations.
+	keys atomic.Pointer[keySet]
+	now  func() time.Time
+}
+
+type keySet struct {
+	current      Key
+	byID         map[string]Key
+	accessTTL    time.Duration
+	refreshTTL   time.Duration
+	telegramRole repository.Role
+}
+
+func New(repo Repository, accounts Accounts, cfg Config) (*Service, error) {
+	s := &Service{
+		repo:     repo,
+		accounts: accounts,
+		now:      time.Now,
+	}
+
+	if err := s.Reconfigure(cfg); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reconfigure replaces the signing keys and the TTLs. Tokens issued before
+// stay valid while their key is the current one or a previous one.
+func (s *Service) Reconfigure(cfg Config) error {
+	if cfg.Current.Secret == "" {
+		return ErrNoSigningKey
+	}
+
+	if cfg.AccessTTL <= 0 {
+		cfg.AccessTTL = defaultAccessTTL
+	}
+
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = defaultRefreshTTL
+	}
+
+	if cfg.TelegramRole == "" {
+		cfg.TelegramRole = repository.RoleViewer
+	}
+
+	byID := make(map[string]Key, len(cfg.Previous)+1)
+	for _, key := range cfg.Previous {
+		byID[key.ID] = key
+	}
+
+	current := cfg.Current
+	current.Until = time.Time{}
+	byID[current.ID] = current
+
+	s.keys.Store(&keySet{
+		current:      current,
+		byID:         byID,
+		accessTTL:    cfg.AccessTTL,
+		refreshTTL:   cfg.RefreshTTL,
+		telegramRole: cfg.TelegramRole,
+	})
+
+	return nil
+}
+
+// subject is whoever a token is issued to: a panel admin or a Telegram user.
+type subject struct {
+	admin            repository.Admin
+	telegramID       int64
+	telegramUsername string
+}
+
+// TelegramLogin is the login of tokens issued to a Telegram user.
+func TelegramLogin(telegramID int64) string {
+	return "tg:" + strconv.FormatInt(telegramID, 10)
+}
+
+// Issue creates an access token and starts a new refresh token family for the admin.
+func (s *Service) Issue(ctx context.Context, admin repository.Admin) (Pair, error) {
+	return s.start(ctx, subject{admin: admin})
+}
+
+// IssueTelegram creates tokens for a Telegram user. Such users get the
+// configured Telegram role, but only for chats they belong to. The returned
+// admin describes the user.
+func (s *Service) IssueTelegram(ctx context.Context, telegramID int64, username string) (Pair, repository.Admin, error) {
+	sub := s.telegramSubject(telegramID, username)
+
+	pair, err := s.start(ctx, sub)
+	if err != nil {
+		return Pair{}, repository.Admin{}, err
+	}
+
+	return pair, sub.admin, nil
+}
+
+// telegramSubject takes the role from the current config, so that a changed
+// role applies to Telegram users on their next refresh.
+func (s *Service) telegramSubject(telegramID int64, username string) subject {
+	return subject{
+		admin: repository.Admin{
+			Login: TelegramLogin(telegramID),
+			Role:  s.keys.Load().telegramRole,
+		},
+		telegramID:       telegramID,
+		telegramUsername: username,
+	}
+}
+
+func (s *Service) start(ctx context.Context, sub subject) (Pair, error) {
+	family, err := randomToken()
+	if err != nil {
+		return Pair{}, err
+	}
+
+	return s.issue(ctx, sub, family)
+}
+
+// Refresh exchanges a refresh token for a new pair. Every refresh token can be
+// used once: presenting an already used one revokes the whole family, since it
+// means the token was stolen.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (Pair, repository.Admin, error) {
+	hash := hashToken(refreshToken)
+
+	stored, err := s.repo.GetRefreshToken(ctx, hash)
+	if errors.Is(err, repository.ErrTokenNotFound) {
+		return Pair{}, repository.Admin{}, ErrInvalidToken
+	}
+
+	if err != nil {
+		return Pair{}, repository.Admin{}, fmt.Errorf("get refresh token from repo: %w", err)
+	}
+
+	now := s.now()
+
+	if !stored.ExpiresAt.After(now) {
+		return Pair{}, repository.Admin{}, ErrInvalidToken
+	}
+
+	err = s.repo.MarkRefreshTokenUsed(ctx, hash, now)
+	if errors.Is(err, repository.ErrTokenAlreadyUsed) || errors.Is(err, repository.ErrTokenNotFound) {
+		if err := s.repo.RevokeRefreshFamily(ctx, stored.Family); err != nil {
+			return Pair{}, repository.Admin{}, fmt.Errorf("revoke refresh token family: %w", err)
+		}
+
+		return Pair{}, repository.Admin{}, ErrTokenRevoked
+	}
+
+	if err != nil {
+		return Pair{}, repository.Admin{}, fmt.Errorf("mark refresh token used: %w", err)
+	}
+
+	if stored.TelegramID != 0 {
+		sub := s.telegramSubject(stored.TelegramID, stored.TelegramUsername)
+
+		pair, err := s.issue(ctx, sub, stored.Family)
+		if err != nil {
+			return Pair{}, repository.Admin{}, err
+		}
+
+		return pair, sub.admin, nil
+	}
+
+	admin, err := s.accounts.Admin(ctx, stored.Login)
+	if errors.Is(err, repository.ErrAdminNotFound) {
+		if err := s.repo.RevokeRefreshFamily(ctx, stored.Family); err != nil {
+			return Pair{}, repository.Admin{}, fmt.Errorf("revoke refresh token family: %w", err)
+		}
+
+		return Pair{}, repository.Admin{}, ErrInvalidToken
+	}
+
+	if err != nil {
+		return Pair{}, repository.Admin{}, fmt.Errorf("get admin: %w", err)
+	}
+
+	pair, err := s.issue(ctx, subject{admin: admin}, stored.Family)
+	if err != nil {
+		return Pair{}, repository.Admin{}, err
+	}
+
+	return pair, admin, nil
+}
+
+// Logout revokes the access token and the refresh token family. Either token
+// may be empty.
+func (s *Service) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	if accessToken != "" {
+		claims, err := s.parse(accessToken)
+		if err == nil && claims.ID != "" && claims.ExpiresAt != nil {
+			if err := s.repo.RevokeAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+				return fmt.Errorf("revoke access token: %w", err)
+			}
+		}
+	}
+
+	if refreshToken != "" {
+		stored, err := s.repo.GetRefreshToken(ctx, hashToken(refreshToken))
+
+		switch {
+		case err == nil:
+			if err := s.repo.RevokeRefreshFamily(ctx, stored.Family); err != nil {
+				return fmt.Errorf("revoke refresh token family: %w", err)
+			}
+		case errors.Is(err, repository.ErrTokenNotFound):
+		default:
+			return fmt.Errorf("get refresh token from repo: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Start deletes expired tokens periodically until ctx is done.
+func (s *Service) Start(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Prune(ctx); err != nil {
+			slog.ErrorContext(ctx, "prune tokens", logging.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune deletes expired refresh tokens and revoked access tokens, which are
+// rejected anyway once expired.
+func (s *Service) Prune(ctx context.Context) error {
+	if err := s.repo.DeleteExpiredTokens(ctx, s.now()); err != nil {
+		return fmt.Errorf("delete expired tokens: %w", err)
+	}
+
+	return nil
+}
+
+// Validate checks the signature, expiry and revocation of an access token.
+func (s *Service) Validate(ctx context.Context, accessToken string) (Claims, error) {
+	claims, err := s.parse(accessToken)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	revoked, err := s.repo.IsAccessTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return Claims{}, fmt.Errorf("check access token revocation: %w", err)
+	}
+
+	if revoked {
+		return Claims{}, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+func (s *Service) issue(ctx context.Context, sub subject, family string) (Pair, error) {
+	now := s.now()
+	keys := s.keys.Load()
+
+	jti, err := randomToken()
+	if err != nil {
+		return Pair{}, err
+	}
+
+	accessExpiresAt := now.Add(keys.accessTTL)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		Login:            sub.admin.Login,
+		Role:             sub.admin.Role,
+		TelegramID:       sub.telegramID,
+		TelegramUsername: sub.telegramUsername,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   sub.admin.Login,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(accessExpiresAt),
+		},
+	})
+	token.Header["kid"] = keys.current.ID
+
+	accessToken, err := token.SignedString([]byte(keys.current.Secret))
+	if err != nil {
+		return Pair{}, fmt.Errorf("sign access token: %w", err)
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return Pair{}, err
+	}
+
+	refreshExpiresAt := now.Add(keys.refreshTTL)
+
+	if err := s.repo.CreateRefreshToken(ctx, repository.RefreshToken{
+		Hash:             hashToken(refreshToken),
+		Family:           family,
+		Login:            sub.admin.Login,
+		TelegramID:       sub.telegramID,
+		TelegramUsername: sub.telegramUsername,
+		ExpiresAt:        refreshExpiresAt,
+	}); err != nil {
+		return Pair{}, fmt.Errorf("save refresh token: %w", err)
+	}
+
+	return Pair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+func (s *Service) parse(accessToken string) (Claims, error) {
+	var claims Claims
+
+	token, err := jwt.ParseWithClaims(accessToken, &claims, s.keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil || !token.Valid || claims.ID == "" {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (s *Service) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := s.keys.Load().byID[kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	if !key.Until.IsZero() && s.now().After(key.Until) {
+		return nil, ErrInvalidToken
+	}
+
+	return []byte(key.Secret), nil
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate random token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,316 @@
+package tokenmanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/stretchr/testify/require"
+)
+
+type accountsStub map[string]repository.Admin
+
+func (a accountsStub) Admin(_ context.Context, login string) (repository.Admin, error) {
+	admin, ok := a[login]
+	if !ok {
+		return repository.Admin{}, repository.ErrAdminNotFound
+	}
+
+	return admin, nil
+}
+
+func newTestService(t *testing.T, accounts accountsStub, cfg Config) *Service {
+	t.Helper()
+
+	if cfg.Current.Secret == "" {
+		cfg.Current = Key{ID: "v1", Secret: "secret-v1"}
+	}
+
+	service, err := New(inmemory.New(), accounts, cfg)
+	require.NoError(t, err)
+
+	return service
+}
+
+func TestService_IssueValidate(t *testing.T) {
+	t.Parallel()
+
+	admin := repository.Admin{Login: "german", Role: repository.RoleOperator}
+	service := newTestService(t, accountsStub{}, Config{})
+
+	pair, err := service.Issue(t.Context(), admin)
+	require.NoError(t, err)
+	require.NotEmpty(t, pair.RefreshToken)
+
+	claims, err := service.Validate(t.Context(), pair.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, "german", claims.Login)
+	require.Equal(t, repository.RoleOperator, claims.Role)
+	require.NotEmpty(t, claims.ID)
+
+	_, err = service.Validate(t.Context(), pair.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	service.now = func() time.Time { return time.Now().Add(defaultAccessTTL + time.Minute) }
+
+	_, err = service.Validate(t.Context(), pair.AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestService_Refresh(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Rotates refresh token", func(t *testing.T) {
+		t.Parallel()
+
+		accounts := accountsStub{"german": {Login: "german", Role: repository.RoleViewer}}
+		service := newTestService(t, accounts, Config{})
+
+		first, err := service.Issue(t.Context(), accounts["german"])
+		require.NoError(t, err)
+
+		// Роль поменялась после входа, новый access-токен должен её учитывать
+		accounts["german"] = repository.Admin{Login: "german", Role: repository.RoleOwner}
+
+		second, admin, err := service.Refresh(t.Context(), first.RefreshToken)
+		require.NoError(t, err)
+		require.Equal(t, repository.RoleOwner, admin.Role)
+		require.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+		claims, err := service.Validate(t.Context(), second.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, repository.RoleOwner, claims.Role)
+
+		_, _, err = service.Refresh(t.Context(), second.RefreshToken)
+		require.NoError(t, err)
+	})
+
+	t.Run("Reuse revokes family", func(t *testing.T) {
+		t.Parallel()
+
+		accounts := accountsStub{"german": {Login: "german", Role: repository.RoleViewer}}
+		service := newTestService(t, accounts, Config{})
+
+		first, err := service.Issue(t.Context(), accounts["german"])
+		require.NoError(t, err)
+
+		second, _, err := service.Refresh(t.Context(), first.RefreshToken)
+		require.NoError(t, err)
+
+		_, _, err = service.Refresh(t.Context(), first.RefreshToken)
+		require.ErrorIs(t, err, ErrTokenRevoked)
+
+		_, _, err = service.Refresh(t.Context(), second.RefreshToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Deleted admin", func(t *testing.T) {
+		t.Parallel()
+
+		accounts := accountsStub{"german": {Login: "german", Role: repository.RoleViewer}}
+		service := newTestService(t, accounts, Config{})
+
+		pair, err := service.Issue(t.Context(), accounts["german"])
+		require.NoError(t, err)
+
+		delete(accounts, "german")
+
+		_, _, err = service.Refresh(t.Context(), pair.RefreshToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		t.Parallel()
+
+		accounts := accountsStub{"german": {Login: "german", Role: repository.RoleViewer}}
+		service := newTestService(t, accounts, Config{RefreshTTL: time.Hour})
+
+		pair, err := service.Issue(t.Context(), accounts["german"])
+		require.NoError(t, err)
+
+		service.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+		_, _, err = service.Refresh(t.Context(), pair.RefreshToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		t.Parallel()
+
+		service := newTestService(t, accountsStub{}, Config{})
+
+		_, _, err := service.Refresh(t.Context(), "unknown")
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestService_Logout(t *testing.T) {
+	t.Parallel()
+
+	accounts := accountsStub{"german": {Login: "german", Role: repository.RoleViewer}}
+	service := newTestService(t, accounts, Config{})
+
+	pair, err := service.Issue(t.Context(), accounts["german"])
+	require.NoError(t, err)
+
+	other, err := service.Issue(t.Context(), accounts["german"])
+	require.NoError(t, err)
+
+	require.NoError(t, service.Logout(t.Context(), pair.AccessToken, pair.RefreshToken))
+
+	_, err = service.Validate(t.Context(), pair.AccessToken)
+	require.ErrorIs(t, err, ErrTokenRevoked)
+
+	_, _, err = service.Refresh(t.Context(), pair.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// Другие сессии того же админа продолжают работать
+	_, err = service.Validate(t.Context(), other.AccessToken)
+	require.NoError(t, err)
+
+	_, _, err = service.Refresh(t.Context(), other.RefreshToken)
+	require.NoError(t, err)
+
+	require.NoError(t, service.Logout(t.Context(), "garbage", "garbage"))
+}
+
+func TestService_Prune(t *testing.T) {
+	t.Parallel()
+
+	repo := inmemory.New()
+	accounts := accountsStub{"german": {Login: "german", Role: repository.RoleViewer}}
+
+	service, err := New(repo, accounts, Config{Current: Key{ID: "v1", Secret: "secret-v1"}})
+	require.NoError(t, err)
+
+	pair, err := service.Issue(t.Context(), accounts["german"])
+	require.NoError(t, err)
+
+	refreshed, _, err := service.Refresh(t.Context(), pair.RefreshToken)
+	require.NoError(t, err)
+
+	require.NoError(t, service.Prune(t.Context()))
+
+	_, err = repo.GetRefreshToken(t.Context(), hashToken(pair.RefreshToken))
+	require.NoError(t, err, "used tokens are kept until they expire to detect reuse")
+
+	service.now = func() time.Time { return time.Now().Add(defaultRefreshTTL + time.Minute) }
+	require.NoError(t, service.Prune(t.Context()))
+
+	for _, token := range []string{pair.RefreshToken, refreshed.RefreshToken} {
+		_, err = repo.GetRefreshToken(t.Context(), hashToken(token))
+		require.ErrorIs(t, err, repository.ErrTokenNotFound)
+	}
+}
+
+func TestService_KeyRotation(t *testing.T) {
+	t.Parallel()
+
+	admin := repository.Admin{Login: "german", Role: repository.RoleViewer}
+	oldService := newTestService(t, accountsStub{}, Config{
+		Current:   Key{ID: "v1", Secret: "secret-v1"},
+		AccessTTL: 24 * time.Hour,
+	})
+
+	pair, err := oldService.Issue(t.Context(), admin)
+	require.NoError(t, err)
+
+	graceUntil := time.Now().Add(time.Hour)
+
+	rotated := newTestService(t, accountsStub{}, Config{
+		Current:  Key{ID: "v2", Secret: "secret-v2"},
+		Previous: []Key{{ID: "v1", Secret: "secret-v1", Until: graceUntil}},
+	})
+
+	_, err = rotated.Validate(t.Context(), pair.AccessToken)
+	require.NoError(t, err)
+
+	rotated.now = func() time.Time { return graceUntil.Add(time.Second) }
+
+	_, err = rotated.Validate(t.Context(), pair.AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	withoutOld := newTestService(t, accountsStub{}, Config{Current: Key{ID: "v2", Secret: "secret-v2"}})
+
+	_, err = withoutOld.Validate(t.Context(), pair.AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestService_Reconfigure(t *testing.T) {
+	t.Parallel()
+
+	admin := repository.Admin{Login: "german", Role: repository.RoleViewer}
+	service := newTestService(t, accountsStub{}, Config{Current: Key{ID: "v1", Secret: "secret-v1"}})
+
+	before, err := service.Issue(t.Context(), admin)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, service.Reconfigure(Config{}), ErrNoSigningKey)
+
+	require.NoError(t, service.Reconfigure(Config{
+		Current:   Key{ID: "v2", Secret: "secret-v2"},
+		Previous:  []Key{{ID: "v1", Secret: "secret-v1", Until: time.Now().Add(time.Hour)}},
+		AccessTTL: time.Minute,
+	}))
+
+	_, err = service.Validate(t.Context(), before.AccessToken)
+	require.NoError(t, err, "tokens of the previous key stay valid")
+
+	after, err := service.Issue(t.Context(), admin)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Minute), after.AccessExpiresAt, 5*time.Second)
+
+	require.NoError(t, service.Reconfigure(Config{Current: Key{ID: "v2", Secret: "secret-v2"}}))
+
+	_, err = service.Validate(t.Context(), before.AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken, "the dropped key is rejected")
+
+	_, err = service.Validate(t.Context(), after.AccessToken)
+	require.NoError(t, err)
+}
+
+func TestNew_RequiresSecret(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(inmemory.New(), accountsStub{}, Config{})
+	require.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+func TestService_Telegram(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t, accountsStub{}, Config{})
+
+	pair, user, err := service.IssueTelegram(t.Context(), 42, "german")
+	require.NoError(t, err)
+	require.Equal(t, repository.Admin{Login: "tg:42", Role: repository.RoleViewer}, user)
+
+	claims, err := service.Validate(t.Context(), pair.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, "tg:42", claims.Login)
+	require.Equal(t, repository.RoleViewer, claims.Role)
+	require.Equal(t, int64(42), claims.TelegramID)
+	require.Equal(t, "german", claims.TelegramUsername)
+
+	// Telegram-пользователей нет среди админов, обновление не должно их искать
+	refreshed, admin, err := service.Refresh(t.Context(), pair.RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, "tg:42", admin.Login)
+
+	claims, err = service.Validate(t.Context(), refreshed.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), claims.TelegramID)
+	require.Equal(t, "german", claims.TelegramUsername)
+
+	// Право на изменения выдаётся только явно
+	service = newTestService(t, accountsStub{}, Config{TelegramRole: repository.RoleOperator})
+
+	pair, _, err = service.IssueTelegram(t.Context(), 42, "german")
+	require.NoError(t, err)
+
+	claims, err = service.Validate(t.Context(), pair.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, repository.RoleOperator, claims.Role)
+}
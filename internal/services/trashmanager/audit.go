@@ -0,0 +1,114 @@
+package trashmanager
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+type ActorType string
+
+const (
+	ActorTelegram ActorType = "telegram"
+	ActorAdmin    ActorType = "admin"
+	ActorSystem   ActorType = "system"
+)
+
+type Source string
+
+const (
+	SourceBot    Source = "bot"
+	SourcePanel  Source = "panel"
+	SourceSystem Source = "system"
+)
+
+// Actor is who made a change, it is recorded in the audit log.
+type Actor struct {
+	Type   ActorType
+	ID     string
+	Name   string
+	Source Source
+}
+
+// AuditRecorder stores audit entries. Recording must not fail the change, so
+// errors are handled by the recorder itself.
+type AuditRecorder interface {
+	Record(ctx context.Context, entry repository.AuditEntry)
+}
+
+type actorKey struct{}
+
+// WithActor sets who makes changes with the returned context.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, the system by default.
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+
+	return Actor{Type: ActorSystem, Source: SourceSystem}
+}
+
+// WithAudit makes the service record every change to the recorder.
+func (s *Service) WithAudit(recorder AuditRecorder) *Service {
+	s.audit = recorder
+
+	return s
+}
+
+// snapshot returns the chat state before a change, nil when the chat does not
+// exist or audit is disabled.
+func (s *Service) snapshot(ctx context.Context, chatID int64) *repository.Chat {
+	if s.audit == nil {
+		return nil
+	}
+
+	chat, err := s.repo.GetChat(ctx, chatID)
+	if err != nil {
+		return nil
+	}
+
+	return chat
+}
+
+// recordChange records a successful change of the chat, reading its new state.
+func (s *Service) recordChange(ctx context.Context, chatID int64, action EventType, before *repository.Chat) {
+	if s.audit == nil {
+		return
+	}
+
+	after, err := s.repo.GetChat(ctx, chatID)
+	if err != nil {
+		slog.ErrorContext(ctx, "get chat after change", logging.KeyChatID, chatID, "action", action, logging.Err(err))
+
+		after = nil
+	}
+
+	s.record(ctx, chatID, action, before, after)
+}
+
+func (s *Service) record(ctx context.Context, chatID int64, action EventType, before, after *repository.Chat) {
+	if s.audit == nil {
+		return
+	}
+
+	actor := ActorFromContext(ctx)
+
+	s.audit.Record(ctx, repository.AuditEntry{
+		OccurredAt: time.Now().UTC(),
+		ActorType:  string(actor.Type),
+		ActorID:    actor.ID,
+		ActorName:  actor.Name,
+		Source:     string(actor.Source),
+		ChatID:     chatID,
+		Action:     string(action),
+		Before:     before,
+		After:      after,
+	})
+}
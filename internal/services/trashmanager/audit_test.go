@@ -0,0 +1,118 @@
+package trashmanager
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/stretchr/testify/require"
+)
+
+type auditRecorder struct {
+	mu      sync.Mutex
+	entries []repository.AuditEntry
+}
+
+func (r *auditRecorder) Record(ctx context.Context, entry repository.AuditEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, entry)
+}
+
+func TestService_Audit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Changes are recorded with snapshots", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := &auditRecorder{}
+		service := New(inmemory.New()).WithAudit(recorder)
+
+		ctx := WithActor(t.Context(), Actor{
+			Type:   ActorTelegram,
+			ID:     "42",
+			Name:   "german",
+			Source: SourceBot,
+		})
+
+		require.NoError(t, service.SetEstablish(ctx, 1, []string{"German", "Anthon"}))
+
+		_, err := service.Next(ctx, 1)
+		require.NoError(t, err)
+
+		require.NoError(t, service.Subscribe(t.Context(), 1, "09:00"))
+
+		require.Len(t, recorder.entries, 3)
+
+		established := recorder.entries[0]
+		require.Equal(t, string(EventMembersChanged), established.Action)
+		require.Equal(t, "telegram", established.ActorType)
+		require.Equal(t, "42", established.ActorID)
+		require.Equal(t, "german", established.ActorName)
+		require.Equal(t, "bot", established.Source)
+		require.Equal(t, int64(1), established.ChatID)
+		require.Nil(t, established.Before)
+		require.Equal(t, []string{"German", "Anthon"}, established.After.Users)
+
+		next := recorder.entries[1]
+		require.Equal(t, string(EventRotationAdvanced), next.Action)
+		require.Equal(t, 0, next.Before.Current)
+		require.Equal(t, 1, next.After.Current)
+
+		subscribed := recorder.entries[2]
+		require.Equal(t, "system", subscribed.ActorType, "actor defaults to the system")
+		require.Nil(t, subscribed.Before.NotifyTime)
+		require.Equal(t, "09:00", *subscribed.After.NotifyTime)
+	})
+
+	t.Run("Failed change is not recorded", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := &auditRecorder{}
+		service := New(inmemory.New()).WithAudit(recorder)
+
+		_, err := service.Next(t.Context(), 1)
+		require.ErrorIs(t, err, ErrTryToInitialize)
+		require.ErrorIs(t, service.Subscribe(t.Context(), 1, "09:00"), ErrTryToInitialize)
+
+		require.Empty(t, recorder.entries)
+	})
+
+	t.Run("Import records created, updated and deleted chats", func(t *testing.T) {
+		t.Parallel()
+
+		repo := inmemory.New()
+		require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 1, Users: []string{"German"}}))
+		require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 2, Users: []string{"Anthon"}}))
+
+		recorder := &auditRecorder{}
+		service := New(repo).WithAudit(recorder)
+
+		data := Export{
+			Version: ExportVersion,
+			Chats: []repository.Chat{
+				{ID: 1, Users: []string{"Vitaly"}},
+				{ID: 3, Users: []string{"Anthon"}},
+			},
+		}
+
+		_, err := service.Import(t.Context(), data, ImportOptions{Mode: ImportModeReplace})
+		require.NoError(t, err)
+
+		require.Len(t, recorder.entries, 3)
+
+		require.Equal(t, string(EventChatImported), recorder.entries[0].Action)
+		require.Equal(t, []string{"German"}, recorder.entries[0].Before.Users)
+		require.Equal(t, []string{"Vitaly"}, recorder.entries[0].After.Users)
+
+		require.Nil(t, recorder.entries[1].Before)
+		require.Equal(t, int64(3), recorder.entries[1].After.ID)
+
+		require.Equal(t, string(EventChatDeleted), recorder.entries[2].Action)
+		require.Equal(t, int64(2), recorder.entries[2].Before.ID)
+		require.Nil(t, recorder.entries[2].After)
+	})
+}
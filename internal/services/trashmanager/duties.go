@@ -0,0 +1,132 @@
+package trashmanager
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/tracing"
+)
+
+// MemberDuty is the part of a member in the duty report.
+type MemberDuty struct {
+	Name string
+	// Done is the number of duties in the period, taken back duties excluded.
+	Done    int
+	Skipped int
+	// Streak is the number of duties done in a row since the last skip,
+	// it is not limited by the period.
+	Streak int
+}
+
+// DutyReport shows how the duties of a chat were shared since Since. Members
+// are the current members, with no duties too, followed by former members
+// who have entries in the period.
+type DutyReport struct {
+	ChatID  int64
+	Since   time.Time // zero for the whole history
+	Members []MemberDuty
+}
+
+// DutyReport builds the report from the persisted duty history of the chat,
+// zero since covers the whole history.
+func (s *Service) DutyReport(ctx context.Context, chatID int64, since time.Time) (_ DutyReport, err error) {
+	ctx, span := startSpan(ctx, "DutyReport", chatID)
+	defer func() { tracing.End(span, err) }()
+
+	chat, err := s.repo.GetChat(ctx, chatID)
+
+	switch {
+	case err == nil:
+		break
+	case errors.Is(err, repository.ErrChatIsNotInitialize):
+		return DutyReport{}, ErrTryToInitialize
+	default:
+		return DutyReport{}, fmt.Errorf("get chat for duty report: %w", err)
+	}
+
+	duties, err := s.repo.GetDuties(ctx, chatID)
+	if err != nil {
+		return DutyReport{}, fmt.Errorf("get duties from repo: %w", err)
+	}
+
+	byName := make(map[string]*MemberDuty)
+
+	member := func(name string) *MemberDuty {
+		if byName[name] == nil {
+			byName[name] = &MemberDuty{Name: name}
+		}
+
+		return byName[name]
+	}
+
+	for _, user := range chat.Users {
+		member(user)
+	}
+
+	for _, duty := range duties {
+		current := member(duty.Member)
+
+		// Серия считается по всей истории, отметка о пропуске её обнуляет
+		if duty.Skipped {
+			current.Streak = 0
+		} else {
+			current.Streak++
+		}
+
+		if duty.OccurredAt.Before(since) {
+			continue
+		}
+
+		if duty.Skipped {
+			current.Skipped++
+			// Пропуск отменяет засчитанное дежурство
+			current.Done = max(current.Done-1, 0)
+		} else {
+			current.Done++
+		}
+	}
+
+	report := DutyReport{ChatID: chatID, Since: since}
+	former := make([]MemberDuty, 0)
+
+	for name, duty := range byName {
+		switch {
+		case slices.Contains(chat.Users, name):
+			report.Members = append(report.Members, *duty)
+		case duty.Done > 0 || duty.Skipped > 0:
+			former = append(former, *duty)
+		}
+	}
+
+	byDone := func(a, b MemberDuty) int {
+		return cmp.Or(cmp.Compare(b.Done, a.Done), cmp.Compare(a.Skipped, b.Skipped), cmp.Compare(a.Name, b.Name))
+	}
+
+	slices.SortFunc(report.Members, byDone)
+	slices.SortFunc(former, byDone)
+	report.Members = append(report.Members, former...)
+
+	return report, nil
+}
+
+// recordDuty appends an entry to the duty history. The history must not fail
+// the rotation, so a failure is only logged.
+func (s *Service) recordDuty(ctx context.Context, chatID int64, member string, skipped bool) {
+	duty := repository.Duty{
+		ChatID:     chatID,
+		Member:     member,
+		OccurredAt: time.Now(),
+		Skipped:    skipped,
+	}
+
+	if err := s.repo.AddDuty(context.WithoutCancel(ctx), duty); err != nil {
+		slog.ErrorContext(ctx, "record duty", logging.KeyChatID, chatID, "member", member, logging.Err(err))
+	}
+}
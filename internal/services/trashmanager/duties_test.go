@@ -0,0 +1,87 @@
+package trashmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_DutyReport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Rotation records the history", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newMockRepo()
+		repo.chats[1] = &repository.Chat{ID: 1, Users: []string{"German", "Anthon", "Vitaly"}}
+
+		service := New(repo)
+		ctx := t.Context()
+
+		for range 3 {
+			_, err := service.Next(ctx, 1)
+			require.NoError(t, err)
+		}
+
+		// Vitaly не вынес мусор, очередь вернули к нему
+		_, err := service.Prev(ctx, 1)
+		require.NoError(t, err)
+
+		report, err := service.DutyReport(ctx, 1, time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, []MemberDuty{
+			{Name: "Anthon", Done: 1, Streak: 1},
+			{Name: "German", Done: 1, Streak: 1},
+			{Name: "Vitaly", Skipped: 1},
+		}, report.Members)
+	})
+
+	t.Run("Period, streaks and former members", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Now()
+		monthAgo := now.AddDate(0, -1, 0)
+
+		repo := newMockRepo()
+		repo.chats[1] = &repository.Chat{ID: 1, Users: []string{"German", "Anthon", "Vitaly"}}
+		repo.duties = []repository.Duty{
+			{ChatID: 1, Member: "German", OccurredAt: monthAgo.Add(-time.Hour)},
+			{ChatID: 1, Member: "Kirill", OccurredAt: monthAgo.Add(-time.Hour)},
+			{ChatID: 1, Member: "German", OccurredAt: now.Add(-3 * time.Hour)},
+			{ChatID: 1, Member: "Anthon", OccurredAt: now.Add(-3 * time.Hour)},
+			{ChatID: 1, Member: "Anthon", OccurredAt: now.Add(-2 * time.Hour), Skipped: true},
+			{ChatID: 1, Member: "Anthon", OccurredAt: now.Add(-time.Hour)},
+			{ChatID: 2, Member: "German", OccurredAt: now},
+		}
+
+		service := New(repo)
+
+		report, err := service.DutyReport(t.Context(), 1, monthAgo)
+		require.NoError(t, err)
+		require.Equal(t, []MemberDuty{
+			{Name: "German", Done: 1, Streak: 2},
+			{Name: "Anthon", Done: 1, Skipped: 1, Streak: 1},
+			{Name: "Vitaly"},
+		}, report.Members)
+
+		report, err = service.DutyReport(t.Context(), 1, time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, []MemberDuty{
+			{Name: "German", Done: 2, Streak: 2},
+			{Name: "Anthon", Done: 1, Skipped: 1, Streak: 1},
+			{Name: "Vitaly"},
+			{Name: "Kirill", Done: 1, Streak: 1},
+		}, report.Members)
+	})
+
+	t.Run("Unknown chat", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(newMockRepo())
+
+		_, err := service.DutyReport(t.Context(), 1, time.Time{})
+		require.ErrorIs(t, err, ErrTryToInitialize)
+	})
+}
@@ -0,0 +1,161 @@
+package trashmanager
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/6ermvH/trash-bot/internal/logging"
+)
+
+const asyncQueueSize = 256
+
+// EventBus delivers events to synchronous and asynchronous subscribers.
+// A panic in a subscriber is recovered and does not affect the others.
+type EventBus struct {
+	mu     sync.RWMutex
+	sync   []EventHandler
+	async  []*asyncSubscriber
+	closed bool
+}
+
+type asyncSubscriber struct {
+	handler EventHandler
+	queue   chan queuedEvent
+	done    chan struct{}
+}
+
+type queuedEvent struct {
+	ctx   context.Context //nolint:containedctx // event is handled after the publisher returned
+	event Event
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+func (b *EventBus) Subscribe(handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sync = append(b.sync, handler)
+}
+
+func (b *EventBus) SubscribeAsync(handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	sub := &asyncSubscriber{
+		handler: handler,
+		queue:   make(chan queuedEvent, asyncQueueSize),
+		done:    make(chan struct{}),
+	}
+	b.async = append(b.async, sub)
+
+	go sub.run()
+}
+
+func (b *EventBus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	closed, handlers := b.closed, b.sync
+	b.mu.RUnlock()
+
+	if closed {
+		return
+	}
+
+	// Обработчики вызываются без блокировки, чтобы они могли сами вызывать методы сервиса
+	for _, handler := range handlers {
+		callHandler(ctx, handler, event)
+	}
+
+	b.enqueue(ctx, event)
+}
+
+func (b *EventBus) enqueue(ctx context.Context, event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed || len(b.async) == 0 {
+		return
+	}
+
+	// Асинхронные подписчики не должны зависеть от отмены контекста запроса
+	asyncCtx := context.WithoutCancel(ctx)
+
+	for _, sub := range b.async {
+		select {
+		case sub.queue <- queuedEvent{ctx: asyncCtx, event: event}:
+		default:
+			slog.WarnContext(ctx, "event bus queue is full, event dropped",
+				"event", event.Type(), logging.KeyChatID, event.Meta().ChatID)
+		}
+	}
+}
+
+// Close stops accepting events and waits until asynchronous subscribers
+// handled everything already queued.
+func (b *EventBus) Close() {
+	_ = b.Shutdown(context.Background())
+}
+
+// Shutdown stops accepting events and waits until asynchronous subscribers
+// handled everything already queued. When ctx is done first, an error with the
+// number of events still queued is returned.
+func (b *EventBus) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+
+	if b.closed {
+		b.mu.Unlock()
+
+		return nil
+	}
+
+	b.closed = true
+	subs := b.async
+
+	for _, sub := range subs {
+		close(sub.queue)
+	}
+
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case <-sub.done:
+		case <-ctx.Done():
+			left := 0
+			for _, queued := range subs {
+				left += len(queued.queue)
+			}
+
+			return fmt.Errorf("flush events, %d left: %w", left, ctx.Err())
+		}
+	}
+
+	return nil
+}
+
+func (s *asyncSubscriber) run() {
+	defer close(s.done)
+
+	for queued := range s.queue {
+		callHandler(queued.ctx, s.handler, queued.event)
+	}
+}
+
+func callHandler(ctx context.Context, handler EventHandler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "event handler panic",
+				"event", event.Type(), logging.KeyChatID, event.Meta().ChatID, "panic", r)
+		}
+	}()
+
+	handler(ctx, event)
+}
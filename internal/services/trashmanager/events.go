@@ -0,0 +1,202 @@
+package trashmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+)
+
+type EventType string
+
+var ErrUnknownEvent = errors.New("unknown event type")
+
+const (
+	EventChatCreated      EventType = "chat.created"
+	EventChatImported     EventType = "chat.imported"
+	EventChatDeleted      EventType = "chat.deleted"
+	EventRotationAdvanced EventType = "rotation.advanced"
+	EventRotationReverted EventType = "rotation.reverted"
+	EventMembersChanged   EventType = "members.changed"
+	EventSubscribed       EventType = "subscription.enabled"
+	EventUnsubscribed     EventType = "subscription.disabled"
+)
+
+// EventMeta is common data of every domain event.
+type EventMeta struct {
+	ChatID     int64
+	OccurredAt time.Time
+	// Announce is set when the change should be announced in the chat itself.
+	Announce bool
+}
+
+func (m EventMeta) Meta() EventMeta {
+	return m
+}
+
+// Event is emitted by Service after a successful repository write.
+type Event interface {
+	Meta() EventMeta
+	Type() EventType
+}
+
+// ChatCreated is emitted when a chat is initialized for the first time.
+type ChatCreated struct {
+	EventMeta
+}
+
+func (ChatCreated) Type() EventType { return EventChatCreated }
+
+// ChatImported is emitted for every chat written by Import.
+type ChatImported struct {
+	EventMeta
+
+	Chat repository.Chat
+}
+
+func (ChatImported) Type() EventType { return EventChatImported }
+
+// ChatDeleted is emitted for every chat removed by Import in replace mode.
+type ChatDeleted struct {
+	EventMeta
+}
+
+func (ChatDeleted) Type() EventType { return EventChatDeleted }
+
+// RotationAdvanced is emitted after the rotation moved to the next user.
+type RotationAdvanced struct {
+	EventMeta
+
+	// Previous is the user whose turn has ended.
+	Previous string
+	Current  string
+}
+
+func (RotationAdvanced) Type() EventType { return EventRotationAdvanced }
+
+// RotationReverted is emitted after the rotation moved back to the previous user.
+type RotationReverted struct {
+	EventMeta
+
+	Current string
+}
+
+func (RotationReverted) Type() EventType { return EventRotationReverted }
+
+// MembersChanged is emitted after the list of users was replaced.
+type MembersChanged struct {
+	EventMeta
+
+	Users []string
+}
+
+func (MembersChanged) Type() EventType { return EventMembersChanged }
+
+// Subscribed is emitted after the daily reminder was enabled or its time changed.
+type Subscribed struct {
+	EventMeta
+
+	NotifyTime string
+}
+
+func (Subscribed) Type() EventType { return EventSubscribed }
+
+// Unsubscribed is emitted after the daily reminder was disabled.
+type Unsubscribed struct {
+	EventMeta
+}
+
+func (Unsubscribed) Type() EventType { return EventUnsubscribed }
+
+// UnmarshalEvent decodes an event encoded with encoding/json, e.g. by another
+// process sharing the database.
+func UnmarshalEvent(eventType EventType, data []byte) (Event, error) {
+	switch eventType {
+	case EventChatCreated:
+		return unmarshalEvent[ChatCreated](data)
+	case EventChatImported:
+		return unmarshalEvent[ChatImported](data)
+	case EventChatDeleted:
+		return unmarshalEvent[ChatDeleted](data)
+	case EventRotationAdvanced:
+		return unmarshalEvent[RotationAdvanced](data)
+	case EventRotationReverted:
+		return unmarshalEvent[RotationReverted](data)
+	case EventMembersChanged:
+		return unmarshalEvent[MembersChanged](data)
+	case EventSubscribed:
+		return unmarshalEvent[Subscribed](data)
+	case EventUnsubscribed:
+		return unmarshalEvent[Unsubscribed](data)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEvent, eventType)
+	}
+}
+
+func unmarshalEvent[E Event](data []byte) (Event, error) {
+	var event E
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("decode %s event: %w", event.Type(), err)
+	}
+
+	return event, nil
+}
+
+type EventHandler func(ctx context.Context, event Event)
+
+// On adapts a handler of one concrete event type to EventHandler,
+// other events are ignored.
+func On[E Event](handler func(ctx context.Context, event E)) EventHandler {
+	return func(ctx context.Context, event Event) {
+		if typed, ok := event.(E); ok {
+			handler(ctx, typed)
+		}
+	}
+}
+
+type announceKey struct{}
+
+// WithAnnounce marks changes made with the returned context to be announced in the chat.
+func WithAnnounce(ctx context.Context) context.Context {
+	return context.WithValue(ctx, announceKey{}, true)
+}
+
+func announceFromContext(ctx context.Context) bool {
+	announce, _ := ctx.Value(announceKey{}).(bool)
+
+	return announce
+}
+
+// OnEvent registers a handler called synchronously for every event, before
+// the changing method returns.
+func (s *Service) OnEvent(handler EventHandler) {
+	s.events.Subscribe(handler)
+}
+
+// OnEventAsync registers a handler called for every event in its own goroutine,
+// in the order the events were emitted.
+func (s *Service) OnEventAsync(handler EventHandler) {
+	s.events.SubscribeAsync(handler)
+}
+
+// Close stops event delivery and waits for queued asynchronous events to be handled.
+func (s *Service) Close() {
+	s.events.Close()
+}
+
+// Shutdown stops event delivery and waits for queued asynchronous events to be
+// handled until ctx is done.
+func (s *Service) Shutdown(ctx context.Context) error {
+	return s.events.Shutdown(ctx) //nolint:wrapcheck // the error describes the lost events
+}
+
+func (s *Service) newMeta(ctx context.Context, chatID int64) EventMeta {
+	return EventMeta{
+		ChatID:     chatID,
+		OccurredAt: time.Now(),
+		Announce:   announceFromContext(ctx),
+	}
+}
@@ -0,0 +1,320 @@
+package trashmanager
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/repository/inmemory"
+	"github.com/stretchr/testify/require"
+)
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *eventRecorder) handle(ctx context.Context, event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) types() []EventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	types := make([]EventType, 0, len(r.events))
+	for _, event := range r.events {
+		types = append(types, event.Type())
+	}
+
+	return types
+}
+
+func TestService_Events(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Changes emit events", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+		ctx := t.Context()
+
+		recorder := &eventRecorder{}
+		service.OnEvent(recorder.handle)
+
+		require.NoError(t, service.SetEstablish(ctx, 1, []string{"German", "Anthon"}))
+
+		_, err := service.Next(ctx, 1)
+		require.NoError(t, err)
+
+		_, err = service.Prev(ctx, 1)
+		require.NoError(t, err)
+
+		require.NoError(t, service.Subscribe(ctx, 1, "09:00"))
+		require.NoError(t, service.Unsubscribe(ctx, 1))
+		require.NoError(t, service.SetEstablish(ctx, 1, []string{"Vitaly"}))
+
+		require.Equal(t, []EventType{
+			EventChatCreated,
+			EventMembersChanged,
+			EventRotationAdvanced,
+			EventRotationReverted,
+			EventSubscribed,
+			EventUnsubscribed,
+			EventMembersChanged,
+		}, recorder.types())
+
+		events := recorder.events
+		require.Equal(t, []string{"German", "Anthon"}, events[1].(MembersChanged).Users)
+		require.Equal(t, "Anthon", events[2].(RotationAdvanced).Current)
+		require.Equal(t, "German", events[2].(RotationAdvanced).Previous)
+		require.Equal(t, "German", events[3].(RotationReverted).Current)
+		require.Equal(t, "09:00", events[4].(Subscribed).NotifyTime)
+
+		for _, event := range events {
+			require.Equal(t, int64(1), event.Meta().ChatID)
+			require.False(t, event.Meta().Announce)
+			require.False(t, event.Meta().OccurredAt.IsZero())
+		}
+	})
+
+	t.Run("Failed change emits nothing", func(t *testing.T) {
+		t.Parallel()
+
+		repo := inmemory.New()
+		require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 1}))
+
+		service := New(repo)
+
+		recorder := &eventRecorder{}
+		service.OnEvent(recorder.handle)
+
+		_, err := service.Next(t.Context(), 1)
+		require.ErrorIs(t, err, ErrTryToAddUsers)
+
+		require.NoError(t, service.Subscribe(t.Context(), 1, "09:00"))
+		require.ErrorIs(t, service.Subscribe(t.Context(), 2, "09:00"), ErrTryToInitialize)
+
+		require.Equal(t, []EventType{EventSubscribed}, recorder.types())
+	})
+
+	t.Run("Import emits events", func(t *testing.T) {
+		t.Parallel()
+
+		repo := inmemory.New()
+		require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 1, Users: []string{"German"}}))
+
+		service := New(repo)
+
+		recorder := &eventRecorder{}
+		service.OnEvent(recorder.handle)
+
+		data := Export{
+			Version: ExportVersion,
+			Chats:   []repository.Chat{{ID: 2, Users: []string{"Anthon"}}},
+		}
+
+		_, err := service.Import(t.Context(), data, ImportOptions{Mode: ImportModeReplace})
+		require.NoError(t, err)
+
+		require.Equal(t, []EventType{EventChatImported, EventChatDeleted}, recorder.types())
+		require.Equal(t, int64(2), recorder.events[0].Meta().ChatID)
+		require.Equal(t, int64(1), recorder.events[1].Meta().ChatID)
+	})
+
+	t.Run("Announce flag comes from context", func(t *testing.T) {
+		t.Parallel()
+
+		repo := inmemory.New()
+		require.NoError(t, repo.SaveChat(t.Context(), repository.Chat{ID: 1, Users: []string{"German"}}))
+
+		service := New(repo)
+
+		recorder := &eventRecorder{}
+		service.OnEvent(recorder.handle)
+
+		_, err := service.Next(WithAnnounce(t.Context()), 1)
+		require.NoError(t, err)
+
+		require.Len(t, recorder.events, 1)
+		require.True(t, recorder.events[0].Meta().Announce)
+	})
+
+	t.Run("Typed handler receives only its events", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+		ctx := t.Context()
+
+		var currents []string
+
+		service.OnEvent(On(func(ctx context.Context, event RotationAdvanced) {
+			currents = append(currents, event.Current)
+		}))
+
+		require.NoError(t, service.SetEstablish(ctx, 1, []string{"German", "Anthon"}))
+
+		for range 2 {
+			_, err := service.Next(ctx, 1)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, []string{"Anthon", "German"}, currents)
+	})
+}
+
+func TestService_AsyncEvents(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Close waits for queued events in order", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+		ctx := t.Context()
+
+		recorder := &eventRecorder{}
+		service.OnEventAsync(func(ctx context.Context, event Event) {
+			time.Sleep(time.Millisecond)
+			recorder.handle(ctx, event)
+		})
+
+		require.NoError(t, service.SetEstablish(ctx, 1, []string{"German", "Anthon"}))
+
+		_, err := service.Next(ctx, 1)
+		require.NoError(t, err)
+
+		service.Close()
+
+		require.Equal(t, []EventType{
+			EventChatCreated,
+			EventMembersChanged,
+			EventRotationAdvanced,
+		}, recorder.types())
+	})
+
+	t.Run("Async handler outlives request context", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+
+		received := make(chan error, 1)
+
+		service.OnEventAsync(On(func(ctx context.Context, event MembersChanged) {
+			received <- ctx.Err()
+		}))
+
+		ctx, cancel := context.WithCancel(t.Context())
+		require.NoError(t, service.SetEstablish(ctx, 1, []string{"German"}))
+		cancel()
+
+		service.Close()
+
+		require.NoError(t, <-received)
+	})
+
+	t.Run("Events after close are dropped", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+
+		recorder := &eventRecorder{}
+		service.OnEventAsync(recorder.handle)
+		service.Close()
+
+		require.NoError(t, service.SetEstablish(t.Context(), 1, []string{"German"}))
+
+		require.Empty(t, recorder.types())
+	})
+
+	t.Run("Shutdown gives up on timeout", func(t *testing.T) {
+		t.Parallel()
+
+		service := New(inmemory.New())
+
+		started := make(chan struct{}, 2)
+		release := make(chan struct{})
+		service.OnEventAsync(func(context.Context, Event) {
+			started <- struct{}{}
+			<-release
+		})
+
+		// Первое событие обрабатывается, второе остаётся в очереди
+		require.NoError(t, service.SetEstablish(t.Context(), 1, []string{"German"}))
+		<-started
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		err := service.Shutdown(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorContains(t, err, "1 left")
+
+		close(release)
+	})
+}
+
+func TestService_EventHandlerPanic(t *testing.T) {
+	t.Parallel()
+
+	service := New(inmemory.New())
+	ctx := t.Context()
+
+	service.OnEvent(func(ctx context.Context, event Event) {
+		panic("sync boom")
+	})
+	service.OnEventAsync(func(ctx context.Context, event Event) {
+		panic("async boom")
+	})
+
+	syncRecorder := &eventRecorder{}
+	service.OnEvent(syncRecorder.handle)
+
+	asyncRecorder := &eventRecorder{}
+	service.OnEventAsync(asyncRecorder.handle)
+
+	require.NoError(t, service.SetEstablish(ctx, 1, []string{"German"}))
+
+	_, err := service.Next(ctx, 1)
+	require.NoError(t, err)
+
+	service.Close()
+
+	expected := []EventType{EventChatCreated, EventMembersChanged, EventRotationAdvanced}
+	require.Equal(t, expected, syncRecorder.types())
+	require.Equal(t, expected, asyncRecorder.types())
+}
+
+func TestUnmarshalEvent(t *testing.T) {
+	t.Parallel()
+
+	meta := EventMeta{ChatID: 1, OccurredAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), Announce: true}
+
+	events := []Event{
+		ChatCreated{EventMeta: meta},
+		ChatImported{EventMeta: meta, Chat: repository.Chat{ID: 1, Users: []string{"German"}}},
+		ChatDeleted{EventMeta: meta},
+		RotationAdvanced{EventMeta: meta, Previous: "German", Current: "Anthon"},
+		RotationReverted{EventMeta: meta, Current: "German"},
+		MembersChanged{EventMeta: meta, Users: []string{"German", "Anthon"}},
+		Subscribed{EventMeta: meta, NotifyTime: "09:00"},
+		Unsubscribed{EventMeta: meta},
+	}
+
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		require.NoError(t, err)
+
+		decoded, err := UnmarshalEvent(event.Type(), data)
+		require.NoError(t, err)
+		require.Equal(t, event, decoded)
+	}
+
+	_, err := UnmarshalEvent("chat.renamed", []byte("{}"))
+	require.ErrorIs(t, err, ErrUnknownEvent)
+}
@@ -0,0 +1,201 @@
+package trashmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/tracing"
+)
+
+// ExportVersion is the version of the export document format.
+const ExportVersion = 1
+
+const notifyTimeLayout = "15:04"
+
+var (
+	ErrUnsupportedExportVersion = errors.New("unsupported export version")
+	ErrUnknownImportMode        = errors.New("unknown import mode")
+	ErrInvalidNotifyTime        = errors.New("notify time must be in HH:MM format")
+	ErrInvalidImportChat        = errors.New("invalid chat")
+)
+
+type ImportMode string
+
+const (
+	// ImportModeMerge creates or overwrites imported chats and keeps the others.
+	ImportModeMerge ImportMode = "merge"
+	// ImportModeReplace makes the stored chats exactly match the imported ones.
+	ImportModeReplace ImportMode = "replace"
+)
+
+type Export struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exportedAt"`
+	Chats      []repository.Chat `json:"chats"`
+}
+
+type ImportOptions struct {
+	Mode   ImportMode
+	DryRun bool
+}
+
+type ImportChatError struct {
+	ChatID int64  `json:"chatId"`
+	Index  int    `json:"index"`
+	Error  string `json:"error"`
+}
+
+type ImportResult struct {
+	Mode    ImportMode        `json:"mode"`
+	DryRun  bool              `json:"dryRun"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Deleted int               `json:"deleted"`
+	Errors  []ImportChatError `json:"errors,omitempty"`
+}
+
+// ValidateNotifyTime checks that notifyTime is a time of day in HH:MM format.
+func ValidateNotifyTime(notifyTime string) error {
+	if _, err := time.Parse(notifyTimeLayout, notifyTime); err != nil || len(notifyTime) != len(notifyTimeLayout) {
+		return ErrInvalidNotifyTime
+	}
+
+	return nil
+}
+
+func (s *Service) Export(ctx context.Context) (_ Export, err error) {
+	ctx, span := startSpan(ctx, "Export", 0)
+	defer func() { tracing.End(span, err) }()
+
+	chats, err := s.repo.GetChats(ctx)
+	if err != nil {
+		return Export{}, fmt.Errorf("get chats for export: %w", err)
+	}
+
+	return Export{
+		Version:    ExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Chats:      chats,
+	}, nil
+}
+
+// Import applies the export document to the repository in one transaction. If
+// any chat fails validation nothing is written and the result lists the errors
+// per chat.
+func (s *Service) Import(ctx context.Context, data Export, opts ImportOptions) (_ ImportResult, err error) {
+	ctx, span := startSpan(ctx, "Import", 0)
+	defer func() { tracing.End(span, err) }()
+
+	if data.Version != ExportVersion {
+		return ImportResult{}, fmt.Errorf("%w: %d", ErrUnsupportedExportVersion, data.Version)
+	}
+
+	if opts.Mode != ImportModeMerge && opts.Mode != ImportModeReplace {
+		return ImportResult{}, fmt.Errorf("%w: %q", ErrUnknownImportMode, opts.Mode)
+	}
+
+	result := ImportResult{Mode: opts.Mode, DryRun: opts.DryRun}
+
+	seen := make(map[int64]bool, len(data.Chats))
+
+	for ind, chat := range data.Chats {
+		if err := validateImportChat(chat, seen); err != nil {
+			result.Errors = append(result.Errors, ImportChatError{
+				ChatID: chat.ID,
+				Index:  ind,
+				Error:  err.Error(),
+			})
+		}
+
+		seen[chat.ID] = true
+	}
+
+	existing, err := s.repo.GetChats(ctx)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("get chats for import: %w", err)
+	}
+
+	stored := make(map[int64]*repository.Chat, len(existing))
+	for ind := range existing {
+		stored[existing[ind].ID] = &existing[ind]
+	}
+
+	for _, chat := range data.Chats {
+		if stored[chat.ID] != nil {
+			result.Updated++
+		} else {
+			result.Created++
+		}
+	}
+
+	var toDelete []int64
+
+	if opts.Mode == ImportModeReplace {
+		for _, chat := range existing {
+			if !seen[chat.ID] {
+				toDelete = append(toDelete, chat.ID)
+			}
+		}
+	}
+
+	result.Deleted = len(toDelete)
+
+	if opts.DryRun || len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	if err := s.repo.ImportChats(ctx, data.Chats, toDelete); err != nil {
+		return ImportResult{}, fmt.Errorf("import chats in repo: %w", err)
+	}
+
+	for _, chat := range data.Chats {
+		s.record(ctx, chat.ID, EventChatImported, stored[chat.ID], &chat)
+		s.events.Publish(ctx, ChatImported{EventMeta: s.newMeta(ctx, chat.ID), Chat: chat})
+	}
+
+	for _, chatID := range toDelete {
+		s.record(ctx, chatID, EventChatDeleted, stored[chatID], nil)
+		s.events.Publish(ctx, ChatDeleted{EventMeta: s.newMeta(ctx, chatID)})
+	}
+
+	return result, nil
+}
+
+func validateImportChat(chat repository.Chat, seen map[int64]bool) error {
+	var problems []string
+
+	if chat.ID == 0 {
+		problems = append(problems, "chat id is required")
+	}
+
+	if seen[chat.ID] {
+		problems = append(problems, "duplicate chat id")
+	}
+
+	for ind, user := range chat.Users {
+		if strings.TrimSpace(user) == "" {
+			problems = append(problems, fmt.Sprintf("user %d is empty", ind))
+		}
+	}
+
+	if chat.Current < 0 || (len(chat.Users) > 0 && chat.Current >= len(chat.Users)) ||
+		(len(chat.Users) == 0 && chat.Current != 0) {
+		problems = append(problems, fmt.Sprintf("current user index %d is out of range", chat.Current))
+	}
+
+	if chat.NotifyTime != nil {
+		if err := ValidateNotifyTime(*chat.NotifyTime); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidImportChat, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
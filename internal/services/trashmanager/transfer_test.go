@@ -0,0 +1,189 @@
+package trashmanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ExportImport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Export then import into empty repo", func(t *testing.T) {
+		t.Parallel()
+
+		notifyTime := "09:00"
+		source := newMockRepo()
+		source.chats[1] = &repository.Chat{
+			ID:         1,
+			Users:      []string{"German", "Anthon"},
+			Current:    1,
+			NotifyTime: &notifyTime,
+		}
+		source.chats[2] = &repository.Chat{ID: 2, Users: []string{"Vitaly"}}
+
+		ctx := t.Context()
+
+		data, err := New(source).Export(ctx)
+		require.NoError(t, err)
+		require.Equal(t, ExportVersion, data.Version)
+		require.Len(t, data.Chats, 2)
+
+		target := newMockRepo()
+
+		result, err := New(target).Import(ctx, data, ImportOptions{Mode: ImportModeMerge})
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+		require.Equal(t, 2, result.Created)
+
+		require.Equal(t, *source.chats[1], *target.chats[1])
+		require.Equal(t, *source.chats[2], *target.chats[2])
+	})
+
+	t.Run("Merge keeps other chats", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newMockRepo()
+		repo.chats[1] = &repository.Chat{ID: 1, Users: []string{"German"}}
+		repo.chats[2] = &repository.Chat{ID: 2, Users: []string{"Anthon"}}
+
+		data := Export{
+			Version: ExportVersion,
+			Chats:   []repository.Chat{{ID: 1, Users: []string{"Vitaly", "German"}, Current: 1}},
+		}
+
+		result, err := New(repo).Import(t.Context(), data, ImportOptions{Mode: ImportModeMerge})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Updated)
+		require.Zero(t, result.Deleted)
+
+		require.Len(t, repo.chats, 2)
+		require.Equal(t, []string{"Vitaly", "German"}, repo.chats[1].Users)
+		require.Equal(t, 1, repo.chats[1].Current)
+	})
+
+	t.Run("Replace deletes missing chats", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newMockRepo()
+		repo.chats[1] = &repository.Chat{ID: 1, Users: []string{"German"}}
+		repo.chats[2] = &repository.Chat{ID: 2, Users: []string{"Anthon"}}
+
+		data := Export{
+			Version: ExportVersion,
+			Chats:   []repository.Chat{{ID: 3, Users: []string{"Vitaly"}}},
+		}
+
+		result, err := New(repo).Import(t.Context(), data, ImportOptions{Mode: ImportModeReplace})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Created)
+		require.Equal(t, 2, result.Deleted)
+
+		require.Len(t, repo.chats, 1)
+		require.Contains(t, repo.chats, int64(3))
+	})
+
+	t.Run("Dry run does not write", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newMockRepo()
+		repo.chats[1] = &repository.Chat{ID: 1, Users: []string{"German"}}
+
+		data := Export{
+			Version: ExportVersion,
+			Chats:   []repository.Chat{{ID: 2, Users: []string{"Anthon"}}},
+		}
+
+		result, err := New(repo).Import(t.Context(), data, ImportOptions{Mode: ImportModeReplace, DryRun: true})
+		require.NoError(t, err)
+		require.True(t, result.DryRun)
+		require.Equal(t, 1, result.Created)
+		require.Equal(t, 1, result.Deleted)
+
+		require.Len(t, repo.chats, 1)
+		require.Contains(t, repo.chats, int64(1))
+	})
+
+	t.Run("Invalid chats are reported and nothing is written", func(t *testing.T) {
+		t.Parallel()
+
+		badTime := "9am"
+		repo := newMockRepo()
+
+		data := Export{
+			Version: ExportVersion,
+			Chats: []repository.Chat{
+				{ID: 1, Users: []string{"German"}},
+				{ID: 2, Users: []string{"Anthon"}, Current: 5},
+				{ID: 3, Users: []string{"Vitaly"}, NotifyTime: &badTime},
+				{ID: 1, Users: []string{""}},
+			},
+		}
+
+		result, err := New(repo).Import(t.Context(), data, ImportOptions{Mode: ImportModeMerge})
+		require.NoError(t, err)
+		require.Len(t, result.Errors, 3)
+
+		require.Equal(t, int64(2), result.Errors[0].ChatID)
+		require.Equal(t, int64(3), result.Errors[1].ChatID)
+		require.Equal(t, 3, result.Errors[2].Index)
+		require.Contains(t, result.Errors[2].Error, "duplicate chat id")
+
+		require.Empty(t, repo.chats)
+	})
+
+	t.Run("Failed import publishes nothing", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &errorRepo{
+			mockRepo:  mockRepo{chats: make(map[int64]*repository.Chat)},
+			importErr: errDatabaseConnection,
+		}
+		repo.chats[1] = &repository.Chat{ID: 1, Users: []string{"German"}}
+
+		service := New(repo)
+
+		var events []Event
+		service.OnEvent(func(_ context.Context, event Event) {
+			events = append(events, event)
+		})
+
+		data := Export{
+			Version: ExportVersion,
+			Chats:   []repository.Chat{{ID: 2, Users: []string{"Anthon"}}},
+		}
+
+		_, err := service.Import(t.Context(), data, ImportOptions{Mode: ImportModeReplace})
+		require.ErrorIs(t, err, errDatabaseConnection)
+		require.Empty(t, events)
+		require.Len(t, repo.chats, 1)
+	})
+
+	t.Run("Unsupported version", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := New(newMockRepo()).Import(t.Context(), Export{Version: 99}, ImportOptions{Mode: ImportModeMerge})
+		require.ErrorIs(t, err, ErrUnsupportedExportVersion)
+	})
+
+	t.Run("Unknown mode", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := New(newMockRepo()).Import(t.Context(), Export{Version: ExportVersion}, ImportOptions{Mode: "append"})
+		require.ErrorIs(t, err, ErrUnknownImportMode)
+	})
+}
+
+func TestValidateNotifyTime(t *testing.T) {
+	t.Parallel()
+
+	for _, valid := range []string{"00:00", "09:30", "23:59"} {
+		require.NoError(t, ValidateNotifyTime(valid), valid)
+	}
+
+	for _, invalid := range []string{"", "9:30", "24:00", "12:60", "12-30", "12:30:00"} {
+		require.ErrorIs(t, ValidateNotifyTime(invalid), ErrInvalidNotifyTime, invalid)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 
 	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -15,6 +17,7 @@ var (
 
 type Repository interface {
 	GetChats(ctx context.Context) ([]repository.Chat, error)
+	FindChats(ctx context.Context, query repository.ChatQuery) ([]repository.Chat, error)
 	GetChat(ctx context.Context, chatID int64) (*repository.Chat, error)
 	GetSubscribedChats(ctx context.Context) ([]repository.Chat, error)
 
@@ -24,6 +27,15 @@ type Repository interface {
 	SetEstablish(ctx context.Context, chatID int64, users []string) error
 	Subscribe(ctx context.Context, chatID int64, notifyTime string) error
 	Unsubscribe(ctx context.Context, chatID int64) error
+
+	SaveChat(ctx context.Context, chat repository.Chat) error
+	DeleteChat(ctx context.Context, chatID int64) error
+	// ImportChats saves chats and deletes the chats with deleteIDs atomically,
+	// either all changes are stored or none.
+	ImportChats(ctx context.Context, chats []repository.Chat, deleteIDs []int64) error
+
+	AddDuty(ctx context.Context, duty repository.Duty) error
+	GetDuties(ctx context.Context, chatID int64) ([]repository.Duty, error)
 }
 
 type Stats struct {
@@ -32,15 +44,29 @@ type Stats struct {
 	AvgUsersPerChat float64 `json:"avgUsersPerChat"`
 }
 
+// ChatPage is a page of chats selected by FindChats, Next is nil on the last page.
+type ChatPage struct {
+	Chats []repository.Chat
+	Next  *repository.ChatCursor
+}
+
 type Service struct {
-	repo Repository
+	repo   Repository
+	events *EventBus
+	audit  AuditRecorder
 }
 
 func New(repo Repository) *Service {
-	return &Service{repo: repo}
+	return &Service{
+		repo:   repo,
+		events: NewEventBus(),
+	}
 }
 
-func (s *Service) Chats(ctx context.Context) ([]repository.Chat, error) {
+func (s *Service) Chats(ctx context.Context) (_ []repository.Chat, err error) {
+	ctx, span := startSpan(ctx, "Chats", 0)
+	defer func() { tracing.End(span, err) }()
+
 	chats, err := s.repo.GetChats(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get chats from repo: %w", err)
@@ -49,7 +75,37 @@ func (s *Service) Chats(ctx context.Context) ([]repository.Chat, error) {
 	return chats, nil
 }
 
-func (s *Service) Chat(ctx context.Context, chatID int64) (*repository.Chat, error) {
+// FindChats returns a page of chats selected by the query, all of them when the limit is 0.
+func (s *Service) FindChats(ctx context.Context, query repository.ChatQuery) (_ ChatPage, err error) {
+	ctx, span := startSpan(ctx, "FindChats", 0)
+	defer func() { tracing.End(span, err) }()
+
+	limit := query.Limit
+	if limit > 0 {
+		// Лишний чат показывает, что есть следующая страница
+		query.Limit++
+	}
+
+	chats, err := s.repo.FindChats(ctx, query)
+	if err != nil {
+		return ChatPage{}, fmt.Errorf("find chats in repo: %w", err)
+	}
+
+	page := ChatPage{Chats: chats}
+
+	if limit > 0 && len(chats) > limit {
+		page.Chats = chats[:limit]
+		next := repository.CursorOf(chats[limit-1])
+		page.Next = &next
+	}
+
+	return page, nil
+}
+
+func (s *Service) Chat(ctx context.Context, chatID int64) (_ *repository.Chat, err error) {
+	ctx, span := startSpan(ctx, "Chat", chatID)
+	defer func() { tracing.End(span, err) }()
+
 	chat, err := s.repo.GetChat(ctx, chatID)
 	if err != nil {
 		return nil, fmt.Errorf("get chat from repo: %w", err)
@@ -58,12 +114,20 @@ func (s *Service) Chat(ctx context.Context, chatID int64) (*repository.Chat, err
 	return chat, nil
 }
 
-func (s *Service) Stats(ctx context.Context) (Stats, error) {
+func (s *Service) Stats(ctx context.Context) (_ Stats, err error) {
+	ctx, span := startSpan(ctx, "Stats", 0)
+	defer func() { tracing.End(span, err) }()
+
 	chats, err := s.repo.GetChats(ctx)
 	if err != nil {
 		return Stats{}, fmt.Errorf("get chats for stats: %w", err)
 	}
 
+	return StatsOf(chats), nil
+}
+
+// StatsOf calculates statistics of the given chats.
+func StatsOf(chats []repository.Chat) Stats {
 	totalChats := len(chats)
 
 	totalUsers := 0
@@ -80,10 +144,13 @@ func (s *Service) Stats(ctx context.Context) (Stats, error) {
 		TotalChats:      totalChats,
 		TotalUsers:      totalUsers,
 		AvgUsersPerChat: avgUsers,
-	}, nil
+	}
 }
 
-func (s *Service) Who(ctx context.Context, chatID int64) (string, error) {
+func (s *Service) Who(ctx context.Context, chatID int64) (_ string, err error) {
+	ctx, span := startSpan(ctx, "Who", chatID)
+	defer func() { tracing.End(span, err) }()
+
 	username, err := s.repo.GetCurrent(ctx, chatID)
 
 	switch {
@@ -98,8 +165,16 @@ func (s *Service) Who(ctx context.Context, chatID int64) (string, error) {
 	}
 }
 
-func (s *Service) Next(ctx context.Context, chatID int64) (string, error) {
-	err := s.repo.SetNext(ctx, chatID)
+func (s *Service) Next(ctx context.Context, chatID int64) (_ string, err error) {
+	ctx, span := startSpan(ctx, "Next", chatID)
+	defer func() { tracing.End(span, err) }()
+
+	before := s.snapshot(ctx, chatID)
+
+	// Ошибку пустого или неизвестного чата вернёт SetNext
+	previous, _ := s.Who(ctx, chatID)
+
+	err = s.repo.SetNext(ctx, chatID)
 
 	switch {
 	case err == nil:
@@ -112,11 +187,32 @@ func (s *Service) Next(ctx context.Context, chatID int64) (string, error) {
 		return "", fmt.Errorf("get next from repo: %w", err)
 	}
 
-	return s.Who(ctx, chatID)
+	username, err := s.Who(ctx, chatID)
+	if err != nil {
+		return "", err
+	}
+
+	if previous != "" {
+		s.recordDuty(ctx, chatID, previous, false)
+	}
+
+	s.recordChange(ctx, chatID, EventRotationAdvanced, before)
+	s.events.Publish(ctx, RotationAdvanced{
+		EventMeta: s.newMeta(ctx, chatID),
+		Previous:  previous,
+		Current:   username,
+	})
+
+	return username, nil
 }
 
-func (s *Service) Prev(ctx context.Context, chatID int64) (string, error) {
-	err := s.repo.SetPrev(ctx, chatID)
+func (s *Service) Prev(ctx context.Context, chatID int64) (_ string, err error) {
+	ctx, span := startSpan(ctx, "Prev", chatID)
+	defer func() { tracing.End(span, err) }()
+
+	before := s.snapshot(ctx, chatID)
+
+	err = s.repo.SetPrev(ctx, chatID)
 
 	switch {
 	case err == nil:
@@ -129,19 +225,52 @@ func (s *Service) Prev(ctx context.Context, chatID int64) (string, error) {
 		return "", fmt.Errorf("get prev from repo: %w", err)
 	}
 
-	return s.Who(ctx, chatID)
+	username, err := s.Who(ctx, chatID)
+	if err != nil {
+		return "", err
+	}
+
+	// Дежурство вернувшегося участника не засчитывается
+	s.recordDuty(ctx, chatID, username, true)
+
+	s.recordChange(ctx, chatID, EventRotationReverted, before)
+	s.events.Publish(ctx, RotationReverted{EventMeta: s.newMeta(ctx, chatID), Current: username})
+
+	return username, nil
 }
 
-func (s *Service) SetEstablish(ctx context.Context, chatID int64, users []string) error {
+func (s *Service) SetEstablish(ctx context.Context, chatID int64, users []string) (err error) {
+	ctx, span := startSpan(ctx, "SetEstablish", chatID)
+	defer func() { tracing.End(span, err) }()
+
+	before, err := s.repo.GetChat(ctx, chatID)
+	if err != nil && !errors.Is(err, repository.ErrChatIsNotInitialize) {
+		return fmt.Errorf("get chat for establish: %w", err)
+	}
+
+	created := err != nil
+
 	if err := s.repo.SetEstablish(ctx, chatID, users); err != nil {
 		return fmt.Errorf("set establish from repo: %w", err)
 	}
 
+	s.recordChange(ctx, chatID, EventMembersChanged, before)
+
+	if created {
+		s.events.Publish(ctx, ChatCreated{EventMeta: s.newMeta(ctx, chatID)})
+	}
+
+	s.events.Publish(ctx, MembersChanged{EventMeta: s.newMeta(ctx, chatID), Users: users})
+
 	return nil
 }
 
-func (s *Service) Subscribe(ctx context.Context, chatID int64, notifyTime string) error {
-	if _, err := s.repo.GetChat(ctx, chatID); err != nil {
+func (s *Service) Subscribe(ctx context.Context, chatID int64, notifyTime string) (err error) {
+	ctx, span := startSpan(ctx, "Subscribe", chatID)
+	defer func() { tracing.End(span, err) }()
+
+	before, err := s.repo.GetChat(ctx, chatID)
+	if err != nil {
 		if errors.Is(err, repository.ErrChatIsNotInitialize) {
 			return ErrTryToInitialize
 		}
@@ -153,18 +282,34 @@ func (s *Service) Subscribe(ctx context.Context, chatID int64, notifyTime string
 		return fmt.Errorf("subscribe in repo: %w", err)
 	}
 
+	s.recordChange(ctx, chatID, EventSubscribed, before)
+
+	s.events.Publish(ctx, Subscribed{EventMeta: s.newMeta(ctx, chatID), NotifyTime: notifyTime})
+
 	return nil
 }
 
-func (s *Service) Unsubscribe(ctx context.Context, chatID int64) error {
+func (s *Service) Unsubscribe(ctx context.Context, chatID int64) (err error) {
+	ctx, span := startSpan(ctx, "Unsubscribe", chatID)
+	defer func() { tracing.End(span, err) }()
+
+	before := s.snapshot(ctx, chatID)
+
 	if err := s.repo.Unsubscribe(ctx, chatID); err != nil {
 		return fmt.Errorf("unsubscribe in repo: %w", err)
 	}
 
+	s.recordChange(ctx, chatID, EventUnsubscribed, before)
+
+	s.events.Publish(ctx, Unsubscribed{EventMeta: s.newMeta(ctx, chatID)})
+
 	return nil
 }
 
-func (s *Service) GetSubscribedChats(ctx context.Context) ([]repository.Chat, error) {
+func (s *Service) GetSubscribedChats(ctx context.Context) (_ []repository.Chat, err error) {
+	ctx, span := startSpan(ctx, "GetSubscribedChats", 0)
+	defer func() { tracing.End(span, err) }()
+
 	chats, err := s.repo.GetSubscribedChats(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get subscribed chats from repo: %w", err)
@@ -172,3 +317,14 @@ func (s *Service) GetSubscribedChats(ctx context.Context) ([]repository.Chat, er
 
 	return chats, nil
 }
+
+// startSpan starts the span of a service method, chatID 0 means the method is
+// not bound to a chat.
+func startSpan(ctx context.Context, method string, chatID int64) (context.Context, trace.Span) {
+	var opts []trace.SpanStartOption
+	if chatID != 0 {
+		opts = append(opts, trace.WithAttributes(tracing.KeyChatID.Int64(chatID)))
+	}
+
+	return tracing.Start(ctx, "trashmanager."+method, opts...)
+}
@@ -6,13 +6,17 @@ import (
 	"testing"
 
 	"github.com/6ermvH/trash-bot/internal/repository"
+	"github.com/6ermvH/trash-bot/internal/tracing"
+	"github.com/6ermvH/trash-bot/internal/tracing/tracingtest"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
 )
 
 var errDatabaseConnection = errors.New("database connection failed")
 
 type mockRepo struct {
-	chats map[int64]*repository.Chat
+	chats  map[int64]*repository.Chat
+	duties []repository.Duty
 }
 
 func newMockRepo() *mockRepo {
@@ -28,6 +32,12 @@ func (m *mockRepo) GetChats(ctx context.Context) ([]repository.Chat, error) {
 	return result, nil
 }
 
+func (m *mockRepo) FindChats(ctx context.Context, query repository.ChatQuery) ([]repository.Chat, error) {
+	chats, _ := m.GetChats(ctx)
+
+	return query.Apply(chats), nil
+}
+
 func (m *mockRepo) GetChat(ctx context.Context, chatID int64) (*repository.Chat, error) {
 	chat, ok := m.chats[chatID]
 	if !ok {
@@ -130,6 +140,49 @@ func (m *mockRepo) Unsubscribe(ctx context.Context, chatID int64) error {
 	return nil
 }
 
+func (m *mockRepo) SaveChat(ctx context.Context, chat repository.Chat) error {
+	chatCopy := chat
+	m.chats[chat.ID] = &chatCopy
+
+	return nil
+}
+
+func (m *mockRepo) DeleteChat(ctx context.Context, chatID int64) error {
+	delete(m.chats, chatID)
+
+	return nil
+}
+
+func (m *mockRepo) ImportChats(ctx context.Context, chats []repository.Chat, deleteIDs []int64) error {
+	for _, chat := range chats {
+		_ = m.SaveChat(ctx, chat)
+	}
+
+	for _, chatID := range deleteIDs {
+		_ = m.DeleteChat(ctx, chatID)
+	}
+
+	return nil
+}
+
+func (m *mockRepo) AddDuty(ctx context.Context, duty repository.Duty) error {
+	m.duties = append(m.duties, duty)
+
+	return nil
+}
+
+func (m *mockRepo) GetDuties(ctx context.Context, chatID int64) ([]repository.Duty, error) {
+	result := make([]repository.Duty, 0)
+
+	for _, duty := range m.duties {
+		if duty.ChatID == chatID {
+			result = append(result, duty)
+		}
+	}
+
+	return result, nil
+}
+
 func TestService_Subscribe(t *testing.T) {
 	t.Parallel()
 
@@ -223,6 +276,43 @@ func TestService_Unsubscribe(t *testing.T) {
 	})
 }
 
+func TestService_FindChats(t *testing.T) {
+	t.Parallel()
+
+	repo := newMockRepo()
+	for id := range int64(5) {
+		repo.chats[id] = &repository.Chat{ID: id, Users: []string{"German"}}
+	}
+
+	service := New(repo)
+	query := repository.ChatQuery{Limit: 2}
+
+	var ids []int64
+
+	for {
+		page, err := service.FindChats(t.Context(), query)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page.Chats), 2)
+
+		for _, chat := range page.Chats {
+			ids = append(ids, chat.ID)
+		}
+
+		if page.Next == nil {
+			break
+		}
+
+		query.After = page.Next
+	}
+
+	require.Equal(t, []int64{0, 1, 2, 3, 4}, ids)
+
+	page, err := service.FindChats(t.Context(), repository.ChatQuery{})
+	require.NoError(t, err)
+	require.Len(t, page.Chats, 5)
+	require.Nil(t, page.Next)
+}
+
 func TestService_GetSubscribedChats(t *testing.T) {
 	t.Parallel()
 
@@ -326,6 +416,15 @@ type errorRepo struct {
 	mockRepo
 
 	subscribeErr error
+	importErr    error
+}
+
+func (e *errorRepo) ImportChats(ctx context.Context, chats []repository.Chat, deleteIDs []int64) error {
+	if e.importErr != nil {
+		return e.importErr
+	}
+
+	return e.mockRepo.ImportChats(ctx, chats, deleteIDs)
 }
 
 func (e *errorRepo) Subscribe(ctx context.Context, chatID int64, notifyTime string) error {
@@ -360,3 +459,42 @@ func TestService_Subscribe_RepoError(t *testing.T) {
 		require.ErrorIs(t, err, errDatabaseConnection)
 	})
 }
+
+//nolint:paralleltest // sets the global tracer provider
+func TestService_Spans(t *testing.T) {
+	recorder := tracingtest.Record(t)
+
+	repo := newMockRepo()
+	repo.chats[1] = &repository.Chat{ID: 1, Users: []string{"German", "Ivan"}}
+
+	service := New(repo)
+
+	ctx, root := tracing.Start(t.Context(), "update")
+
+	_, err := service.Next(ctx, 1)
+	require.NoError(t, err)
+
+	_, err = service.Next(ctx, 2)
+	require.ErrorIs(t, err, ErrTryToInitialize)
+
+	root.End()
+
+	require.Equal(t, []string{
+		"trashmanager.Who", "trashmanager.Who", "trashmanager.Next",
+		"trashmanager.Who", "trashmanager.Next",
+		"update",
+	}, tracingtest.Names(recorder))
+
+	spans := recorder.Ended()
+	rootSpan := spans[len(spans)-1]
+
+	next := spans[2]
+	require.Equal(t, rootSpan.SpanContext().SpanID(), next.Parent().SpanID())
+	require.Equal(t, next.SpanContext().SpanID(), spans[0].Parent().SpanID(), "nested calls are children")
+	require.Contains(t, next.Attributes(), tracing.KeyChatID.Int64(1))
+	require.Equal(t, codes.Unset, next.Status().Code)
+
+	failed := spans[4]
+	require.Equal(t, codes.Error, failed.Status().Code)
+	require.Equal(t, ErrTryToInitialize.Error(), failed.Status().Description)
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/6ermvH/trash-bot/internal/tracing/tracingtest"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestSetup(t *testing.T) {
+	t.Parallel()
+
+	shutdown, err := Setup(t.Context(), Config{Exporter: ExporterNone})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(t.Context()))
+
+	_, err = Setup(t.Context(), Config{Exporter: "jaeger"})
+	require.ErrorIs(t, err, ErrUnknownExporter)
+}
+
+//nolint:paralleltest // sets the global tracer provider
+func TestStartEnd(t *testing.T) {
+	recorder := tracingtest.Record(t)
+
+	ctx, parent := Start(t.Context(), "parent")
+	_, child := Start(ctx, "child")
+
+	End(child, errors.New("failed"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	require.Equal(t, []string{"child", "parent"}, tracingtest.Names(recorder))
+
+	require.Equal(t, spans[1].SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+	require.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Len(t, spans[0].Events(), 1, "the error is recorded")
+	require.Equal(t, codes.Unset, spans[1].Status().Code)
+}